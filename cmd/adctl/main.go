@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"homework9/internal/adctl"
+	grpcPort "homework9/internal/ports/grpc"
+	"os"
+	"time"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	fs := flag.NewFlagSet("adctl", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, adctl.Usage())
+	}
+	configPath := fs.String("config", "", "config file, ~/.adctl.yaml by default")
+	server := fs.String("server", "", "server address")
+	output := fs.String("o", "", "output format: table, json or yaml")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of one command")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return 2
+	}
+
+	path, required := *configPath, true
+	if path == "" {
+		path, required = adctl.DefaultConfigPath(), false
+	}
+	cfg, err := adctl.LoadConfig(path, required)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "adctl: %v\n", err)
+		return 1
+	}
+	if *server != "" {
+		cfg.Server = *server
+	}
+	if *output != "" {
+		cfg.Output = *output
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	conn, err := adctl.Dial(ctx, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "adctl: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+
+	cli := adctl.CLI{Client: grpcPort.NewAdServiceClient(conn), Out: os.Stdout, Output: cfg.Output}
+	if err := cli.Run(ctx, fs.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "adctl: %v\n", err)
+		if errors.Is(err, adctl.ErrUsage) {
+			fmt.Fprint(os.Stderr, adctl.Usage())
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
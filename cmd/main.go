@@ -3,16 +3,35 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"homework9/internal/adapters/adcache"
 	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
 	"homework9/internal/adapters/userrepo"
+	"homework9/internal/ads"
 	"homework9/internal/app"
+	"homework9/internal/config"
+	"homework9/internal/contentfilter"
+	"homework9/internal/health"
+	"homework9/internal/idempotency"
+	"homework9/internal/logging"
+	"homework9/internal/metrics"
 	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/grpcv2"
 	"homework9/internal/ports/httpgin"
+	"homework9/internal/ratelimit"
+	"homework9/internal/scheduler"
+	"homework9/internal/tlsconfig"
+	"homework9/internal/tracing"
+	"homework9/internal/users"
 	"homework9/middleware"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
@@ -21,23 +40,161 @@ import (
 	"time"
 )
 
-const (
-	grpcPortAdr = ":50054"
-	httpPort    = ":9000"
-)
-
 func main() {
-	lis, err := net.Listen("tcp", grpcPortAdr)
+	cfg, opts, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		config.Usage(os.Stderr)
+		return
+	}
+	if err != nil {
+		fatal("failed to load config", err)
+	}
+	if opts.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fatal("failed to print config", err)
+		}
+		return
+	}
+	level, err := logging.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		fatal("invalid log level", err)
+	}
+	slog.SetDefault(logging.New(os.Stderr, level))
+	exporter, err := tracing.NewExporter(cfg.Tracing.Exporter, cfg.Tracing.File)
+	if err != nil {
+		fatal("failed to create span exporter", err)
+	}
+	shutdownTracing := tracing.Setup(exporter)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("failed to flush spans", "error", err)
+		}
+	}()
+
+	lis, err := net.Listen("tcp", cfg.GRPC.Addr)
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		fatal("failed to listen", err)
+	}
+	repoAds, repoUsers, repoMessages, repoReports, err := newRepositories(cfg.Storage)
+	if err != nil {
+		fatal("failed to open storage", err)
+	}
+	if cfg.AdminEmail != "" {
+		if err := createAdmin(repoUsers, cfg.AdminEmail); err != nil {
+			fatal("failed to create admin", err)
+		}
+	}
+	checker := health.New(health.PingCheck("storage", repoAds, repoUsers, repoMessages, repoReports))
+	m := metrics.New()
+	m.MustRegister(metrics.NewDomainCollector(repoAds, repoUsers))
+	unary := []grpc.UnaryServerInterceptor{
+		middleware.PanicUnaryInterceptor,
+		middleware.MetricsUnaryServerInterceptor(m),
+		middleware.TracingUnaryServerInterceptor,
+		middleware.LoggerUnaryServerInterceptor,
+		middleware.IdentityUnaryServerInterceptor,
+		middleware.ActorUnaryServerInterceptor,
+	}
+	httpOpts := []httpgin.Option{
+		httpgin.WithTimeouts(cfg.HTTP.ReadTimeout.Duration, cfg.HTTP.WriteTimeout.Duration),
+		httpgin.WithMetrics(m),
+		httpgin.WithHealth(checker),
+	}
+	var grpcOpts []grpc.ServerOption
+	var jobs []scheduler.Job
+	if cfg.TLS.Enabled() {
+		reloader, err := tlsconfig.NewReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			fatal("failed to load tls certificate", err)
+		}
+		// client certificates are for service-to-service calls over gRPC,
+		// browsers and curl talk to the HTTP port without them
+		grpcTLS, err := tlsconfig.Server(reloader, cfg.TLS.ClientCAFile)
+		if err != nil {
+			fatal("failed to load tls client ca", err)
+		}
+		httpTLS, err := tlsconfig.Server(reloader, "")
+		if err != nil {
+			fatal("failed to configure tls", err)
+		}
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(grpcTLS)))
+		httpOpts = append(httpOpts, httpgin.WithTLS(httpTLS))
+		jobs = append(jobs, scheduler.Job{Name: "reload tls certificate", Interval: 10 * time.Second, Run: reloader.Reload})
+	}
+	if cfg.RateLimit.Enabled {
+		limiter := newLimiter(cfg.RateLimit)
+		unary = append(unary, middleware.RateLimitUnaryServerInterceptor(limiter))
+		httpOpts = append(httpOpts, httpgin.WithRateLimit(limiter))
+	}
+	if cfg.Idempotency.TTL.Duration > 0 {
+		store := idempotency.New(cfg.Idempotency.TTL.Duration)
+		unary = append(unary, middleware.IdempotencyUnaryServerInterceptor(store))
+		httpOpts = append(httpOpts, httpgin.WithIdempotency(store))
+	}
+	grpcServer := grpc.NewServer(append(grpcOpts,
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(
+			middleware.PanicStreamInterceptor,
+			middleware.MetricsStreamServerInterceptor(m),
+			middleware.TracingStreamServerInterceptor,
+			middleware.LoggerStreamServerInterceptor,
+			middleware.IdentityStreamServerInterceptor,
+			middleware.ActorStreamServerInterceptor,
+		),
+	)...)
+	filters, err := contentFilters(cfg.Moderation.BannedWordsFile)
+	if err != nil {
+		fatal("failed to load content filters", err)
+	}
+	appOpts := []app.Option{
+		app.WithContentFilters(filters...),
+		app.WithExpiryNotifier(logExpiryNotifier{}, 3*24*time.Hour),
+		app.WithDailyAdQuota(cfg.RateLimit.AdsPerDay),
+	}
+	if cfg.Moderation.Premoderation {
+		appOpts = append(appOpts, app.WithPremoderation())
 	}
-	repoAds := adrepo.New()
-	repoUsers := userrepo.New()
-	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(middleware.LoggerUnaryServerInterceptor, middleware.PanicUnaryInterceptor))
-	svc := grpcPort.NewService(app.NewApp(repoAds, repoUsers))
+	if len(cfg.TLS.Clients) > 0 {
+		clients := make(map[string]users.Role, len(cfg.TLS.Clients))
+		for name, role := range cfg.TLS.Clients {
+			clients[name] = users.Role(role)
+		}
+		appOpts = append(appOpts, app.WithClients(clients))
+	}
+	adRepo := tracing.AdRepository(repoAds)
+	if cfg.Cache.Size > 0 {
+		// the cache sits in front of the traced repository, the spans show
+		// only the reads that reach the storage
+		cache := adcache.New(adRepo, cfg.Cache.Size, cfg.Cache.TTL.Duration)
+		m.MustRegister(cache)
+		adRepo = cache
+	}
+	a := tracing.App(app.NewApp(
+		adRepo,
+		tracing.UserRepository(repoUsers),
+		tracing.MessageRepository(repoMessages),
+		tracing.ReportRepository(repoReports),
+		appOpts...,
+	))
+	svc := grpcPort.NewService(a)
 	grpcPort.RegisterAdServiceServer(grpcServer, svc)
+	grpcv2.RegisterAdServiceServer(grpcServer, grpcv2.NewService(a))
+	healthpb.RegisterHealthServer(grpcServer, health.NewGRPCServer(checker,
+		grpcPort.AdService_ServiceDesc.ServiceName, grpcv2.AdService_ServiceDesc.ServiceName))
+
+	gateway, err := grpcPort.NewGateway(context.Background(), svc)
+	if err != nil {
+		fatal("failed to create grpc gateway", err)
+	}
+	httpOpts = append(httpOpts, httpgin.WithGateway(gateway))
+	httpServer := httpgin.NewHTTPServer(cfg.HTTP.Addr, a, httpOpts...)
 
-	httpServer := httpgin.NewHTTPServer(httpPort, app.NewApp(repoAds, repoUsers))
+	sched := scheduler.New(append(jobs,
+		scheduler.Job{Name: "expire ads", Interval: time.Minute, Run: a.ExpireAds},
+		scheduler.Job{Name: "publish scheduled ads", Interval: 10 * time.Second, Run: a.PublishScheduledAds},
+	)...)
 
 	eg, ctx := errgroup.WithContext(context.Background())
 
@@ -48,7 +205,14 @@ func main() {
 	eg.Go(func() error {
 		select {
 		case s := <-sigQuit:
-			log.Printf("captured signal: %v\n", s)
+			slog.Info("captured signal", "signal", s.String())
+			// stop getting traffic first, the servers go down after the delay
+			checker.Shutdown()
+			select {
+			case <-time.After(cfg.ShutdownDelay.Duration):
+			case <-sigQuit:
+				slog.Info("second signal, skipping the shutdown delay")
+			}
 			return fmt.Errorf("captured signal: %v", s)
 		case <-ctx.Done():
 			return nil
@@ -57,13 +221,23 @@ func main() {
 
 	// run grpc server
 	eg.Go(func() error {
-		log.Printf("starting grpc server, listening on %s\n", grpcPortAdr)
-		defer log.Printf("close grpc server listening on %s\n", grpcPortAdr)
+		slog.Info("starting grpc server", "addr", cfg.GRPC.Addr)
+		defer slog.Info("grpc server closed", "addr", cfg.GRPC.Addr)
 
 		errCh := make(chan error)
 
 		defer func() {
-			grpcServer.GracefulStop()
+			stopped := make(chan struct{})
+			go func() {
+				grpcServer.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-time.After(cfg.ShutdownTimeout.Duration):
+				slog.Error("grpc server did not stop in time, closing connections", "addr", cfg.GRPC.Addr)
+				grpcServer.Stop()
+			}
 			_ = lis.Close()
 
 			close(errCh)
@@ -85,17 +259,17 @@ func main() {
 
 	// run http server
 	eg.Go(func() error {
-		log.Printf("starting http server, listening on %s\n", httpPort)
-		defer log.Printf("close http server listening on %s\n", httpPort)
+		slog.Info("starting http server", "addr", cfg.HTTP.Addr)
+		defer slog.Info("http server closed", "addr", cfg.HTTP.Addr)
 
 		errCh := make(chan error)
 
 		defer func() {
-			shCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			shCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout.Duration)
 			defer cancel()
 
 			if err := httpServer.Shutdown(shCtx); err != nil {
-				log.Printf("can't close http server listening on %s: %s", httpServer, err.Error()) // nolint
+				slog.Error("can't close http server", "addr", cfg.HTTP.Addr, "error", err)
 			}
 
 			close(errCh)
@@ -115,10 +289,71 @@ func main() {
 		}
 	})
 
+	// run background jobs
+	eg.Go(func() error {
+		slog.Info("starting scheduler")
+		defer slog.Info("scheduler stopped")
+		return sched.Run(ctx)
+	})
+
 	if err := eg.Wait(); err != nil {
-		log.Printf("gracefully shutting down the servers: %s\n", err.Error())
+		slog.Info("gracefully shutting down the servers", "reason", err.Error())
 		return
 	}
 
-	log.Println("servers were successfully shutdown")
+	slog.Info("servers were successfully shutdown")
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+func newLimiter(cfg config.RateLimitConfig) *ratelimit.Limiter {
+	routes := make(map[string]ratelimit.Limit, len(cfg.Routes))
+	for route, l := range cfg.Routes {
+		routes[route] = ratelimit.Limit{RPS: l.RPS, Burst: l.Burst}
+	}
+	return ratelimit.New(ratelimit.Limit{RPS: cfg.RPS, Burst: cfg.Burst}, routes)
+}
+
+// newRepositories opens the storage backend, config validation guarantees
+// it is known.
+func newRepositories(cfg config.StorageConfig) (app.AdRepository, app.UserRepository, app.MessageRepository, app.ReportRepository, error) {
+	switch cfg.Backend {
+	case "memory":
+		return adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New(), nil
+	}
+	return nil, nil, nil, nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+}
+
+// createAdmin seeds the first administrator, other roles are granted through the API.
+func createAdmin(repo app.UserRepository, email string) error {
+	ctx := context.Background()
+	admin, err := repo.CreateUser(ctx, "admin", email)
+	if err != nil {
+		return err
+	}
+	_, err = repo.SetUserRole(ctx, admin.ID, users.RoleAdmin)
+	return err
+}
+
+// contentFilters uses the banned words from path instead of the built-in lists when path is set.
+func contentFilters(path string) ([]app.ContentFilter, error) {
+	if path == "" {
+		return contentfilter.Default(), nil
+	}
+	words, err := contentfilter.LoadBannedWords(path)
+	if err != nil {
+		return nil, err
+	}
+	return []app.ContentFilter{words, contentfilter.Contacts{}, contentfilter.Caps{}, contentfilter.Duplicates{}}, nil
+}
+
+// logExpiryNotifier stands in for e-mail notifications until the service can send them.
+type logExpiryNotifier struct{}
+
+func (logExpiryNotifier) NotifyExpiringSoon(ctx context.Context, ad ads.Ad) error {
+	slog.InfoContext(ctx, "ad expires soon", "ad_id", ad.ID, "user_id", ad.AuthorID, "expires_at", ad.ExpiresAt)
+	return nil
 }
@@ -0,0 +1,236 @@
+// Package adcache keeps the hot reads of an ad repository in memory.
+package adcache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+)
+
+var (
+	requestsDesc  = prometheus.NewDesc("ad_cache_requests_total", "Reads of the ad cache, by kind and result.", []string{"kind", "result"}, nil)
+	evictionsDesc = prometheus.NewDesc("ad_cache_evictions_total", "Entries dropped from the ad cache to make room.", nil, nil)
+	sizeDesc      = prometheus.NewDesc("ad_cache_size", "Ads held by the ad cache.", nil, nil)
+)
+
+const (
+	kindAd        = "ad"
+	kindPublished = "published"
+)
+
+type Option func(*Repo)
+
+func WithClock(now func() time.Time) Option {
+	return func(r *Repo) {
+		r.now = now
+	}
+}
+
+// Repo is a read-through cache in front of an AdRepository. GetAd and the
+// published listing of GetAds are served from an LRU holding at most size
+// ads for ttl, the writes through Repo drop the entries they change.
+type Repo struct {
+	next app.AdRepository
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[entryKey]*list.Element
+	used    int
+	// version changes on every write, a read started before a write does
+	// not store what it read
+	version   uint64
+	hits      map[string]uint64
+	misses    map[string]uint64
+	evictions uint64
+}
+
+type entryKey struct {
+	kind string
+	id   int64
+}
+
+type entry struct {
+	key     entryKey
+	ad      ads.Ad
+	list    []ads.Ad
+	cost    int
+	expires time.Time
+}
+
+func New(next app.AdRepository, size int, ttl time.Duration, opts ...Option) *Repo {
+	r := &Repo{
+		next:    next,
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		lru:     list.New(),
+		entries: make(map[entryKey]*list.Element),
+		hits:    make(map[string]uint64),
+		misses:  make(map[string]uint64),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *Repo) GetAd(ctx context.Context, index int64) (ads.Ad, error) {
+	key := entryKey{kindAd, index}
+	e, version := r.get(key)
+	if e != nil {
+		return e.ad, nil
+	}
+	ad, err := r.next.GetAd(ctx, index)
+	if err == nil {
+		r.put(&entry{key: key, ad: ad, cost: 1}, version)
+	}
+	return ad, err
+}
+
+func (r *Repo) GetAds(ctx context.Context) ([]ads.Ad, error) {
+	key := entryKey{kind: kindPublished}
+	e, version := r.get(key)
+	if e != nil {
+		return append([]ads.Ad(nil), e.list...), nil
+	}
+	published, err := r.next.GetAds(ctx)
+	if err == nil {
+		r.put(&entry{key: key, list: append([]ads.Ad(nil), published...), cost: max(len(published), 1)}, version)
+	}
+	return published, err
+}
+
+func (r *Repo) CreateAd(ctx context.Context, Title string, Text string, UserID int64) (ads.Ad, error) {
+	// a new ad is a draft, it is in none of the cached reads
+	return r.next.CreateAd(ctx, Title, Text, UserID)
+}
+
+func (r *Repo) ChangeAdStatus(ctx context.Context, adID int64, Status ads.Status, Reason string) (ads.Ad, error) {
+	defer r.drop(adID)
+	return r.next.ChangeAdStatus(ctx, adID, Status, Reason)
+}
+
+func (r *Repo) UpdateAd(ctx context.Context, adID int64, Title string, Text string) (ads.Ad, error) {
+	defer r.drop(adID)
+	return r.next.UpdateAd(ctx, adID, Title, Text)
+}
+
+func (r *Repo) SetAdExpiration(ctx context.Context, adID int64, ExpiresAt time.Time, ExpiryNotified bool) (ads.Ad, error) {
+	defer r.drop(adID)
+	return r.next.SetAdExpiration(ctx, adID, ExpiresAt, ExpiryNotified)
+}
+
+func (r *Repo) SetAdPublishAt(ctx context.Context, adID int64, PublishAt time.Time) (ads.Ad, error) {
+	defer r.drop(adID)
+	return r.next.SetAdPublishAt(ctx, adID, PublishAt)
+}
+
+func (r *Repo) ImportAd(ctx context.Context, ad ads.Ad, PreserveID bool) (ads.Ad, error) {
+	imported, err := r.next.ImportAd(ctx, ad, PreserveID)
+	r.drop(imported.ID)
+	return imported, err
+}
+
+func (r *Repo) DeleteAd(ctx context.Context, adID int64) error {
+	defer r.drop(adID)
+	return r.next.DeleteAd(ctx, adID)
+}
+
+func (r *Repo) GetAdByTitle(ctx context.Context, Title string) (ads.Ad, error) {
+	return r.next.GetAdByTitle(ctx, Title)
+}
+
+func (r *Repo) GetAdsByStatus(ctx context.Context, Status ads.Status) ([]ads.Ad, error) {
+	return r.next.GetAdsByStatus(ctx, Status)
+}
+
+func (r *Repo) GetAdsByAuthor(ctx context.Context, authorID int64) ([]ads.Ad, error) {
+	return r.next.GetAdsByAuthor(ctx, authorID)
+}
+
+func (r *Repo) GetScheduledAds(ctx context.Context, before time.Time) ([]ads.Ad, error) {
+	return r.next.GetScheduledAds(ctx, before)
+}
+
+func (r *Repo) GetAllAds(ctx context.Context) ([]ads.Ad, error) {
+	return r.next.GetAllAds(ctx)
+}
+
+// get returns the live entry of key, or nil and the version to store the
+// value read from the repository with.
+func (r *Repo) get(key entryKey) (*entry, uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if el, ok := r.entries[key]; ok {
+		e := el.Value.(*entry)
+		if r.now().Before(e.expires) {
+			r.lru.MoveToFront(el)
+			r.hits[key.kind]++
+			return e, r.version
+		}
+		r.remove(el)
+	}
+	r.misses[key.kind]++
+	return nil, r.version
+}
+
+func (r *Repo) put(e *entry, version uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if version != r.version || e.cost > r.size {
+		return
+	}
+	if el, ok := r.entries[e.key]; ok {
+		r.remove(el)
+	}
+	e.expires = r.now().Add(r.ttl)
+	r.entries[e.key] = r.lru.PushFront(e)
+	r.used += e.cost
+	for r.used > r.size {
+		r.remove(r.lru.Back())
+		r.evictions++
+	}
+}
+
+// drop forgets the ad and the listing it may be in.
+func (r *Repo) drop(adID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.version++
+	for _, key := range []entryKey{{kindAd, adID}, {kind: kindPublished}} {
+		if el, ok := r.entries[key]; ok {
+			r.remove(el)
+		}
+	}
+}
+
+func (r *Repo) remove(el *list.Element) {
+	e := r.lru.Remove(el).(*entry)
+	delete(r.entries, e.key)
+	r.used -= e.cost
+}
+
+func (r *Repo) Describe(ch chan<- *prometheus.Desc) {
+	ch <- requestsDesc
+	ch <- evictionsDesc
+	ch <- sizeDesc
+}
+
+func (r *Repo) Collect(ch chan<- prometheus.Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, kind := range []string{kindAd, kindPublished} {
+		ch <- prometheus.MustNewConstMetric(requestsDesc, prometheus.CounterValue, float64(r.hits[kind]), kind, "hit")
+		ch <- prometheus.MustNewConstMetric(requestsDesc, prometheus.CounterValue, float64(r.misses[kind]), kind, "miss")
+	}
+	ch <- prometheus.MustNewConstMetric(evictionsDesc, prometheus.CounterValue, float64(r.evictions))
+	ch <- prometheus.MustNewConstMetric(sizeDesc, prometheus.GaugeValue, float64(r.used))
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"homework9/internal/ads"
 	"homework9/internal/app"
+	"log/slog"
+	"sort"
 	"sync"
 	"time"
 )
@@ -21,55 +23,69 @@ type adRepo struct {
 
 func (r *adRepo) CreateAd(ctx context.Context, Title string, Text string, UserID int64) (ads.Ad, error) {
 	r.mutex.Lock()
-	newAd := ads.Ad{ID: r.idx, Title: Title, Text: Text, AuthorID: UserID, DateCreate: time.Now().UTC(), DateUpdate: time.Now().UTC()}
+	newAd := ads.Ad{ID: r.idx, Title: Title, Text: Text, AuthorID: UserID, Status: ads.StatusDraft, DateCreate: time.Now().UTC(), DateUpdate: time.Now().UTC()}
 	r.ads[r.idx] = newAd
 	r.idx++
 	r.mutex.Unlock()
+	slog.DebugContext(ctx, "adrepo: ad stored", "ad_id", newAd.ID)
 	return newAd, nil
 }
-func (r *adRepo) ChangeAdStatus(ctx context.Context, adID int64, Published bool) (ads.Ad, error) {
+func (r *adRepo) ChangeAdStatus(ctx context.Context, adID int64, Status ads.Status, Reason string) (ads.Ad, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	ad, ok := r.ads[adID]
 	if !ok {
-		return ads.Ad{}, fmt.Errorf("can not find ad")
+		return ads.Ad{}, fmt.Errorf("ad %w", app.ErrNotFound)
 	}
-	ad.Published = Published
+	ad.Status = Status
+	ad.RejectReason = Reason
 	ad.DateUpdate = time.Now().UTC()
 	r.ads[adID] = ad
+	slog.DebugContext(ctx, "adrepo: ad status stored", "ad_id", adID, "status", Status)
 	return ad, nil
 }
 func (r *adRepo) UpdateAd(ctx context.Context, adID int64, Title string, Text string) (ads.Ad, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	ad, ok := r.ads[adID]
 	if !ok {
-		return ads.Ad{}, fmt.Errorf("can not find ad")
+		return ads.Ad{}, fmt.Errorf("ad %w", app.ErrNotFound)
 	}
 	ad.Text = Text
 	ad.Title = Title
 	ad.DateUpdate = time.Now().UTC()
 	r.ads[adID] = ad
+	slog.DebugContext(ctx, "adrepo: ad updated", "ad_id", adID)
 	return ad, nil
 }
 
 func (r *adRepo) GetAd(ctx context.Context, index int64) (ads.Ad, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	ad, ok := r.ads[index]
 	if !ok {
-		return ads.Ad{}, fmt.Errorf("can not find ad")
+		return ads.Ad{}, fmt.Errorf("ad %w", app.ErrNotFound)
 	}
 	return ad, nil
 }
 
 func (r *adRepo) GetAdByTitle(ctx context.Context, Title string) (ads.Ad, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	for i := range r.ads {
 		if r.ads[i].Title == Title {
 			return r.ads[i], nil
 		}
 	}
-	return ads.Ad{}, fmt.Errorf("ad not found")
+	return ads.Ad{}, fmt.Errorf("ad %w", app.ErrNotFound)
 }
 
 func (r *adRepo) GetAdsByUserID(ctx context.Context, ID int64) []ads.Ad {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	ads := make([]ads.Ad, 0)
 	for _, ad := range ads {
-		if ad.AuthorID == ID && ad.Published {
+		if ad.AuthorID == ID && ad.Published() {
 			ads = append(ads, ad)
 		}
 	}
@@ -77,19 +93,129 @@ func (r *adRepo) GetAdsByUserID(ctx context.Context, ID int64) []ads.Ad {
 }
 
 func (r *adRepo) GetAds(ctx context.Context) ([]ads.Ad, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	ads := make([]ads.Ad, 0)
+	for _, ad := range r.ads {
+		if ad.Published() {
+			ads = append(ads, ad)
+		}
+	}
+	sortByID(ads)
+	return ads, nil
+}
+
+func (r *adRepo) GetAdsByStatus(ctx context.Context, Status ads.Status) ([]ads.Ad, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	ads := make([]ads.Ad, 0)
+	for _, ad := range r.ads {
+		if ad.Status == Status {
+			ads = append(ads, ad)
+		}
+	}
+	sortByID(ads)
+	return ads, nil
+}
+
+func (r *adRepo) GetAdsByAuthor(ctx context.Context, authorID int64) ([]ads.Ad, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	ads := make([]ads.Ad, 0)
+	for _, ad := range r.ads {
+		if ad.AuthorID == authorID {
+			ads = append(ads, ad)
+		}
+	}
+	sortByID(ads)
+	return ads, nil
+}
+
+func (r *adRepo) SetAdExpiration(ctx context.Context, adID int64, ExpiresAt time.Time, ExpiryNotified bool) (ads.Ad, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	ad, ok := r.ads[adID]
+	if !ok {
+		return ads.Ad{}, fmt.Errorf("ad %w", app.ErrNotFound)
+	}
+	ad.ExpiresAt = ExpiresAt
+	ad.ExpiryNotified = ExpiryNotified
+	r.ads[adID] = ad
+	return ad, nil
+}
+
+func (r *adRepo) SetAdPublishAt(ctx context.Context, adID int64, PublishAt time.Time) (ads.Ad, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	ad, ok := r.ads[adID]
+	if !ok {
+		return ads.Ad{}, fmt.Errorf("ad %w", app.ErrNotFound)
+	}
+	ad.PublishAt = PublishAt
+	ad.DateUpdate = time.Now().UTC()
+	r.ads[adID] = ad
+	return ad, nil
+}
+
+func (r *adRepo) GetScheduledAds(ctx context.Context, before time.Time) ([]ads.Ad, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	ads := make([]ads.Ad, 0)
 	for _, ad := range r.ads {
-		if ad.Published {
+		if ad.Scheduled() && !ad.PublishAt.After(before) {
 			ads = append(ads, ad)
 		}
 	}
+	sortByID(ads)
 	return ads, nil
 }
 
+func (r *adRepo) GetAllAds(ctx context.Context) ([]ads.Ad, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	ads := make([]ads.Ad, 0, len(r.ads))
+	for _, ad := range r.ads {
+		ads = append(ads, ad)
+	}
+	sortByID(ads)
+	return ads, nil
+}
+
+// ImportAd stores the ad as is. With PreserveID the ad keeps its ID, which
+// must be free, otherwise it gets the next one.
+func (r *adRepo) ImportAd(ctx context.Context, ad ads.Ad, PreserveID bool) (ads.Ad, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if PreserveID {
+		if ad.ID < 0 {
+			return ads.Ad{}, fmt.Errorf("invalid ad id %d", ad.ID)
+		}
+		if _, ok := r.ads[ad.ID]; ok {
+			return ads.Ad{}, fmt.Errorf("ad %d already exists", ad.ID)
+		}
+		if ad.ID >= r.idx {
+			r.idx = ad.ID + 1
+		}
+	} else {
+		ad.ID = r.idx
+		r.idx++
+	}
+	if ad.DateCreate.IsZero() {
+		ad.DateCreate = time.Now().UTC()
+	}
+	if ad.DateUpdate.IsZero() {
+		ad.DateUpdate = ad.DateCreate
+	}
+	r.ads[ad.ID] = ad
+	return ad, nil
+}
+
 func (r *adRepo) GetAdsByTime(ctx context.Context, Time time.Time) []ads.Ad {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	ads := make([]ads.Ad, 0)
 	for _, ad := range ads {
-		if ad.DateCreate == Time && ad.Published {
+		if ad.DateCreate == Time && ad.Published() {
 			ads = append(ads, ad)
 		}
 	}
@@ -97,10 +223,19 @@ func (r *adRepo) GetAdsByTime(ctx context.Context, Time time.Time) []ads.Ad {
 }
 
 func (r *adRepo) DeleteAd(ctx context.Context, adID int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	_, ok := r.ads[adID]
 	if !ok {
-		return fmt.Errorf("can not find ad")
+		return fmt.Errorf("ad %w", app.ErrNotFound)
 	}
 	delete(r.ads, adID)
+	slog.DebugContext(ctx, "adrepo: ad deleted", "ad_id", adID)
 	return nil
 }
+
+func sortByID(ads []ads.Ad) {
+	sort.Slice(ads, func(i, j int) bool {
+		return ads[i].ID < ads[j].ID
+	})
+}
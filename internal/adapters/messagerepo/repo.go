@@ -0,0 +1,142 @@
+package messagerepo
+
+import (
+	"context"
+	"fmt"
+	"homework9/internal/app"
+	"homework9/internal/messages"
+	"sort"
+	"sync"
+	"time"
+)
+
+func New() app.MessageRepository {
+	return &messageRepo{
+		conversations: make(map[int64]messages.Conversation, 0),
+		messages:      make(map[int64][]messages.Message, 0),
+	}
+}
+
+type messageRepo struct {
+	conversations map[int64]messages.Conversation
+	messages      map[int64][]messages.Message
+	convIdx       int64
+	msgIdx        int64
+	mutex         sync.Mutex
+}
+
+func (r *messageRepo) GetOrCreateConversation(ctx context.Context, adID int64, buyerID int64, sellerID int64) (messages.Conversation, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, conv := range r.conversations {
+		if conv.AdID == adID && conv.BuyerID == buyerID {
+			return conv, nil
+		}
+	}
+	conv := messages.Conversation{
+		ID:         r.convIdx,
+		AdID:       adID,
+		BuyerID:    buyerID,
+		SellerID:   sellerID,
+		DateCreate: time.Now().UTC(),
+		DateUpdate: time.Now().UTC(),
+	}
+	r.conversations[r.convIdx] = conv
+	r.convIdx++
+	return conv, nil
+}
+
+func (r *messageRepo) GetConversation(ctx context.Context, ID int64) (messages.Conversation, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	conv, ok := r.conversations[ID]
+	if !ok {
+		return messages.Conversation{}, fmt.Errorf("conversation %w", app.ErrNotFound)
+	}
+	return conv, nil
+}
+
+func (r *messageRepo) GetConversationsByUser(ctx context.Context, userID int64) ([]messages.Conversation, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	convs := make([]messages.Conversation, 0)
+	for _, conv := range r.conversations {
+		if conv.HasParticipant(userID) {
+			convs = append(convs, conv)
+		}
+	}
+	sortConversations(convs)
+	return convs, nil
+}
+
+func (r *messageRepo) GetConversationsByAd(ctx context.Context, adID int64) ([]messages.Conversation, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	convs := make([]messages.Conversation, 0)
+	for _, conv := range r.conversations {
+		if conv.AdID == adID {
+			convs = append(convs, conv)
+		}
+	}
+	sortConversations(convs)
+	return convs, nil
+}
+
+func (r *messageRepo) CreateMessage(ctx context.Context, conversationID int64, senderID int64, Text string) (messages.Message, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	conv, ok := r.conversations[conversationID]
+	if !ok {
+		return messages.Message{}, fmt.Errorf("conversation %w", app.ErrNotFound)
+	}
+	msg := messages.Message{
+		ID:             r.msgIdx,
+		ConversationID: conversationID,
+		SenderID:       senderID,
+		Text:           Text,
+		DateCreate:     time.Now().UTC(),
+	}
+	r.messages[conversationID] = append(r.messages[conversationID], msg)
+	r.msgIdx++
+	conv.DateUpdate = msg.DateCreate
+	r.conversations[conversationID] = conv
+	return msg, nil
+}
+
+func (r *messageRepo) GetMessages(ctx context.Context, conversationID int64) ([]messages.Message, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.conversations[conversationID]; !ok {
+		return nil, fmt.Errorf("conversation %w", app.ErrNotFound)
+	}
+	msgs := make([]messages.Message, len(r.messages[conversationID]))
+	copy(msgs, r.messages[conversationID])
+	return msgs, nil
+}
+
+func (r *messageRepo) MarkRead(ctx context.Context, conversationID int64, readerID int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.conversations[conversationID]; !ok {
+		return fmt.Errorf("conversation %w", app.ErrNotFound)
+	}
+	now := time.Now().UTC()
+	msgs := r.messages[conversationID]
+	for i := range msgs {
+		if msgs[i].SenderID != readerID && !msgs[i].Read {
+			msgs[i].Read = true
+			msgs[i].DateRead = now
+		}
+	}
+	return nil
+}
+
+// sortConversations puts the most recently active threads first.
+func sortConversations(convs []messages.Conversation) {
+	sort.Slice(convs, func(i, j int) bool {
+		if convs[i].DateUpdate.Equal(convs[j].DateUpdate) {
+			return convs[i].ID > convs[j].ID
+		}
+		return convs[i].DateUpdate.After(convs[j].DateUpdate)
+	})
+}
@@ -0,0 +1,73 @@
+package reportrepo
+
+import (
+	"context"
+	"homework9/internal/app"
+	"homework9/internal/reports"
+	"sync"
+	"time"
+)
+
+func New() app.ReportRepository {
+	return &reportRepo{reports: make(map[int64]reports.Report, 0)}
+}
+
+type reportRepo struct {
+	reports map[int64]reports.Report
+	idx     int64
+	mutex   sync.Mutex
+}
+
+func (r *reportRepo) CreateReport(ctx context.Context, adID int64, reporterID int64, Reason reports.Reason, Comment string) (reports.Report, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	report := reports.Report{
+		ID:         r.idx,
+		AdID:       adID,
+		ReporterID: reporterID,
+		Reason:     Reason,
+		Comment:    Comment,
+		DateCreate: time.Now().UTC(),
+	}
+	r.reports[r.idx] = report
+	r.idx++
+	return report, nil
+}
+
+func (r *reportRepo) GetOpenReports(ctx context.Context) ([]reports.Report, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	list := make([]reports.Report, 0)
+	for i := int64(0); i < r.idx; i++ {
+		report, ok := r.reports[i]
+		if ok && report.Open() {
+			list = append(list, report)
+		}
+	}
+	return list, nil
+}
+
+func (r *reportRepo) GetOpenReportsByAd(ctx context.Context, adID int64) ([]reports.Report, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	list := make([]reports.Report, 0)
+	for i := int64(0); i < r.idx; i++ {
+		report, ok := r.reports[i]
+		if ok && report.AdID == adID && report.Open() {
+			list = append(list, report)
+		}
+	}
+	return list, nil
+}
+
+func (r *reportRepo) ResolveReports(ctx context.Context, adID int64, Resolution reports.Resolution) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for id, report := range r.reports {
+		if report.AdID == adID && report.Open() {
+			report.Resolution = Resolution
+			r.reports[id] = report
+		}
+	}
+	return nil
+}
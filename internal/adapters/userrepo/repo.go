@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"homework9/internal/app"
 	"homework9/internal/users"
+	"log/slog"
 	"sync"
 )
 
@@ -19,35 +20,101 @@ type userRepo struct {
 }
 
 func (r *userRepo) DeleteUser(ctx context.Context, ID int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	_, ok := r.users[ID]
 	if !ok {
-		return fmt.Errorf("user not found")
+		return fmt.Errorf("user %w", app.ErrNotFound)
 	}
 	delete(r.users, ID)
+	slog.DebugContext(ctx, "userrepo: user deleted", "user_id", ID)
 	return nil
 }
 
 func (r *userRepo) CreateUser(ctx context.Context, Nickname string, Email string) (users.User, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	for _, user := range r.users {
 		if user.Email == Email {
 			return users.User{}, fmt.Errorf("user already exists")
 		}
 	}
-	r.mutex.Lock()
-	newUser := users.User{ID: r.idx, Nickname: Nickname, Email: Email}
+	newUser := users.User{ID: r.idx, Nickname: Nickname, Email: Email, Role: users.RoleUser}
 	r.users[r.idx] = newUser
 	r.idx++
-	r.mutex.Unlock()
+	slog.DebugContext(ctx, "userrepo: user stored", "user_id", newUser.ID)
 	return newUser, nil
 }
 
 func (r *userRepo) GetUser(ctx context.Context, ID int64) (users.User, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	user, ok := r.users[ID]
 	if !ok {
-		return users.User{}, fmt.Errorf("user not found")
+		return users.User{}, fmt.Errorf("user %w", app.ErrNotFound)
 	}
 	return user, nil
 }
+
+// GetUsers returns a copy, the map of the repository changes under the lock.
 func (r *userRepo) GetUsers(ctx context.Context) map[int64]users.User {
-	return r.users
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	all := make(map[int64]users.User, len(r.users))
+	for id, user := range r.users {
+		all[id] = user
+	}
+	return all
+}
+
+func (r *userRepo) SetUserRole(ctx context.Context, ID int64, Role users.Role) (users.User, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	user, ok := r.users[ID]
+	if !ok {
+		return users.User{}, fmt.Errorf("user %w", app.ErrNotFound)
+	}
+	user.Role = Role
+	r.users[ID] = user
+	return user, nil
+}
+
+// ImportUser stores the user as is. With PreserveID the user keeps its ID,
+// which must be free, otherwise it gets the next one.
+func (r *userRepo) ImportUser(ctx context.Context, user users.User, PreserveID bool) (users.User, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, other := range r.users {
+		if other.Email == user.Email {
+			return users.User{}, fmt.Errorf("user already exists")
+		}
+	}
+	if PreserveID {
+		if user.ID < 0 {
+			return users.User{}, fmt.Errorf("invalid user id %d", user.ID)
+		}
+		if _, ok := r.users[user.ID]; ok {
+			return users.User{}, fmt.Errorf("user %d already exists", user.ID)
+		}
+		if user.ID >= r.idx {
+			r.idx = user.ID + 1
+		}
+	} else {
+		user.ID = r.idx
+		r.idx++
+	}
+	r.users[user.ID] = user
+	return user, nil
+}
+
+func (r *userRepo) SetUserBanned(ctx context.Context, ID int64, Banned bool) (users.User, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	user, ok := r.users[ID]
+	if !ok {
+		return users.User{}, fmt.Errorf("user %w", app.ErrNotFound)
+	}
+	user.Banned = Banned
+	r.users[ID] = user
+	return user, nil
 }
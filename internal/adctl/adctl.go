@@ -0,0 +1,311 @@
+// Package adctl implements the adctl command, an admin client of the
+// AdService gRPC API.
+package adctl
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/tlsconfig"
+)
+
+const usage = `usage: adctl [-config file] [-server addr] [-o table|json|yaml] <command>
+
+commands:
+  ads create -user-id N -title T -text T
+  ads list [-author-id N] [-title substring]
+  ads get ID [-user-id N]
+  ads update ID -user-id N [-title T] [-text T]
+  ads publish ID -user-id N [-at RFC3339]
+  ads unpublish ID -user-id N
+  ads delete ID -user-id N
+  users create -nickname N -email E
+  users get ID
+  users delete ID -user-id N
+`
+
+// userIDMetadata names the user a call acts for, see middleware.UserIDMetadata.
+const userIDMetadata = "x-user-id"
+
+// ErrUsage is returned for a malformed command line.
+var ErrUsage = errors.New("invalid usage")
+
+type CLI struct {
+	Client grpcPort.AdServiceClient
+	Out    io.Writer
+	// Output is table, json or yaml.
+	Output string
+}
+
+// Dial connects to the server from cfg.
+func Dial(ctx context.Context, cfg Config, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if cfg.CAFile != "" || cfg.CertFile != "" {
+		tlsCfg, err := tlsconfig.Client(cfg.CAFile, cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsCfg)
+	}
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, opts...)
+	return grpc.DialContext(ctx, cfg.Server, opts...)
+}
+
+// Run executes one command, args do not include the global flags.
+func (c *CLI) Run(ctx context.Context, args []string) error {
+	if err := checkOutput(c.Output); err != nil {
+		return err
+	}
+	if len(args) < 2 {
+		return usageError("missing command")
+	}
+	switch args[0] + " " + args[1] {
+	case "ads create":
+		return c.createAd(ctx, args[2:])
+	case "ads list":
+		return c.listAds(ctx, args[2:])
+	case "ads get":
+		return c.getAd(ctx, args[2:])
+	case "ads update":
+		return c.updateAd(ctx, args[2:])
+	case "ads publish":
+		return c.changeAdStatus(ctx, args[2:], true)
+	case "ads unpublish":
+		return c.changeAdStatus(ctx, args[2:], false)
+	case "ads delete":
+		return c.deleteAd(ctx, args[2:])
+	case "users create":
+		return c.createUser(ctx, args[2:])
+	case "users get":
+		return c.getUser(ctx, args[2:])
+	case "users delete":
+		return c.deleteUser(ctx, args[2:])
+	}
+	return usageError("unknown command %q", strings.Join(args[:2], " "))
+}
+
+func Usage() string {
+	return usage
+}
+
+func usageError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrUsage, fmt.Sprintf(format, args...))
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// parse accepts flags before and after positional arguments, so both
+// "ads get 5 -o json" and "ads get -o json 5" work.
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, usageError("%s: %v", fs.Name(), err)
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// parseID parses the flags and the single ID argument of a command.
+func parseID(fs *flag.FlagSet, args []string) (int64, error) {
+	positional, err := parse(fs, args)
+	if err != nil {
+		return 0, err
+	}
+	if len(positional) != 1 {
+		return 0, usageError("%s: want exactly one ID", fs.Name())
+	}
+	id, err := strconv.ParseInt(positional[0], 10, 64)
+	if err != nil {
+		return 0, usageError("%s: invalid ID %q", fs.Name(), positional[0])
+	}
+	return id, nil
+}
+
+func (c *CLI) createAd(ctx context.Context, args []string) error {
+	fs := newFlagSet("ads create")
+	userID := fs.Int64("user-id", -1, "author of the ad")
+	title := fs.String("title", "", "title of the ad")
+	text := fs.String("text", "", "text of the ad")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	if *userID < 0 {
+		return usageError("ads create: -user-id is required")
+	}
+	ad, err := c.Client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: *userID, Title: *title, Text: *text})
+	if err != nil {
+		return err
+	}
+	return write(c.Out, c.Output, newAdView(ad))
+}
+
+func (c *CLI) listAds(ctx context.Context, args []string) error {
+	fs := newFlagSet("ads list")
+	authorID := fs.Int64("author-id", -1, "only ads of this author")
+	title := fs.String("title", "", "only ads with the title containing this text")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	resp, err := c.Client.ListAds(ctx, &grpcPort.ListAdsRequest{})
+	if err != nil {
+		return err
+	}
+	list := make([]adView, 0, len(resp.List))
+	for _, ad := range resp.List {
+		if *authorID >= 0 && ad.AuthorId != *authorID {
+			continue
+		}
+		if *title != "" && !strings.Contains(strings.ToLower(ad.Title), strings.ToLower(*title)) {
+			continue
+		}
+		list = append(list, newAdView(ad))
+	}
+	return write(c.Out, c.Output, list)
+}
+
+func (c *CLI) getAd(ctx context.Context, args []string) error {
+	fs := newFlagSet("ads get")
+	userID := fs.Int64("user-id", -1, "the author or a moderator, they see ads that are not published")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+	if *userID >= 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, userIDMetadata, strconv.FormatInt(*userID, 10))
+	}
+	resp, err := c.Client.GetAd(ctx, &grpcPort.GetAdRequest{AdId: id})
+	if err != nil {
+		return err
+	}
+	return write(c.Out, c.Output, newAdView(resp))
+}
+
+func (c *CLI) updateAd(ctx context.Context, args []string) error {
+	fs := newFlagSet("ads update")
+	userID := fs.Int64("user-id", -1, "author of the ad")
+	title := fs.String("title", "", "new title")
+	text := fs.String("text", "", "new text")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+	if *userID < 0 {
+		return usageError("ads update: -user-id is required")
+	}
+	ad, err := c.Client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: id, UserId: *userID, Title: *title, Text: *text})
+	if err != nil {
+		return err
+	}
+	return write(c.Out, c.Output, newAdView(ad))
+}
+
+func (c *CLI) changeAdStatus(ctx context.Context, args []string, published bool) error {
+	name := "ads unpublish"
+	if published {
+		name = "ads publish"
+	}
+	fs := newFlagSet(name)
+	userID := fs.Int64("user-id", -1, "author of the ad")
+	at := fs.String("at", "", "publish at this RFC 3339 time instead of now")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+	if *userID < 0 {
+		return usageError("%s: -user-id is required", name)
+	}
+	req := &grpcPort.ChangeAdStatusRequest{AdId: id, UserId: *userID, Published: published}
+	if *at != "" {
+		publishAt, err := time.Parse(time.RFC3339, *at)
+		if err != nil {
+			return usageError("%s: invalid -at: %v", name, err)
+		}
+		req.PublishAt = timestamppb.New(publishAt)
+	}
+	ad, err := c.Client.ChangeAdStatus(ctx, req)
+	if err != nil {
+		return err
+	}
+	return write(c.Out, c.Output, newAdView(ad))
+}
+
+func (c *CLI) deleteAd(ctx context.Context, args []string) error {
+	fs := newFlagSet("ads delete")
+	userID := fs.Int64("user-id", -1, "author of the ad or an admin")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+	if *userID < 0 {
+		return usageError("ads delete: -user-id is required")
+	}
+	if _, err := c.Client.DeleteAd(ctx, &grpcPort.DeleteAdRequest{AdId: id, AuthorId: *userID}); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Out, "ad %d deleted\n", id)
+	return err
+}
+
+func (c *CLI) createUser(ctx context.Context, args []string) error {
+	fs := newFlagSet("users create")
+	nickname := fs.String("nickname", "", "nickname of the user")
+	email := fs.String("email", "", "email of the user")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	user, err := c.Client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: *nickname, Email: *email})
+	if err != nil {
+		return err
+	}
+	return write(c.Out, c.Output, newUserView(user))
+}
+
+func (c *CLI) getUser(ctx context.Context, args []string) error {
+	id, err := parseID(newFlagSet("users get"), args)
+	if err != nil {
+		return err
+	}
+	user, err := c.Client.GetUser(ctx, &grpcPort.GetUserRequest{Id: id})
+	if err != nil {
+		return err
+	}
+	return write(c.Out, c.Output, newUserView(user))
+}
+
+func (c *CLI) deleteUser(ctx context.Context, args []string) error {
+	fs := newFlagSet("users delete")
+	userID := fs.Int64("user-id", -1, "the user or an admin")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+	if *userID < 0 {
+		return usageError("users delete: -user-id is required")
+	}
+	if _, err := c.Client.DeleteUser(ctx, &grpcPort.DeleteUserRequest{Id: id, ActorId: *userID}); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Out, "user %d deleted\n", id)
+	return err
+}
@@ -0,0 +1,55 @@
+package adctl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultServer = "localhost:50054"
+	defaultOutput = "table"
+)
+
+// Config is read from ~/.adctl.yaml unless another file is given. ADCTL_SERVER
+// overrides the file, command-line flags override both.
+type Config struct {
+	Server string `yaml:"server"`
+	Output string `yaml:"output"`
+	// CAFile switches the connection to TLS, CertFile and KeyFile are the
+	// client certificate of servers that require mTLS.
+	CAFile   string `yaml:"ca_file"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+func DefaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".adctl.yaml"
+	}
+	return filepath.Join(home, ".adctl.yaml")
+}
+
+// LoadConfig reads the config file. A missing file is not an error when
+// required is false, the defaults are used then.
+func LoadConfig(path string, required bool) (Config, error) {
+	cfg := Config{Server: defaultServer, Output: defaultOutput}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return Config{}, fmt.Errorf("parse %s: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && !required:
+	default:
+		return Config{}, err
+	}
+	if server := os.Getenv("ADCTL_SERVER"); server != "" {
+		cfg.Server = server
+	}
+	return cfg, nil
+}
@@ -0,0 +1,122 @@
+package adctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/yaml.v3"
+	grpcPort "homework9/internal/ports/grpc"
+)
+
+// adView and userView are what the commands print, independent of the
+// generated types so the JSON and YAML field names stay stable.
+type adView struct {
+	ID           int64      `json:"id" yaml:"id"`
+	Title        string     `json:"title" yaml:"title"`
+	Text         string     `json:"text" yaml:"text"`
+	AuthorID     int64      `json:"author_id" yaml:"author_id"`
+	Status       string     `json:"status" yaml:"status"`
+	RejectReason string     `json:"reject_reason,omitempty" yaml:"reject_reason,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+	PublishAt    *time.Time `json:"publish_at,omitempty" yaml:"publish_at,omitempty"`
+	DateCreate   *time.Time `json:"date_create,omitempty" yaml:"date_create,omitempty"`
+	DateUpdate   *time.Time `json:"date_update,omitempty" yaml:"date_update,omitempty"`
+}
+
+type userView struct {
+	ID       int64  `json:"id" yaml:"id"`
+	Nickname string `json:"nickname" yaml:"nickname"`
+	Email    string `json:"email" yaml:"email"`
+	Role     string `json:"role" yaml:"role"`
+	Banned   bool   `json:"banned" yaml:"banned"`
+}
+
+func timeOf(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func newAdView(ad *grpcPort.AdResponse) adView {
+	return adView{
+		ID:           ad.Id,
+		Title:        ad.Title,
+		Text:         ad.Text,
+		AuthorID:     ad.AuthorId,
+		Status:       ad.Status,
+		RejectReason: ad.RejectReason,
+		ExpiresAt:    timeOf(ad.ExpiresAt),
+		PublishAt:    timeOf(ad.PublishAt),
+		DateCreate:   timeOf(ad.DateCreate),
+		DateUpdate:   timeOf(ad.DateUpdate),
+	}
+}
+
+func newUserView(user *grpcPort.UserResponse) userView {
+	return userView{ID: user.Id, Nickname: user.Nickname, Email: user.Email, Role: user.Role, Banned: user.Banned}
+}
+
+func checkOutput(format string) error {
+	switch format {
+	case "table", "json", "yaml":
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q, want table, json or yaml", format)
+}
+
+// write prints v, a view or a slice of views, in the chosen format.
+func write(w io.Writer, format string, v any) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		defer enc.Close()
+		return enc.Encode(v)
+	}
+	return writeTable(w, v)
+}
+
+func writeTable(w io.Writer, v any) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	switch v := v.(type) {
+	case adView:
+		writeAdRows(tw, []adView{v})
+	case []adView:
+		writeAdRows(tw, v)
+	case userView:
+		fmt.Fprintln(tw, "ID\tNICKNAME\tEMAIL\tROLE\tBANNED")
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%t\n", v.ID, v.Nickname, v.Email, v.Role, v.Banned)
+	default:
+		return fmt.Errorf("can not print %T as a table", v)
+	}
+	return tw.Flush()
+}
+
+func writeAdRows(w io.Writer, list []adView) {
+	fmt.Fprintln(w, "ID\tTITLE\tAUTHOR\tSTATUS\tEXPIRES")
+	for _, ad := range list {
+		expires := "-"
+		if ad.ExpiresAt != nil {
+			expires = ad.ExpiresAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\n", ad.ID, shorten(ad.Title, 40), ad.AuthorID, ad.Status, expires)
+	}
+}
+
+func shorten(s string, n int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len([]rune(s)) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
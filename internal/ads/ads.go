@@ -2,12 +2,68 @@ package ads
 
 import "time"
 
+type Status string
+
+const (
+	StatusDraft         Status = "draft"
+	StatusPendingReview Status = "pending_review"
+	StatusPublished     Status = "published"
+	StatusRejected      Status = "rejected"
+	StatusArchived      Status = "archived"
+)
+
+// transitions lists the statuses reachable from each status.
+var transitions = map[Status][]Status{
+	StatusDraft:         {StatusPendingReview, StatusArchived},
+	StatusPendingReview: {StatusPublished, StatusRejected, StatusDraft},
+	StatusPublished:     {StatusArchived, StatusPendingReview},
+	StatusRejected:      {StatusPendingReview, StatusArchived},
+	StatusArchived:      {StatusPendingReview},
+}
+
+// Statuses lists every status in lifecycle order.
+func Statuses() []Status {
+	return []Status{StatusDraft, StatusPendingReview, StatusPublished, StatusRejected, StatusArchived}
+}
+
+func (s Status) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+func (s Status) CanTransitionTo(to Status) bool {
+	for _, next := range transitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
 type Ad struct {
-	ID         int64
-	Title      string
-	Text       string
-	AuthorID   int64
-	Published  bool
-	DateCreate time.Time
-	DateUpdate time.Time
+	ID           int64
+	Title        string
+	Text         string
+	AuthorID     int64
+	Status       Status
+	RejectReason string
+	DateCreate   time.Time
+	DateUpdate   time.Time
+	// ExpiresAt is set when the ad gets published, zero means it never expires.
+	ExpiresAt      time.Time
+	ExpiryNotified bool
+	// PublishAt is when a scheduled ad goes live, zero means it is not scheduled.
+	PublishAt time.Time
+}
+
+func (a Ad) Published() bool {
+	return a.Status == StatusPublished
+}
+
+func (a Ad) Scheduled() bool {
+	return !a.PublishAt.IsZero()
+}
+
+func (a Ad) Expired(now time.Time) bool {
+	return !a.ExpiresAt.IsZero() && !now.Before(a.ExpiresAt)
 }
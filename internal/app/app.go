@@ -6,34 +6,76 @@ import (
 	"github.com/mirgalieva/valid"
 	"github.com/pkg/errors"
 	"homework9/internal/ads"
+	"homework9/internal/messages"
+	"homework9/internal/reports"
 	"homework9/internal/users"
+	"log/slog"
+	"time"
 )
 
 var ErrWrongUser = errors.New("user has no rights")
+var ErrUserBanned = fmt.Errorf("%w: user is banned", ErrWrongUser)
+var ErrUnknownClient = fmt.Errorf("%w: unknown client", ErrWrongUser)
 var ErrValidationFail = errors.New("ad is not valid")
+var ErrInvalidTransition = errors.New("illegal ad status transition")
+var ErrNotFound = errors.New("not found")
+
+// Anonymous is the UserID of reads made without a user.
+const Anonymous int64 = -1
 
 type App interface {
 	CreateAd(ctx context.Context, Title string, Text string, UserID int64) (ads.Ad, error)
 	ChangeAdStatus(ctx context.Context, adID int64, UserID int64, Published bool) (ads.Ad, error)
 	UpdateAd(ctx context.Context, adID int64, UserID int64, Title string, Text string) (ads.Ad, error)
 	CreateUser(ctx context.Context, Nickname string, Email string) (users.User, error)
-	DeleteUser(ctx context.Context, ID int64) error
+	DeleteUser(ctx context.Context, actorID int64, ID int64) error
 	GetUser(ctx context.Context, ID int64) (users.User, error)
-	GetAd(ctx context.Context, index int64) (ads.Ad, error)
+	GetAd(ctx context.Context, index int64, UserID int64) (ads.Ad, error)
 	GetAdByTitle(ctx context.Context, Title string) (ads.Ad, error)
 	GetUsers(ctx context.Context) map[int64]users.User
 	GetAds(ctx context.Context) ([]ads.Ad, error)
 	GetAdsPrams(ctx context.Context, param map[string]interface{}) ([]ads.Ad, error)
 	DeleteAd(ctx context.Context, adID int64, userID int64) error
+	SendMessageToAd(ctx context.Context, adID int64, UserID int64, Text string) (messages.Message, error)
+	SendMessage(ctx context.Context, conversationID int64, UserID int64, Text string) (messages.Message, error)
+	GetMessages(ctx context.Context, conversationID int64, UserID int64) ([]messages.Message, error)
+	GetInbox(ctx context.Context, actorID int64, UserID int64) ([]messages.Conversation, error)
+	GetAdConversations(ctx context.Context, adID int64, UserID int64) ([]messages.Conversation, error)
+	SetUserRole(ctx context.Context, adminID int64, UserID int64, Role users.Role) (users.User, error)
+	BanUser(ctx context.Context, adminID int64, UserID int64, Banned bool) (users.User, error)
+	GetModerationQueue(ctx context.Context, moderatorID int64) ([]ads.Ad, error)
+	ApproveAd(ctx context.Context, adID int64, moderatorID int64) (ads.Ad, error)
+	RejectAd(ctx context.Context, adID int64, moderatorID int64, Reason string) (ads.Ad, error)
+	ReportAd(ctx context.Context, adID int64, UserID int64, Reason reports.Reason, Comment string) (reports.Report, error)
+	GetReportedAds(ctx context.Context, moderatorID int64) ([]reports.Summary, error)
+	ResolveReports(ctx context.Context, adID int64, moderatorID int64, Resolution reports.Resolution, Comment string) (ads.Ad, error)
+	RenewAd(ctx context.Context, adID int64, UserID int64) (ads.Ad, error)
+	ExpireAds(ctx context.Context) error
+	ScheduleAd(ctx context.Context, adID int64, UserID int64, PublishAt time.Time) (ads.Ad, error)
+	CancelScheduledAd(ctx context.Context, adID int64, UserID int64) (ads.Ad, error)
+	PublishScheduledAds(ctx context.Context) error
+	CreateAds(ctx context.Context, UserID int64, items []NewAd, atomic bool) ([]BatchResult, error)
+	ChangeAdsStatus(ctx context.Context, adIDs []int64, UserID int64, Published bool, atomic bool) ([]BatchResult, error)
+	DeleteAds(ctx context.Context, adIDs []int64, UserID int64, atomic bool) ([]BatchResult, error)
+	ExportUsers(ctx context.Context, adminID int64) ([]users.User, error)
+	ExportAds(ctx context.Context, adminID int64) ([]ads.Ad, error)
+	Import(ctx context.Context, adminID int64, Users []users.User, Ads []ads.Ad, PreserveIDs bool) (ImportResult, error)
 }
 
 type AdRepository interface {
 	CreateAd(ctx context.Context, Title string, Text string, UserID int64) (ads.Ad, error)
-	ChangeAdStatus(ctx context.Context, adID int64, Published bool) (ads.Ad, error)
+	ChangeAdStatus(ctx context.Context, adID int64, Status ads.Status, Reason string) (ads.Ad, error)
 	UpdateAd(ctx context.Context, adID int64, Title string, Text string) (ads.Ad, error)
 	GetAd(ctx context.Context, index int64) (ads.Ad, error)
 	GetAdByTitle(ctx context.Context, Title string) (ads.Ad, error)
 	GetAds(ctx context.Context) ([]ads.Ad, error)
+	GetAdsByStatus(ctx context.Context, Status ads.Status) ([]ads.Ad, error)
+	GetAdsByAuthor(ctx context.Context, authorID int64) ([]ads.Ad, error)
+	SetAdExpiration(ctx context.Context, adID int64, ExpiresAt time.Time, ExpiryNotified bool) (ads.Ad, error)
+	SetAdPublishAt(ctx context.Context, adID int64, PublishAt time.Time) (ads.Ad, error)
+	GetScheduledAds(ctx context.Context, before time.Time) ([]ads.Ad, error)
+	GetAllAds(ctx context.Context) ([]ads.Ad, error)
+	ImportAd(ctx context.Context, ad ads.Ad, PreserveID bool) (ads.Ad, error)
 	DeleteAd(ctx context.Context, adID int64) error
 }
 
@@ -42,42 +84,105 @@ type UserRepository interface {
 	DeleteUser(ctx context.Context, ID int64) error
 	GetUser(ctx context.Context, ID int64) (users.User, error)
 	GetUsers(ctx context.Context) map[int64]users.User
+	SetUserRole(ctx context.Context, ID int64, Role users.Role) (users.User, error)
+	SetUserBanned(ctx context.Context, ID int64, Banned bool) (users.User, error)
+	ImportUser(ctx context.Context, user users.User, PreserveID bool) (users.User, error)
+}
+
+type MessageRepository interface {
+	GetOrCreateConversation(ctx context.Context, adID int64, buyerID int64, sellerID int64) (messages.Conversation, error)
+	GetConversation(ctx context.Context, ID int64) (messages.Conversation, error)
+	GetConversationsByUser(ctx context.Context, userID int64) ([]messages.Conversation, error)
+	GetConversationsByAd(ctx context.Context, adID int64) ([]messages.Conversation, error)
+	CreateMessage(ctx context.Context, conversationID int64, senderID int64, Text string) (messages.Message, error)
+	GetMessages(ctx context.Context, conversationID int64) ([]messages.Message, error)
+	MarkRead(ctx context.Context, conversationID int64, readerID int64) error
+}
+
+type ReportRepository interface {
+	CreateReport(ctx context.Context, adID int64, reporterID int64, Reason reports.Reason, Comment string) (reports.Report, error)
+	GetOpenReports(ctx context.Context) ([]reports.Report, error)
+	GetOpenReportsByAd(ctx context.Context, adID int64) ([]reports.Report, error)
+	ResolveReports(ctx context.Context, adID int64, Resolution reports.Resolution) error
+}
+
+type Option func(*app)
+
+// WithPremoderation makes published ads wait in the moderation queue
+// until a moderator approves them.
+func WithPremoderation() Option {
+	return func(a *app) {
+		a.premoderation = true
+	}
 }
 
-func NewApp(adRepo AdRepository, userRepo UserRepository) App {
-	return &app{adRepo: adRepo, userRepo: userRepo}
+func NewApp(adRepo AdRepository, userRepo UserRepository, messageRepo MessageRepository, reportRepo ReportRepository, opts ...Option) App {
+	a := &app{
+		adRepo:          adRepo,
+		userRepo:        userRepo,
+		messageRepo:     messageRepo,
+		reportRepo:      reportRepo,
+		reportThreshold: defaultReportThreshold,
+		adTTL:           defaultAdTTL,
+		now:             time.Now,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
 }
 
 type app struct {
-	adRepo   AdRepository
-	userRepo UserRepository
+	adRepo          AdRepository
+	userRepo        UserRepository
+	messageRepo     MessageRepository
+	reportRepo      ReportRepository
+	contentFilters  []ContentFilter
+	premoderation   bool
+	reportThreshold int
+	adTTL           time.Duration
+	dailyAdQuota    int
+	quota           adQuota
+	expiryNotifier  ExpiryNotifier
+	expiryNotice    time.Duration
+	now             func() time.Time
+	clients         map[string]users.Role
 }
 
 func (a *app) DeleteAd(ctx context.Context, adID int64, userID int64) error {
-	ad, err := a.GetAd(ctx, adID)
-	if err != nil {
+	if _, err := a.checkDelete(ctx, adID, userID); err != nil {
 		return err
 	}
-	if ad.AuthorID != userID {
-		return ErrWrongUser
-	}
-	err = a.adRepo.DeleteAd(ctx, adID)
+	err := a.adRepo.DeleteAd(ctx, adID)
 	if err != nil {
 		return err
 	}
+	slog.InfoContext(ctx, "ad deleted", "ad_id", adID, "user_id", userID)
 	return nil
 }
 
+func (a *app) checkDelete(ctx context.Context, adID int64, userID int64) (ads.Ad, error) {
+	ad, err := a.adRepo.GetAd(ctx, adID)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	if err := a.authorize(ctx, userID, ActionDeleteAd, ad); err != nil {
+		return ads.Ad{}, err
+	}
+	return ad, nil
+}
+
 func (a *app) GetAdsPrams(ctx context.Context, param map[string]any) ([]ads.Ad, error) {
 	ans, err := a.adRepo.GetAds(ctx)
 	if err != nil {
 		return nil, err
 	}
+	ans = a.unexpired(ans)
 	var adArr = make([]ads.Ad, 0)
 LP:
 	for _, an := range ans {
 		for k, v := range param {
-			if k == "published" && an.Published != v {
+			if k == "published" && an.Published() != v {
 				continue LP
 			}
 			if k == "author_id" && an.AuthorID != v {
@@ -97,7 +202,7 @@ func (a *app) GetAds(ctx context.Context) ([]ads.Ad, error) {
 	if err != nil {
 		return make([]ads.Ad, 0), err
 	}
-	return Ads, nil
+	return a.unexpired(Ads), nil
 }
 
 func (a *app) GetUsers(ctx context.Context) map[int64]users.User {
@@ -115,47 +220,137 @@ type ValidNicknameAndEmail struct {
 }
 
 func (a *app) CreateAd(ctx context.Context, Title string, Text string, UserID int64) (ads.Ad, error) {
-	valid := ValidTitleAndText{Title, Text}
-	err := homework.Validate(valid)
-	if err != nil {
-		return ads.Ad{}, ErrValidationFail
+	if err := a.checkNewAd(ctx, Title, Text, UserID); err != nil {
+		return ads.Ad{}, err
+	}
+	if _, err := a.reserveQuota(UserID, 1); err != nil {
+		return ads.Ad{}, err
 	}
 	ad, err := a.adRepo.CreateAd(ctx, Title, Text, UserID)
 	if err != nil {
+		a.releaseQuota(UserID, 1)
 		return ad, err
 	}
+	slog.InfoContext(ctx, "ad created", "ad_id", ad.ID, "user_id", UserID)
 	return ad, nil
 }
+
+func (a *app) checkNewAd(ctx context.Context, Title string, Text string, UserID int64) error {
+	valid := ValidTitleAndText{Title, Text}
+	err := homework.Validate(valid)
+	if err != nil {
+		return ErrValidationFail
+	}
+	if err := a.authorize(ctx, UserID, ActionCreateAd, ads.Ad{}); err != nil {
+		return err
+	}
+	return a.checkContent(ctx, ads.Ad{ID: -1, Title: Title, Text: Text, AuthorID: UserID})
+}
+
 func (a *app) ChangeAdStatus(ctx context.Context, adID int64, UserID int64, Published bool) (ads.Ad, error) {
+	ad, err := a.checkStatusChange(ctx, adID, UserID, Published)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	return a.applyStatusChange(ctx, ad, Published)
+}
+
+func (a *app) checkStatusChange(ctx context.Context, adID int64, UserID int64, Published bool) (ads.Ad, error) {
 	ad, err := a.adRepo.GetAd(ctx, adID)
 	if err != nil {
-		return ads.Ad{}, fmt.Errorf("invalid adId")
+		return ads.Ad{}, fmt.Errorf("invalid adId: %w", err)
+	}
+
+	action := ActionUnpublishAd
+	if Published {
+		action = ActionPublishAd
+	}
+	if err := a.authorize(ctx, UserID, action, ad); err != nil {
+		return ads.Ad{}, err
+	}
+	return ad, nil
+}
+
+func (a *app) applyStatusChange(ctx context.Context, ad ads.Ad, Published bool) (ads.Ad, error) {
+	if Published {
+		return a.submitAd(ctx, ad)
+	}
+	switch ad.Status {
+	case ads.StatusPublished:
+		return a.transition(ctx, ad, ads.StatusArchived, "")
+	case ads.StatusPendingReview:
+		return a.transition(ctx, ad, ads.StatusDraft, "")
 	}
+	return ads.Ad{}, fmt.Errorf("%w: %s ad is not published", ErrInvalidTransition, ad.Status)
+}
 
-	if ad.AuthorID != UserID {
-		return ads.Ad{}, ErrWrongUser
+// submitAd sends the ad to review. Without premoderation the review is
+// approved right away, so the author sees the ad published immediately,
+// unless the ad is hidden by reports and waits for a moderator.
+// The schedule of the ad is dropped once it is submitted, a failed
+// submission keeps it for the next run of PublishScheduledAds.
+func (a *app) submitAd(ctx context.Context, ad ads.Ad) (ads.Ad, error) {
+	var err error
+	if ad.Status != ads.StatusPublished && !a.premoderation {
+		hidden, err := a.hiddenByReports(ctx, ad.ID)
+		if err != nil {
+			return ads.Ad{}, err
+		}
+		if hidden {
+			return ads.Ad{}, fmt.Errorf("%w: ad is hidden by reports until a moderator resolves them", ErrInvalidTransition)
+		}
 	}
+	if ad.Status != ads.StatusPublished && ad.Status != ads.StatusPendingReview {
+		ad, err = a.transition(ctx, ad, ads.StatusPendingReview, "")
+		if err != nil {
+			return ads.Ad{}, err
+		}
+	}
+	if ad.Status == ads.StatusPendingReview && !a.premoderation {
+		ad, err = a.transition(ctx, ad, ads.StatusPublished, "")
+		if err != nil {
+			return ads.Ad{}, err
+		}
+	}
+	if ad.Scheduled() {
+		return a.adRepo.SetAdPublishAt(ctx, ad.ID, time.Time{})
+	}
+	return ad, nil
+}
 
-	updatedAd, err := a.adRepo.ChangeAdStatus(ctx, adID, Published)
+// transition is the only place where the status of an ad changes.
+func (a *app) transition(ctx context.Context, ad ads.Ad, to ads.Status, reason string) (ads.Ad, error) {
+	if !ad.Status.CanTransitionTo(to) {
+		return ads.Ad{}, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, ad.Status, to)
+	}
+	updatedAd, err := a.adRepo.ChangeAdStatus(ctx, ad.ID, to, reason)
 	if err != nil {
-		return ads.Ad{}, err
+		return updatedAd, err
 	}
-	return updatedAd, nil
+	slog.InfoContext(ctx, "ad status changed", "ad_id", ad.ID, "from", ad.Status, "to", to)
+	if to != ads.StatusPublished {
+		return updatedAd, err
+	}
+	return a.adRepo.SetAdExpiration(ctx, ad.ID, a.now().UTC().Add(a.adTTL), false)
 }
 
 func (a *app) UpdateAd(ctx context.Context, adID int64, UserID int64, Title string, Text string) (ads.Ad, error) {
 	ad, err := a.adRepo.GetAd(ctx, adID)
 	if err != nil {
-		return ads.Ad{}, fmt.Errorf("invalid adId")
+		return ads.Ad{}, fmt.Errorf("invalid adId: %w", err)
 	}
-	if ad.AuthorID != UserID {
-		return ads.Ad{}, ErrWrongUser
+	if err := a.authorize(ctx, UserID, ActionUpdateAd, ad); err != nil {
+		return ads.Ad{}, err
 	}
 	valid := ValidTitleAndText{Title, Text}
 	err = homework.Validate(valid)
 	if err != nil {
 		return ads.Ad{}, ErrValidationFail
 	}
+	ad.Title, ad.Text = Title, Text
+	if err := a.checkContent(ctx, ad); err != nil {
+		return ads.Ad{}, err
+	}
 	updatedAd, err := a.adRepo.UpdateAd(ctx, adID, Title, Text)
 	if err != nil {
 		return ads.Ad{}, err
@@ -172,14 +367,19 @@ func (a *app) CreateUser(ctx context.Context, Nickname string, Email string) (us
 	if err != nil {
 		return user, err
 	}
+	slog.InfoContext(ctx, "user created", "user_id", user.ID)
 	return user, nil
 }
 
-func (a *app) DeleteUser(ctx context.Context, ID int64) error {
+func (a *app) DeleteUser(ctx context.Context, actorID int64, ID int64) error {
+	if err := a.authorizeOnUser(ctx, actorID, ActionDeleteUser, ID); err != nil {
+		return err
+	}
 	err := a.userRepo.DeleteUser(ctx, ID)
 	if err != nil {
 		return err
 	}
+	slog.InfoContext(ctx, "user deleted", "user_id", ID)
 	return nil
 }
 
@@ -191,11 +391,19 @@ func (a *app) GetUser(ctx context.Context, ID int64) (users.User, error) {
 	return user, nil
 }
 
-func (a *app) GetAd(ctx context.Context, ID int64) (ads.Ad, error) {
+// GetAd returns the ad to UserID. Ads that are not published are seen only
+// by their author and the moderators, the others get ErrNotFound.
+func (a *app) GetAd(ctx context.Context, ID int64, UserID int64) (ads.Ad, error) {
 	ad, err := a.adRepo.GetAd(ctx, ID)
 	if err != nil {
 		return ads.Ad{}, err
 	}
+	if ad.Published() {
+		return ad, nil
+	}
+	if err := a.authorize(ctx, UserID, ActionReadAd, ad); err != nil {
+		return ads.Ad{}, fmt.Errorf("ad %w", ErrNotFound)
+	}
 	return ad, nil
 }
 
@@ -210,3 +418,23 @@ func (a *app) GetAdByTitle(ctx context.Context, Title string) (ads.Ad, error) {
 	}
 	return ad, nil
 }
+
+func (a *app) SetUserRole(ctx context.Context, adminID int64, UserID int64, Role users.Role) (users.User, error) {
+	if !Role.Valid() {
+		return users.User{}, ErrValidationFail
+	}
+	if err := a.authorize(ctx, adminID, ActionSetUserRole, ads.Ad{}); err != nil {
+		return users.User{}, err
+	}
+	return a.userRepo.SetUserRole(ctx, UserID, Role)
+}
+
+func (a *app) BanUser(ctx context.Context, adminID int64, UserID int64, Banned bool) (users.User, error) {
+	if err := a.authorize(ctx, adminID, ActionBanUser, ads.Ad{}); err != nil {
+		return users.User{}, err
+	}
+	if adminID == UserID {
+		return users.User{}, ErrWrongUser
+	}
+	return a.userRepo.SetUserBanned(ctx, UserID, Banned)
+}
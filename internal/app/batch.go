@@ -0,0 +1,209 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework9/internal/ads"
+)
+
+const maxBatchSize = 500
+
+// ErrBatchAborted marks the items of an all-or-nothing batch that were not
+// applied because another item of the batch failed.
+var ErrBatchAborted = errors.New("batch aborted")
+
+// NewAd is one ad of a CreateAds batch.
+type NewAd struct {
+	Title string
+	Text  string
+}
+
+// BatchResult is the outcome of one batch item, results are returned in
+// the order of the request.
+type BatchResult struct {
+	Ad  ads.Ad
+	Err error
+}
+
+func checkBatchSize(n int) error {
+	if n == 0 || n > maxBatchSize {
+		return fmt.Errorf("%w: batch must have from 1 to %d items", ErrValidationFail, maxBatchSize)
+	}
+	return nil
+}
+
+// failDuplicates fails the items that repeat the ID of an earlier item.
+// They would be checked against the ad as it was before the earlier item
+// changed it.
+func failDuplicates(adIDs []int64, results []BatchResult) {
+	seen := make(map[int64]bool, len(adIDs))
+	for i, adID := range adIDs {
+		if seen[adID] {
+			results[i].Err = fmt.Errorf("%w: ad %d is already in the batch", ErrValidationFail, adID)
+		}
+		seen[adID] = true
+	}
+}
+
+// abortBatch replaces the result of every item that passed the checks with
+// ErrBatchAborted. It reports whether the batch has to be aborted.
+func abortBatch(results []BatchResult, atomic bool) bool {
+	if !atomic || !failed(results) {
+		return false
+	}
+	for i := range results {
+		if results[i].Err == nil {
+			results[i] = BatchResult{Ad: results[i].Ad, Err: ErrBatchAborted}
+		}
+	}
+	return true
+}
+
+func failed(results []BatchResult) bool {
+	for _, res := range results {
+		if res.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAds creates the ads for one author. Every item goes through the
+// same checks as CreateAd. With atomic set either all ads are created or,
+// when any item fails, none.
+func (a *app) CreateAds(ctx context.Context, UserID int64, items []NewAd, atomic bool) ([]BatchResult, error) {
+	if err := checkBatchSize(len(items)); err != nil {
+		return nil, err
+	}
+	if err := a.authorize(ctx, UserID, ActionCreateAd, ads.Ad{}); err != nil {
+		return nil, err
+	}
+	results := make([]BatchResult, len(items))
+	valid := 0
+	for i, item := range items {
+		results[i].Err = a.checkNewAd(ctx, item.Title, item.Text, UserID)
+		if results[i].Err == nil {
+			valid++
+		}
+	}
+	reserved, err := a.reserveQuota(UserID, valid)
+	if err != nil {
+		return nil, err
+	}
+	left := reserved
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+		// items over the quota fail like invalid ones, the rest still go in
+		if left == 0 {
+			results[i].Err = &QuotaExceededError{Limit: a.dailyAdQuota, RetryAfter: a.quotaRetryAfter()}
+			continue
+		}
+		left--
+	}
+	if abortBatch(results, atomic) {
+		a.releaseQuota(UserID, reserved)
+		return results, ErrBatchAborted
+	}
+	for i, item := range items {
+		if results[i].Err != nil {
+			continue
+		}
+		results[i].Ad, results[i].Err = a.adRepo.CreateAd(ctx, item.Title, item.Text, UserID)
+		if results[i].Err == nil {
+			continue
+		}
+		if atomic {
+			a.rollbackCreated(ctx, results[:i])
+			abortBatch(results, atomic)
+			a.releaseQuota(UserID, reserved)
+			return results, ErrBatchAborted
+		}
+		a.releaseQuota(UserID, 1)
+	}
+	return results, nil
+}
+
+func (a *app) rollbackCreated(ctx context.Context, results []BatchResult) {
+	for i := range results {
+		_ = a.adRepo.DeleteAd(ctx, results[i].Ad.ID)
+		results[i].Ad = ads.Ad{}
+	}
+}
+
+// ChangeAdsStatus publishes or unpublishes the ads with the ownership
+// checks of ChangeAdStatus. With atomic set a failed item rolls back the
+// ads changed before it.
+func (a *app) ChangeAdsStatus(ctx context.Context, adIDs []int64, UserID int64, Published bool, atomic bool) ([]BatchResult, error) {
+	if err := checkBatchSize(len(adIDs)); err != nil {
+		return nil, err
+	}
+	results := make([]BatchResult, len(adIDs))
+	failDuplicates(adIDs, results)
+	for i, adID := range adIDs {
+		if results[i].Err == nil {
+			results[i].Ad, results[i].Err = a.checkStatusChange(ctx, adID, UserID, Published)
+		}
+	}
+	if abortBatch(results, atomic) {
+		return results, ErrBatchAborted
+	}
+	prev := make([]ads.Ad, len(results))
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+		prev[i] = results[i].Ad
+		results[i].Ad, results[i].Err = a.applyStatusChange(ctx, prev[i], Published)
+		if results[i].Err != nil && atomic {
+			for j := 0; j < i; j++ {
+				results[j].Ad = a.restoreAd(ctx, prev[j])
+			}
+			abortBatch(results, atomic)
+			return results, ErrBatchAborted
+		}
+	}
+	return results, nil
+}
+
+// restoreAd puts back the state of an ad saved before a batch changed it.
+func (a *app) restoreAd(ctx context.Context, ad ads.Ad) ads.Ad {
+	if _, err := a.adRepo.ChangeAdStatus(ctx, ad.ID, ad.Status, ad.RejectReason); err != nil {
+		return ad
+	}
+	if _, err := a.adRepo.SetAdExpiration(ctx, ad.ID, ad.ExpiresAt, ad.ExpiryNotified); err != nil {
+		return ad
+	}
+	restored, err := a.adRepo.SetAdPublishAt(ctx, ad.ID, ad.PublishAt)
+	if err != nil {
+		return ad
+	}
+	return restored
+}
+
+// DeleteAds deletes the ads with the ownership checks of DeleteAd. Deletion
+// can not be rolled back, so in atomic mode nothing is deleted unless every
+// item passes the checks.
+func (a *app) DeleteAds(ctx context.Context, adIDs []int64, UserID int64, atomic bool) ([]BatchResult, error) {
+	if err := checkBatchSize(len(adIDs)); err != nil {
+		return nil, err
+	}
+	results := make([]BatchResult, len(adIDs))
+	failDuplicates(adIDs, results)
+	for i, adID := range adIDs {
+		if results[i].Err == nil {
+			results[i].Ad, results[i].Err = a.checkDelete(ctx, adID, UserID)
+		}
+	}
+	if abortBatch(results, atomic) {
+		return results, ErrBatchAborted
+	}
+	for i, adID := range adIDs {
+		if results[i].Err == nil {
+			results[i].Err = a.adRepo.DeleteAd(ctx, adID)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"homework9/internal/ads"
+	"strings"
+)
+
+var ErrContentRejected = errors.New("ad content rejected")
+
+// Rejection is one reason a ContentFilter refused the ad. Code is stable
+// and meant for clients, Message is for humans.
+type Rejection struct {
+	Code    string
+	Field   string
+	Message string
+}
+
+// ContentFilter inspects an ad before it is stored. authorAds are the
+// other ads of the same author, for filters that compare against them.
+type ContentFilter interface {
+	Check(ctx context.Context, ad ads.Ad, authorAds []ads.Ad) []Rejection
+}
+
+type ContentRejectedError struct {
+	Reasons []Rejection
+}
+
+func (e *ContentRejectedError) Error() string {
+	codes := make([]string, len(e.Reasons))
+	for i, r := range e.Reasons {
+		codes[i] = r.Code
+	}
+	return ErrContentRejected.Error() + ": " + strings.Join(codes, ", ")
+}
+
+// Is lets callers that only know about ErrValidationFail keep treating a
+// rejection as a validation error.
+func (e *ContentRejectedError) Is(target error) bool {
+	return target == ErrContentRejected || target == ErrValidationFail
+}
+
+// WithContentFilters sets the filters run by CreateAd and UpdateAd, in order.
+func WithContentFilters(filters ...ContentFilter) Option {
+	return func(a *app) {
+		a.contentFilters = append(a.contentFilters, filters...)
+	}
+}
+
+func (a *app) checkContent(ctx context.Context, ad ads.Ad) error {
+	if len(a.contentFilters) == 0 {
+		return nil
+	}
+	authorAds, err := a.adRepo.GetAdsByAuthor(ctx, ad.AuthorID)
+	if err != nil {
+		return err
+	}
+	others := make([]ads.Ad, 0, len(authorAds))
+	for _, other := range authorAds {
+		if other.ID != ad.ID {
+			others = append(others, other)
+		}
+	}
+	var reasons []Rejection
+	for _, filter := range a.contentFilters {
+		reasons = append(reasons, filter.Check(ctx, ad, others)...)
+	}
+	if len(reasons) > 0 {
+		return &ContentRejectedError{Reasons: reasons}
+	}
+	return nil
+}
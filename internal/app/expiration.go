@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework9/internal/ads"
+	"log/slog"
+	"time"
+)
+
+const defaultAdTTL = 30 * 24 * time.Hour
+
+// ExpiryNotifier is told once per publication that an ad is about to expire.
+type ExpiryNotifier interface {
+	NotifyExpiringSoon(ctx context.Context, ad ads.Ad) error
+}
+
+// WithAdTTL sets how long an ad stays published before it expires.
+func WithAdTTL(ttl time.Duration) Option {
+	return func(a *app) {
+		a.adTTL = ttl
+	}
+}
+
+// WithExpiryNotifier calls n for ads that expire within notice.
+func WithExpiryNotifier(n ExpiryNotifier, notice time.Duration) Option {
+	return func(a *app) {
+		a.expiryNotifier = n
+		a.expiryNotice = notice
+	}
+}
+
+// WithClock replaces time.Now, used by tests to move time forward.
+func WithClock(now func() time.Time) Option {
+	return func(a *app) {
+		a.now = now
+	}
+}
+
+// RenewAd restarts the expiration period of a published ad. An archived
+// ad is submitted again, which restarts the period once it is published.
+func (a *app) RenewAd(ctx context.Context, adID int64, UserID int64) (ads.Ad, error) {
+	ad, err := a.adRepo.GetAd(ctx, adID)
+	if err != nil {
+		return ads.Ad{}, fmt.Errorf("invalid adId: %w", err)
+	}
+	if err := a.authorize(ctx, UserID, ActionRenewAd, ad); err != nil {
+		return ads.Ad{}, err
+	}
+	switch ad.Status {
+	case ads.StatusPublished:
+		return a.adRepo.SetAdExpiration(ctx, ad.ID, a.now().UTC().Add(a.adTTL), false)
+	case ads.StatusArchived:
+		return a.submitAd(ctx, ad)
+	}
+	return ads.Ad{}, fmt.Errorf("%w: can not renew %s ad", ErrInvalidTransition, ad.Status)
+}
+
+// ExpireAds archives published ads whose time is up and warns the authors
+// of the ones that expire soon. It is run periodically by the scheduler.
+// A failed ad is logged and skipped, the errors of all of them are returned
+// together.
+func (a *app) ExpireAds(ctx context.Context) error {
+	published, err := a.adRepo.GetAds(ctx)
+	if err != nil {
+		return err
+	}
+	now := a.now()
+	var errs []error
+	for _, ad := range published {
+		if err := a.expireAd(ctx, ad, now); err != nil {
+			slog.ErrorContext(ctx, "ad expiration failed", "ad_id", ad.ID, "error", err)
+			errs = append(errs, fmt.Errorf("ad %d: %w", ad.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *app) expireAd(ctx context.Context, ad ads.Ad, now time.Time) error {
+	if ad.Expired(now) {
+		_, err := a.transition(ctx, ad, ads.StatusArchived, "")
+		return err
+	}
+	if a.expiryNotifier == nil || ad.ExpiresAt.IsZero() || ad.ExpiryNotified || ad.ExpiresAt.Sub(now) > a.expiryNotice {
+		return nil
+	}
+	if err := a.expiryNotifier.NotifyExpiringSoon(ctx, ad); err != nil {
+		return err
+	}
+	_, err := a.adRepo.SetAdExpiration(ctx, ad.ID, ad.ExpiresAt, true)
+	return err
+}
+
+// unexpired drops the ads that are past ExpiresAt but not archived yet.
+func (a *app) unexpired(list []ads.Ad) []ads.Ad {
+	now := a.now()
+	ans := make([]ads.Ad, 0, len(list))
+	for _, ad := range list {
+		if !ad.Expired(now) {
+			ans = append(ans, ad)
+		}
+	}
+	return ans
+}
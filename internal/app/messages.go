@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/mirgalieva/valid"
+	"homework9/internal/ads"
+	"homework9/internal/messages"
+)
+
+type ValidMessageText struct {
+	Text string `validate:"min:1,max:1000"`
+}
+
+// SendMessageToAd writes to the author of the ad, opening the (ad, buyer)
+// conversation on the first message. Authors answer via SendMessage. Ads
+// that are not published take no new conversations.
+func (a *app) SendMessageToAd(ctx context.Context, adID int64, UserID int64, Text string) (messages.Message, error) {
+	if err := homework.Validate(ValidMessageText{Text}); err != nil {
+		return messages.Message{}, ErrValidationFail
+	}
+	if _, err := a.userRepo.GetUser(ctx, UserID); err != nil {
+		return messages.Message{}, ErrWrongUser
+	}
+	ad, err := a.adRepo.GetAd(ctx, adID)
+	if err != nil {
+		return messages.Message{}, err
+	}
+	if !ad.Published() {
+		return messages.Message{}, fmt.Errorf("ad %w", ErrNotFound)
+	}
+	if ad.AuthorID == UserID {
+		return messages.Message{}, ErrWrongUser
+	}
+	if err := a.authorize(ctx, UserID, ActionSendMessage, ad); err != nil {
+		return messages.Message{}, err
+	}
+	conv, err := a.messageRepo.GetOrCreateConversation(ctx, ad.ID, UserID, ad.AuthorID)
+	if err != nil {
+		return messages.Message{}, err
+	}
+	return a.messageRepo.CreateMessage(ctx, conv.ID, UserID, Text)
+}
+
+func (a *app) SendMessage(ctx context.Context, conversationID int64, UserID int64, Text string) (messages.Message, error) {
+	if err := homework.Validate(ValidMessageText{Text}); err != nil {
+		return messages.Message{}, ErrValidationFail
+	}
+	conv, err := a.messageRepo.GetConversation(ctx, conversationID)
+	if err != nil {
+		return messages.Message{}, err
+	}
+	if !conv.HasParticipant(UserID) {
+		return messages.Message{}, ErrWrongUser
+	}
+	if err := a.authorize(ctx, UserID, ActionSendMessage, ads.Ad{}); err != nil {
+		return messages.Message{}, err
+	}
+	return a.messageRepo.CreateMessage(ctx, conv.ID, UserID, Text)
+}
+
+// GetMessages returns the whole thread and marks the messages addressed
+// to the user as read.
+func (a *app) GetMessages(ctx context.Context, conversationID int64, UserID int64) ([]messages.Message, error) {
+	conv, err := a.messageRepo.GetConversation(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !conv.HasParticipant(UserID) {
+		return nil, ErrWrongUser
+	}
+	msgs, err := a.messageRepo.GetMessages(ctx, conv.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.messageRepo.MarkRead(ctx, conv.ID, UserID); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// GetInbox lists the conversations of UserID, for the user or an admin.
+func (a *app) GetInbox(ctx context.Context, actorID int64, UserID int64) ([]messages.Conversation, error) {
+	if err := a.authorizeOnUser(ctx, actorID, ActionReadInbox, UserID); err != nil {
+		return nil, err
+	}
+	if _, err := a.userRepo.GetUser(ctx, UserID); err != nil {
+		return nil, err
+	}
+	return a.messageRepo.GetConversationsByUser(ctx, UserID)
+}
+
+func (a *app) GetAdConversations(ctx context.Context, adID int64, UserID int64) ([]messages.Conversation, error) {
+	ad, err := a.adRepo.GetAd(ctx, adID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.authorize(ctx, UserID, ActionReadAdConversations, ad); err != nil {
+		return nil, err
+	}
+	return a.messageRepo.GetConversationsByAd(ctx, ad.ID)
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/mirgalieva/valid"
+	"homework9/internal/ads"
+	"homework9/internal/reports"
+)
+
+type ValidRejectReason struct {
+	Reason string `validate:"min:1,max:500"`
+}
+
+func (a *app) GetModerationQueue(ctx context.Context, moderatorID int64) ([]ads.Ad, error) {
+	if err := a.authorize(ctx, moderatorID, ActionModerateAd, ads.Ad{}); err != nil {
+		return nil, err
+	}
+	return a.adRepo.GetAdsByStatus(ctx, ads.StatusPendingReview)
+}
+
+// ApproveAd publishes the ad and dismisses its open reports, the moderator
+// has seen them. Otherwise the next report would hide the ad again.
+func (a *app) ApproveAd(ctx context.Context, adID int64, moderatorID int64) (ads.Ad, error) {
+	ad, err := a.adRepo.GetAd(ctx, adID)
+	if err != nil {
+		return ads.Ad{}, fmt.Errorf("invalid adId: %w", err)
+	}
+	if err := a.authorize(ctx, moderatorID, ActionModerateAd, ad); err != nil {
+		return ads.Ad{}, err
+	}
+	ad, err = a.transition(ctx, ad, ads.StatusPublished, "")
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	if err := a.reportRepo.ResolveReports(ctx, ad.ID, reports.ResolutionDismissed); err != nil {
+		return ads.Ad{}, err
+	}
+	return ad, nil
+}
+
+func (a *app) RejectAd(ctx context.Context, adID int64, moderatorID int64, Reason string) (ads.Ad, error) {
+	if err := homework.Validate(ValidRejectReason{Reason}); err != nil {
+		return ads.Ad{}, ErrValidationFail
+	}
+	ad, err := a.adRepo.GetAd(ctx, adID)
+	if err != nil {
+		return ads.Ad{}, fmt.Errorf("invalid adId: %w", err)
+	}
+	if err := a.authorize(ctx, moderatorID, ActionModerateAd, ad); err != nil {
+		return ads.Ad{}, err
+	}
+	return a.transition(ctx, ad, ads.StatusRejected, Reason)
+}
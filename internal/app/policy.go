@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"homework9/internal/ads"
+	"homework9/internal/identity"
+	"homework9/internal/users"
+	"log/slog"
+)
+
+type Action int
+
+const (
+	ActionCreateAd Action = iota
+	ActionUpdateAd
+	ActionPublishAd
+	ActionUnpublishAd
+	ActionDeleteAd
+	ActionSendMessage
+	ActionSetUserRole
+	ActionBanUser
+	ActionModerateAd
+	ActionReportAd
+	ActionRenewAd
+	ActionExportData
+	ActionImportData
+	ActionReadInbox
+	ActionDeleteUser
+	ActionReadAdConversations
+	ActionReadAd
+)
+
+// Can decides whether the actor may perform the action. ad is the target
+// of ad-related actions and is ignored otherwise. Banned users are checked
+// separately by authorize, so Can only looks at ownership and roles.
+func Can(actor users.User, action Action, ad ads.Ad) bool {
+	isAuthor := ad.AuthorID == actor.ID
+	switch action {
+	case ActionCreateAd, ActionSendMessage, ActionReportAd:
+		return true
+	case ActionUpdateAd, ActionPublishAd, ActionRenewAd:
+		return isAuthor
+	case ActionReadAd:
+		return ad.Published() || isAuthor || actor.Role == users.RoleModerator || actor.Role == users.RoleAdmin
+	case ActionUnpublishAd, ActionReadAdConversations:
+		return isAuthor || actor.Role == users.RoleModerator || actor.Role == users.RoleAdmin
+	case ActionModerateAd:
+		return actor.Role == users.RoleModerator || actor.Role == users.RoleAdmin
+	case ActionDeleteAd:
+		return isAuthor || actor.Role == users.RoleAdmin
+	case ActionSetUserRole, ActionBanUser, ActionExportData, ActionImportData:
+		return actor.Role == users.RoleAdmin
+	}
+	return false
+}
+
+// CanOnUser is Can for the actions whose target is a user rather than an
+// ad: users act on themselves, admins on anyone.
+func CanOnUser(actor users.User, action Action, targetID int64) bool {
+	switch action {
+	case ActionReadInbox, ActionDeleteUser:
+		return actor.ID == targetID || actor.Role == users.RoleAdmin
+	}
+	return false
+}
+
+// authorize loads the actor and applies Can. Unknown users are treated as
+// plain users so that ownership checks keep working for them.
+func (a *app) authorize(ctx context.Context, userID int64, action Action, ad ads.Ad) error {
+	actor, err := a.actor(ctx, userID, action)
+	if err != nil {
+		return err
+	}
+	if !Can(actor, action, ad) {
+		slog.InfoContext(ctx, "permission denied", "user_id", userID, "action", int(action), "ad_id", ad.ID)
+		return ErrWrongUser
+	}
+	return nil
+}
+
+// authorizeOnUser is authorize for the actions of CanOnUser.
+func (a *app) authorizeOnUser(ctx context.Context, userID int64, action Action, targetID int64) error {
+	actor, err := a.actor(ctx, userID, action)
+	if err != nil {
+		return err
+	}
+	if !CanOnUser(actor, action, targetID) {
+		slog.InfoContext(ctx, "permission denied", "user_id", userID, "action", int(action), "target_id", targetID)
+		return ErrWrongUser
+	}
+	return nil
+}
+
+// WithClients lists the clients that may call with a certificate and the
+// highest role each of them acts with, see actor.
+func WithClients(clients map[string]users.Role) Option {
+	return func(a *app) {
+		a.clients = clients
+	}
+}
+
+var roleRank = map[users.Role]int{users.RoleUser: 0, users.RoleModerator: 1, users.RoleAdmin: 2}
+
+// actor loads the user an action is made for. A call made with a client
+// certificate acts with at most the role of its client, clients missing
+// from WithClients are denied everything.
+func (a *app) actor(ctx context.Context, userID int64, action Action) (users.User, error) {
+	actor, err := a.userRepo.GetUser(ctx, userID)
+	if err != nil {
+		actor = users.User{ID: userID, Role: users.RoleUser}
+	}
+	if actor.Banned {
+		slog.InfoContext(ctx, "banned user denied", "user_id", userID, "action", int(action))
+		return users.User{}, ErrUserBanned
+	}
+	if name, ok := identity.Client(ctx); ok {
+		role, known := a.clients[name]
+		if !known {
+			slog.InfoContext(ctx, "unknown client denied", "client", name, "user_id", userID, "action", int(action))
+			return users.User{}, ErrUnknownClient
+		}
+		if roleRank[actor.Role] > roleRank[role] {
+			actor.Role = role
+		}
+	}
+	return actor, nil
+}
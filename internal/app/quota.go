@@ -0,0 +1,80 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrQuotaExceeded = errors.New("daily ad quota exceeded")
+
+// QuotaExceededError says when the quota of the user is renewed.
+type QuotaExceededError struct {
+	Limit      int
+	RetryAfter time.Duration
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s: %d ads a day", ErrQuotaExceeded, e.Limit)
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// WithDailyAdQuota limits the ads one user creates a UTC day, 0 means no
+// limit. Deleting an ad does not give its place in the quota back. The
+// creations are counted in memory, a restart of the service renews the
+// quota of everyone.
+func WithDailyAdQuota(n int) Option {
+	return func(a *app) {
+		a.dailyAdQuota = n
+	}
+}
+
+// adQuota counts the ads every user created on day.
+type adQuota struct {
+	mu      sync.Mutex
+	day     time.Time
+	created map[int64]int
+}
+
+// reserveQuota takes up to n ads off the quota of the user for today and
+// returns how many it took, or QuotaExceededError when none are left.
+// Reserved ads that are not created go back with releaseQuota.
+func (a *app) reserveQuota(userID int64, n int) (int, error) {
+	if a.dailyAdQuota <= 0 {
+		return n, nil
+	}
+	a.quota.mu.Lock()
+	defer a.quota.mu.Unlock()
+	day := a.now().UTC().Truncate(24 * time.Hour)
+	if !day.Equal(a.quota.day) {
+		a.quota.day = day
+		a.quota.created = make(map[int64]int)
+	}
+	left := a.dailyAdQuota - a.quota.created[userID]
+	if left <= 0 {
+		return 0, &QuotaExceededError{Limit: a.dailyAdQuota, RetryAfter: a.quotaRetryAfter()}
+	}
+	n = min(n, left)
+	a.quota.created[userID] += n
+	return n, nil
+}
+
+func (a *app) releaseQuota(userID int64, n int) {
+	if a.dailyAdQuota <= 0 || n == 0 {
+		return
+	}
+	a.quota.mu.Lock()
+	defer a.quota.mu.Unlock()
+	// the counter starts over at midnight, a reservation of yesterday is gone
+	a.quota.created[userID] = max(a.quota.created[userID]-n, 0)
+}
+
+// quotaRetryAfter is the time left until the quota is renewed at midnight UTC.
+func (a *app) quotaRetryAfter() time.Duration {
+	now := a.now().UTC()
+	return now.Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now)
+}
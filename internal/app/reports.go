@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/mirgalieva/valid"
+	"homework9/internal/ads"
+	"homework9/internal/reports"
+)
+
+const defaultReportThreshold = 3
+
+type ValidReportComment struct {
+	Comment string `validate:"max:500"`
+}
+
+// WithReportThreshold sets how many distinct users have to report a
+// published ad before it is hidden and sent back to review.
+func WithReportThreshold(n int) Option {
+	return func(a *app) {
+		a.reportThreshold = n
+	}
+}
+
+func (a *app) ReportAd(ctx context.Context, adID int64, UserID int64, Reason reports.Reason, Comment string) (reports.Report, error) {
+	if !Reason.Valid() {
+		return reports.Report{}, ErrValidationFail
+	}
+	if err := homework.Validate(ValidReportComment{Comment}); err != nil {
+		return reports.Report{}, ErrValidationFail
+	}
+	if _, err := a.userRepo.GetUser(ctx, UserID); err != nil {
+		return reports.Report{}, ErrWrongUser
+	}
+	ad, err := a.adRepo.GetAd(ctx, adID)
+	if err != nil {
+		return reports.Report{}, fmt.Errorf("invalid adId: %w", err)
+	}
+	if ad.AuthorID == UserID {
+		return reports.Report{}, ErrWrongUser
+	}
+	if err := a.authorize(ctx, UserID, ActionReportAd, ad); err != nil {
+		return reports.Report{}, err
+	}
+	report, err := a.reportRepo.CreateReport(ctx, ad.ID, UserID, Reason, Comment)
+	if err != nil {
+		return reports.Report{}, err
+	}
+	if err := a.hideIfReported(ctx, ad); err != nil {
+		return reports.Report{}, err
+	}
+	return report, nil
+}
+
+// hideIfReported moves a published ad back to review once enough distinct
+// users have reported it.
+func (a *app) hideIfReported(ctx context.Context, ad ads.Ad) error {
+	if !ad.Published() {
+		return nil
+	}
+	hidden, err := a.hiddenByReports(ctx, ad.ID)
+	if err != nil || !hidden {
+		return err
+	}
+	_, err = a.transition(ctx, ad, ads.StatusPendingReview, "")
+	return err
+}
+
+// hiddenByReports tells whether enough distinct users reported the ad to
+// keep it off the site until a moderator resolves the reports.
+func (a *app) hiddenByReports(ctx context.Context, adID int64) (bool, error) {
+	open, err := a.reportRepo.GetOpenReportsByAd(ctx, adID)
+	if err != nil {
+		return false, err
+	}
+	summary := reports.Summarize(open)
+	return len(summary) > 0 && summary[0].Reporters >= a.reportThreshold, nil
+}
+
+func (a *app) GetReportedAds(ctx context.Context, moderatorID int64) ([]reports.Summary, error) {
+	if err := a.authorize(ctx, moderatorID, ActionModerateAd, ads.Ad{}); err != nil {
+		return nil, err
+	}
+	open, err := a.reportRepo.GetOpenReports(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reports.Summarize(open), nil
+}
+
+// ResolveReports closes all open reports of the ad. Dismissing them puts a
+// hidden ad back online, rejecting takes the ad down with the comment as
+// the reject reason.
+func (a *app) ResolveReports(ctx context.Context, adID int64, moderatorID int64, Resolution reports.Resolution, Comment string) (ads.Ad, error) {
+	if err := homework.Validate(ValidReportComment{Comment}); err != nil {
+		return ads.Ad{}, ErrValidationFail
+	}
+	ad, err := a.adRepo.GetAd(ctx, adID)
+	if err != nil {
+		return ads.Ad{}, fmt.Errorf("invalid adId: %w", err)
+	}
+	if err := a.authorize(ctx, moderatorID, ActionModerateAd, ad); err != nil {
+		return ads.Ad{}, err
+	}
+	switch Resolution {
+	case reports.ResolutionDismissed:
+		if ad.Status == ads.StatusPendingReview {
+			ad, err = a.transition(ctx, ad, ads.StatusPublished, "")
+		}
+	case reports.ResolutionRejected:
+		if err := homework.Validate(ValidRejectReason{Comment}); err != nil {
+			return ads.Ad{}, ErrValidationFail
+		}
+		if ad.Published() {
+			ad, err = a.transition(ctx, ad, ads.StatusPendingReview, "")
+			if err != nil {
+				return ads.Ad{}, err
+			}
+		}
+		ad, err = a.transition(ctx, ad, ads.StatusRejected, Comment)
+	default:
+		return ads.Ad{}, ErrValidationFail
+	}
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	if err := a.reportRepo.ResolveReports(ctx, ad.ID, Resolution); err != nil {
+		return ads.Ad{}, err
+	}
+	return ad, nil
+}
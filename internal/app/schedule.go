@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework9/internal/ads"
+	"log/slog"
+	"time"
+)
+
+// ScheduleAd makes the ad go live at PublishAt. The schedule is stored on
+// the ad in the repository, so it is picked up again after a restart.
+func (a *app) ScheduleAd(ctx context.Context, adID int64, UserID int64, PublishAt time.Time) (ads.Ad, error) {
+	ad, err := a.adRepo.GetAd(ctx, adID)
+	if err != nil {
+		return ads.Ad{}, fmt.Errorf("invalid adId: %w", err)
+	}
+	if err := a.authorize(ctx, UserID, ActionPublishAd, ad); err != nil {
+		return ads.Ad{}, err
+	}
+	if !PublishAt.After(a.now()) {
+		return ads.Ad{}, fmt.Errorf("%w: publish time is in the past", ErrValidationFail)
+	}
+	if ad.Published() || !ad.Status.CanTransitionTo(ads.StatusPendingReview) {
+		return ads.Ad{}, fmt.Errorf("%w: can not schedule %s ad", ErrInvalidTransition, ad.Status)
+	}
+	return a.adRepo.SetAdPublishAt(ctx, ad.ID, PublishAt.UTC())
+}
+
+func (a *app) CancelScheduledAd(ctx context.Context, adID int64, UserID int64) (ads.Ad, error) {
+	ad, err := a.adRepo.GetAd(ctx, adID)
+	if err != nil {
+		return ads.Ad{}, fmt.Errorf("invalid adId: %w", err)
+	}
+	if err := a.authorize(ctx, UserID, ActionPublishAd, ad); err != nil {
+		return ads.Ad{}, err
+	}
+	if !ad.Scheduled() {
+		return ads.Ad{}, fmt.Errorf("%w: ad is not scheduled", ErrInvalidTransition)
+	}
+	return a.adRepo.SetAdPublishAt(ctx, ad.ID, time.Time{})
+}
+
+// PublishScheduledAds submits the ads whose publish time has come. An ad
+// that can no longer be published, e.g. because its author got banned,
+// loses its schedule. It is run periodically by the scheduler. A failed ad
+// is logged and skipped, the errors of all of them are returned together.
+func (a *app) PublishScheduledAds(ctx context.Context) error {
+	due, err := a.adRepo.GetScheduledAds(ctx, a.now())
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, ad := range due {
+		if err := a.publishScheduledAd(ctx, ad); err != nil {
+			slog.ErrorContext(ctx, "scheduled publishing failed", "ad_id", ad.ID, "error", err)
+			errs = append(errs, fmt.Errorf("ad %d: %w", ad.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *app) publishScheduledAd(ctx context.Context, ad ads.Ad) error {
+	err := a.authorize(ctx, ad.AuthorID, ActionPublishAd, ad)
+	if err == nil {
+		_, err = a.submitAd(ctx, ad)
+	}
+	if errors.Is(err, ErrWrongUser) || errors.Is(err, ErrInvalidTransition) {
+		_, err = a.adRepo.SetAdPublishAt(ctx, ad.ID, time.Time{})
+	}
+	return err
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/mirgalieva/valid"
+	"homework9/internal/ads"
+	"homework9/internal/users"
+	"sort"
+)
+
+// ImportResult holds one error per imported record, in the order of the
+// records. A nil error means the record was stored.
+type ImportResult struct {
+	Users []error
+	Ads   []error
+}
+
+func (a *app) ExportUsers(ctx context.Context, adminID int64) ([]users.User, error) {
+	if err := a.authorize(ctx, adminID, ActionExportData, ads.Ad{}); err != nil {
+		return nil, err
+	}
+	all := a.userRepo.GetUsers(ctx)
+	list := make([]users.User, 0, len(all))
+	for _, user := range all {
+		list = append(list, user)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+	return list, nil
+}
+
+func (a *app) ExportAds(ctx context.Context, adminID int64) ([]ads.Ad, error) {
+	if err := a.authorize(ctx, adminID, ActionExportData, ads.Ad{}); err != nil {
+		return nil, err
+	}
+	return a.adRepo.GetAllAds(ctx)
+}
+
+// Import stores exported users and ads after the checks CreateUser and
+// CreateAd apply. Users are imported first. Without PreserveIDs records get
+// new IDs and the ads of imported users follow their authors to the new IDs,
+// an ad whose author is not among the imported users is rejected then.
+func (a *app) Import(ctx context.Context, adminID int64, Users []users.User, Ads []ads.Ad, PreserveIDs bool) (ImportResult, error) {
+	if err := a.authorize(ctx, adminID, ActionImportData, ads.Ad{}); err != nil {
+		return ImportResult{}, err
+	}
+	res := ImportResult{Users: make([]error, len(Users)), Ads: make([]error, len(Ads))}
+	newIDs := make(map[int64]int64, len(Users))
+	for i, user := range Users {
+		stored, err := a.importUser(ctx, user, PreserveIDs)
+		if err != nil {
+			res.Users[i] = err
+			continue
+		}
+		newIDs[user.ID] = stored.ID
+	}
+	for i, ad := range Ads {
+		if !PreserveIDs {
+			id, ok := newIDs[ad.AuthorID]
+			if !ok {
+				res.Ads[i] = fmt.Errorf("%w: unknown author %d", ErrValidationFail, ad.AuthorID)
+				continue
+			}
+			ad.AuthorID = id
+		}
+		res.Ads[i] = a.importAd(ctx, ad, PreserveIDs)
+	}
+	return res, nil
+}
+
+func (a *app) importUser(ctx context.Context, user users.User, PreserveIDs bool) (users.User, error) {
+	if err := homework.Validate(ValidNicknameAndEmail{Nickname: user.Nickname, Email: user.Email}); err != nil {
+		return users.User{}, ErrValidationFail
+	}
+	if user.Role == "" {
+		user.Role = users.RoleUser
+	}
+	if !user.Role.Valid() {
+		return users.User{}, fmt.Errorf("%w: unknown role %q", ErrValidationFail, user.Role)
+	}
+	return a.userRepo.ImportUser(ctx, user, PreserveIDs)
+}
+
+func (a *app) importAd(ctx context.Context, ad ads.Ad, PreserveIDs bool) error {
+	if err := homework.Validate(ValidTitleAndText{ad.Title, ad.Text}); err != nil {
+		return ErrValidationFail
+	}
+	if ad.Status == "" {
+		ad.Status = ads.StatusDraft
+	}
+	if !ad.Status.Valid() {
+		return fmt.Errorf("%w: unknown status %q", ErrValidationFail, ad.Status)
+	}
+	if _, err := a.userRepo.GetUser(ctx, ad.AuthorID); err != nil {
+		return fmt.Errorf("%w: unknown author %d", ErrValidationFail, ad.AuthorID)
+	}
+	if err := a.checkContent(ctx, ads.Ad{ID: -1, Title: ad.Title, Text: ad.Text, AuthorID: ad.AuthorID}); err != nil {
+		return err
+	}
+	if ad.Published() && ad.ExpiresAt.IsZero() {
+		ad.ExpiresAt = a.now().UTC().Add(a.adTTL)
+	}
+	_, err := a.adRepo.ImportAd(ctx, ad, PreserveIDs)
+	return err
+}
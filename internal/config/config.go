@@ -0,0 +1,408 @@
+// Package config loads the server configuration. Every setting has a
+// default and can be set in a YAML or TOML file, in an environment variable
+// and with a command-line flag, later sources win in this order.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+	"homework9/internal/users"
+)
+
+// EnvPrefix starts the environment variable of every setting, e.g.
+// AD_HTTP_ADDR for http.addr.
+const EnvPrefix = "AD_"
+
+type Config struct {
+	HTTP            HTTPConfig        `yaml:"http" toml:"http"`
+	GRPC            GRPCConfig        `yaml:"grpc" toml:"grpc"`
+	ShutdownTimeout Duration          `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	ShutdownDelay   Duration          `yaml:"shutdown_delay" toml:"shutdown_delay"`
+	Storage         StorageConfig     `yaml:"storage" toml:"storage"`
+	Log             LogConfig         `yaml:"log" toml:"log"`
+	Tracing         TracingConfig     `yaml:"tracing" toml:"tracing"`
+	TLS             TLSConfig         `yaml:"tls" toml:"tls"`
+	RateLimit       RateLimitConfig   `yaml:"rate_limit" toml:"rate_limit"`
+	Moderation      ModerationConfig  `yaml:"moderation" toml:"moderation"`
+	Idempotency     IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	Cache           CacheConfig       `yaml:"cache" toml:"cache"`
+	AdminEmail      string            `yaml:"admin_email" toml:"admin_email"`
+}
+
+type HTTPConfig struct {
+	Addr         string   `yaml:"addr" toml:"addr"`
+	ReadTimeout  Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout Duration `yaml:"write_timeout" toml:"write_timeout"`
+}
+
+type GRPCConfig struct {
+	Addr string `yaml:"addr" toml:"addr"`
+}
+
+type StorageConfig struct {
+	// Backend is the kind of repositories, only "memory" exists for now.
+	Backend string `yaml:"backend" toml:"backend"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level" toml:"level"`
+}
+
+type TracingConfig struct {
+	// Exporter is none, stdout or file. The file exporter appends one JSON
+	// span per line to File.
+	Exporter string `yaml:"exporter" toml:"exporter"`
+	File     string `yaml:"file" toml:"file"`
+}
+
+// TLSConfig turns TLS on when CertFile and KeyFile are set. ClientCAFile
+// additionally requires client certificates on the gRPC port.
+type TLSConfig struct {
+	CertFile     string `yaml:"cert_file" toml:"cert_file"`
+	KeyFile      string `yaml:"key_file" toml:"key_file"`
+	ClientCAFile string `yaml:"client_ca_file" toml:"client_ca_file"`
+	// Clients maps the name in a client certificate to the highest role
+	// its calls act with, other clients are denied.
+	Clients map[string]string `yaml:"clients,omitempty" toml:"clients,omitempty"`
+}
+
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// RPS and Burst are the default token bucket of a client.
+	RPS   float64 `yaml:"rps" toml:"rps"`
+	Burst int     `yaml:"burst" toml:"burst"`
+	// AdsPerDay limits the ads one user creates a day, 0 means no limit.
+	AdsPerDay int `yaml:"ads_per_day" toml:"ads_per_day"`
+	// Routes overrides the default bucket per gin route ("POST /api/v1/ads")
+	// or gRPC method ("/ad.AdService/CreateAd").
+	Routes map[string]Limit `yaml:"routes,omitempty" toml:"routes,omitempty"`
+}
+
+type Limit struct {
+	RPS   float64 `yaml:"rps" toml:"rps"`
+	Burst int     `yaml:"burst" toml:"burst"`
+}
+
+type ModerationConfig struct {
+	Premoderation   bool   `yaml:"premoderation" toml:"premoderation"`
+	BannedWordsFile string `yaml:"banned_words_file" toml:"banned_words_file"`
+}
+
+type IdempotencyConfig struct {
+	// TTL is how long the response to an Idempotency-Key is replayed, 0
+	// turns the keys off.
+	TTL Duration `yaml:"ttl" toml:"ttl"`
+}
+
+// CacheConfig sizes the cache of ad reads, 0 turns it off.
+type CacheConfig struct {
+	// Size is how many ads the cache holds, a cached listing counts all of
+	// its ads.
+	Size int      `yaml:"size" toml:"size"`
+	TTL  Duration `yaml:"ttl" toml:"ttl"`
+}
+
+func Default() Config {
+	return Config{
+		HTTP: HTTPConfig{
+			Addr:         ":9000",
+			ReadTimeout:  Duration{10 * time.Second},
+			WriteTimeout: Duration{30 * time.Second},
+		},
+		GRPC:            GRPCConfig{Addr: ":50054"},
+		ShutdownTimeout: Duration{30 * time.Second},
+		ShutdownDelay:   Duration{5 * time.Second},
+		Storage:         StorageConfig{Backend: "memory"},
+		Log:             LogConfig{Level: "info"},
+		Tracing:         TracingConfig{Exporter: "none"},
+		RateLimit:       RateLimitConfig{RPS: 10, Burst: 20, AdsPerDay: 50},
+		Moderation:      ModerationConfig{Premoderation: true},
+		Idempotency:     IdempotencyConfig{TTL: Duration{24 * time.Hour}},
+		Cache:           CacheConfig{Size: 10000, TTL: Duration{30 * time.Second}},
+	}
+}
+
+// Duration is a time.Duration written as "30s" in files.
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+// setting binds one field of the config to its flag and variable names.
+type setting struct {
+	name  string
+	usage string
+	value func(c *Config) flag.Value
+}
+
+var settings = []setting{
+	{"http.addr", "HTTP listen address", func(c *Config) flag.Value { return (*stringValue)(&c.HTTP.Addr) }},
+	{"http.read-timeout", "HTTP request read timeout", func(c *Config) flag.Value { return (*durationValue)(&c.HTTP.ReadTimeout) }},
+	{"http.write-timeout", "HTTP response write timeout", func(c *Config) flag.Value { return (*durationValue)(&c.HTTP.WriteTimeout) }},
+	{"grpc.addr", "gRPC listen address", func(c *Config) flag.Value { return (*stringValue)(&c.GRPC.Addr) }},
+	{"shutdown-timeout", "graceful shutdown timeout", func(c *Config) flag.Value { return (*durationValue)(&c.ShutdownTimeout) }},
+	{"shutdown-delay", "time to report not ready before stopping", func(c *Config) flag.Value { return (*durationValue)(&c.ShutdownDelay) }},
+	{"storage.backend", "storage backend: memory", func(c *Config) flag.Value { return (*stringValue)(&c.Storage.Backend) }},
+	{"log.level", "log level: debug, info, warn or error", func(c *Config) flag.Value { return (*stringValue)(&c.Log.Level) }},
+	{"tracing.exporter", "span exporter: none, stdout or file", func(c *Config) flag.Value { return (*stringValue)(&c.Tracing.Exporter) }},
+	{"tracing.file", "file of the file span exporter", func(c *Config) flag.Value { return (*stringValue)(&c.Tracing.File) }},
+	{"tls.cert-file", "TLS certificate file", func(c *Config) flag.Value { return (*stringValue)(&c.TLS.CertFile) }},
+	{"tls.key-file", "TLS key file", func(c *Config) flag.Value { return (*stringValue)(&c.TLS.KeyFile) }},
+	{"tls.client-ca-file", "CA of the gRPC client certificates, enables mTLS", func(c *Config) flag.Value { return (*stringValue)(&c.TLS.ClientCAFile) }},
+	{"rate-limit.enabled", "enable rate limiting", func(c *Config) flag.Value { return (*boolValue)(&c.RateLimit.Enabled) }},
+	{"rate-limit.rps", "requests per second of a client", func(c *Config) flag.Value { return (*floatValue)(&c.RateLimit.RPS) }},
+	{"rate-limit.burst", "burst of requests of a client", func(c *Config) flag.Value { return (*intValue)(&c.RateLimit.Burst) }},
+	{"rate-limit.ads-per-day", "ads one user may create a day, 0 for no limit", func(c *Config) flag.Value { return (*intValue)(&c.RateLimit.AdsPerDay) }},
+	{"moderation.premoderation", "make ads wait for a moderator before publishing", func(c *Config) flag.Value { return (*boolValue)(&c.Moderation.Premoderation) }},
+	{"moderation.banned-words-file", "file with banned words, one per line", func(c *Config) flag.Value { return (*stringValue)(&c.Moderation.BannedWordsFile) }},
+	{"idempotency.ttl", "how long responses to an Idempotency-Key are replayed, 0 to disable", func(c *Config) flag.Value { return (*durationValue)(&c.Idempotency.TTL) }},
+	{"cache.size", "ads held by the cache of ad reads, 0 to disable", func(c *Config) flag.Value { return (*intValue)(&c.Cache.Size) }},
+	{"cache.ttl", "how long an ad read stays cached", func(c *Config) flag.Value { return (*durationValue)(&c.Cache.TTL) }},
+	{"admin-email", "email of the administrator created on start", func(c *Config) flag.Value { return (*stringValue)(&c.AdminEmail) }},
+}
+
+// legacyEnv are the variables read before the config package existed, they
+// are still honored when the AD_ variable is not set.
+var legacyEnv = map[string]string{
+	"admin-email":                  "ADMIN_EMAIL",
+	"moderation.banned-words-file": "BANNED_WORDS_FILE",
+}
+
+// envName turns "rate-limit.ads-per-day" into "AD_RATE_LIMIT_ADS_PER_DAY".
+func envName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(name))
+}
+
+// Options are the command-line flags that are not settings.
+type Options struct {
+	ConfigFile  string
+	PrintConfig bool
+}
+
+// Load builds the config from the defaults, the file given by -config or
+// AD_CONFIG, the environment and the flags in args, then validates it.
+func Load(args []string, getenv func(string) string) (Config, Options, error) {
+	var opts Options
+	flagCfg := Default()
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&opts.ConfigFile, "config", getenv(EnvPrefix+"CONFIG"), "YAML or TOML config file")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "print the effective config and exit")
+	for _, s := range settings {
+		fs.Var(s.value(&flagCfg), s.name, s.usage+" (env "+envName(s.name)+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, Options{}, err
+	}
+	if fs.NArg() > 0 {
+		return Config{}, Options{}, fmt.Errorf("unexpected arguments %v", fs.Args())
+	}
+
+	cfg := Default()
+	if opts.ConfigFile != "" {
+		if err := loadFile(opts.ConfigFile, &cfg); err != nil {
+			return Config{}, Options{}, err
+		}
+	}
+	for _, s := range settings {
+		v := getenv(envName(s.name))
+		if v == "" && legacyEnv[s.name] != "" {
+			v = getenv(legacyEnv[s.name])
+		}
+		if v != "" {
+			if err := s.value(&cfg).Set(v); err != nil {
+				return Config{}, Options{}, fmt.Errorf("%s: %w", envName(s.name), err)
+			}
+		}
+	}
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.name == f.Name && flagErr == nil {
+				flagErr = s.value(&cfg).Set(f.Value.String())
+			}
+		}
+	})
+	if flagErr != nil {
+		return Config{}, Options{}, flagErr
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, Options{}, err
+	}
+	return cfg, opts, nil
+}
+
+// Usage prints the flags together with their environment variables.
+func Usage(w io.Writer) {
+	cfg := Default()
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(w)
+	fs.String("config", "", "YAML or TOML config file (env "+EnvPrefix+"CONFIG)")
+	fs.Bool("print-config", false, "print the effective config and exit")
+	for _, s := range settings {
+		fs.Var(s.value(&cfg), s.name, s.usage+" (env "+envName(s.name)+")")
+	}
+	fs.PrintDefaults()
+}
+
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(cfg)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	case ".toml":
+		err = toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields().Decode(cfg)
+	default:
+		return fmt.Errorf("config file %s: unknown extension, want .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c Config) Validate() error {
+	var errs []string
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf(format, args...))
+		}
+	}
+	for name, addr := range map[string]string{"http.addr": c.HTTP.Addr, "grpc.addr": c.GRPC.Addr} {
+		_, _, err := net.SplitHostPort(addr)
+		check(err == nil, "%s: invalid address %q", name, addr)
+	}
+	check(c.HTTP.ReadTimeout.Duration >= 0, "http.read-timeout: must not be negative")
+	check(c.HTTP.WriteTimeout.Duration >= 0, "http.write-timeout: must not be negative")
+	check(c.ShutdownTimeout.Duration > 0, "shutdown-timeout: must be positive")
+	check(c.ShutdownDelay.Duration >= 0, "shutdown-delay: must not be negative")
+	check(c.Storage.Backend == "memory", "storage.backend: unknown backend %q", c.Storage.Backend)
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		check(false, "log.level: unknown level %q", c.Log.Level)
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "file":
+		check(c.Tracing.File != "", "tracing.file: required by the file exporter")
+	default:
+		check(false, "tracing.exporter: unknown exporter %q", c.Tracing.Exporter)
+	}
+	check((c.TLS.CertFile == "") == (c.TLS.KeyFile == ""), "tls: cert-file and key-file must be set together")
+	check(c.TLS.ClientCAFile == "" || c.TLS.Enabled(), "tls.client-ca-file: requires cert-file and key-file")
+	check(c.TLS.ClientCAFile == "" || len(c.TLS.Clients) > 0, "tls.clients: required by client-ca-file")
+	for name, role := range c.TLS.Clients {
+		check(users.Role(role).Valid(), "tls.clients[%s]: unknown role %q", name, role)
+	}
+	if c.RateLimit.Enabled {
+		check(c.RateLimit.RPS > 0, "rate-limit.rps: must be positive")
+		check(c.RateLimit.Burst >= 1, "rate-limit.burst: must be at least 1")
+		for route, l := range c.RateLimit.Routes {
+			check(l.RPS > 0 && l.Burst >= 1, "rate-limit.routes[%s]: rps must be positive and burst at least 1", route)
+		}
+	}
+	check(c.RateLimit.AdsPerDay >= 0, "rate-limit.ads-per-day: must not be negative")
+	check(c.Idempotency.TTL.Duration >= 0, "idempotency.ttl: must not be negative")
+	check(c.Cache.Size >= 0, "cache.size: must not be negative")
+	check(c.Cache.Size == 0 || c.Cache.TTL.Duration > 0, "cache.ttl: must be positive")
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Print writes the config as YAML, the format --print-config uses.
+func (c Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+type stringValue string
+
+func (v *stringValue) String() string     { return string(*v) }
+func (v *stringValue) Set(s string) error { *v = stringValue(s); return nil }
+
+type boolValue bool
+
+func (v *boolValue) String() string { return strconv.FormatBool(bool(*v)) }
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v = boolValue(b)
+	return nil
+}
+func (v *boolValue) IsBoolFlag() bool { return true }
+
+type intValue int
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*v = intValue(n)
+	return nil
+}
+
+type floatValue float64
+
+func (v *floatValue) String() string { return strconv.FormatFloat(float64(*v), 'g', -1, 64) }
+func (v *floatValue) Set(s string) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*v = floatValue(f)
+	return nil
+}
+
+type durationValue Duration
+
+func (v *durationValue) String() string { return v.Duration.String() }
+func (v *durationValue) Set(s string) error {
+	return (*Duration)(v).UnmarshalText([]byte(s))
+}
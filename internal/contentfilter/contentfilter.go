@@ -0,0 +1,193 @@
+package contentfilter
+
+import (
+	"bufio"
+	"context"
+	"embed"
+	"fmt"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+//go:embed words/*.txt
+var defaultWords embed.FS
+
+// Default returns the filters the server runs when nothing else is configured.
+func Default() []app.ContentFilter {
+	words, err := DefaultBannedWords()
+	if err != nil {
+		// the lists are embedded at build time, failing here is a build problem
+		panic(err)
+	}
+	return []app.ContentFilter{words, Contacts{}, Caps{}, Duplicates{}}
+}
+
+type BannedWords struct {
+	words map[string]struct{}
+}
+
+func NewBannedWords(words []string) BannedWords {
+	b := BannedWords{words: make(map[string]struct{}, len(words))}
+	for _, w := range words {
+		if w = normalizeWord(w); w != "" {
+			b.words[w] = struct{}{}
+		}
+	}
+	return b
+}
+
+// DefaultBannedWords loads the built-in English and Russian lists.
+func DefaultBannedWords() (BannedWords, error) {
+	var words []string
+	for _, name := range []string{"words/en.txt", "words/ru.txt"} {
+		f, err := defaultWords.Open(name)
+		if err != nil {
+			return BannedWords{}, err
+		}
+		list, err := readWords(f)
+		f.Close()
+		if err != nil {
+			return BannedWords{}, err
+		}
+		words = append(words, list...)
+	}
+	return NewBannedWords(words), nil
+}
+
+// LoadBannedWords reads a list with one word per line, lines starting
+// with # are comments.
+func LoadBannedWords(path string) (BannedWords, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return BannedWords{}, fmt.Errorf("open banned words: %w", err)
+	}
+	defer f.Close()
+	words, err := readWords(f)
+	if err != nil {
+		return BannedWords{}, fmt.Errorf("read banned words: %w", err)
+	}
+	return NewBannedWords(words), nil
+}
+
+func readWords(r io.Reader) ([]string, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, scanner.Err()
+}
+
+func (b BannedWords) Check(ctx context.Context, ad ads.Ad, authorAds []ads.Ad) []app.Rejection {
+	var reasons []app.Rejection
+	for _, field := range fields(ad) {
+		for _, w := range splitWords(field.value) {
+			if _, ok := b.words[normalizeWord(w)]; ok {
+				reasons = append(reasons, app.Rejection{
+					Code:    "banned_word",
+					Field:   field.name,
+					Message: fmt.Sprintf("%q is not allowed", w),
+				})
+			}
+		}
+	}
+	return reasons
+}
+
+var (
+	urlPattern   = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9-]+\.(?:ru|com|net|org|info|biz|me)\b`)
+	phonePattern = regexp.MustCompile(`(?:\+7|\b8|\+\d{1,3})?[\s(.-]*\d{3}[\s).-]*\d{3}[\s.-]*\d{2}[\s.-]*\d{2}\b`)
+)
+
+// Contacts refuses links and phone numbers in the text, buyers are
+// expected to contact the seller through messages.
+type Contacts struct{}
+
+func (Contacts) Check(ctx context.Context, ad ads.Ad, authorAds []ads.Ad) []app.Rejection {
+	var reasons []app.Rejection
+	if urlPattern.MatchString(ad.Text) {
+		reasons = append(reasons, app.Rejection{Code: "contact_info", Field: "text", Message: "links are not allowed"})
+	}
+	if phonePattern.MatchString(ad.Text) {
+		reasons = append(reasons, app.Rejection{Code: "contact_info", Field: "text", Message: "phone numbers are not allowed"})
+	}
+	return reasons
+}
+
+const (
+	capsMinLetters = 10
+	capsMaxRatio   = 0.7
+)
+
+// Caps refuses fields written mostly in capital letters. Short strings
+// such as abbreviations are ignored.
+type Caps struct{}
+
+func (Caps) Check(ctx context.Context, ad ads.Ad, authorAds []ads.Ad) []app.Rejection {
+	var reasons []app.Rejection
+	for _, field := range fields(ad) {
+		letters, upper := 0, 0
+		for _, r := range field.value {
+			if unicode.IsLetter(r) {
+				letters++
+				if unicode.IsUpper(r) {
+					upper++
+				}
+			}
+		}
+		if letters >= capsMinLetters && float64(upper)/float64(letters) > capsMaxRatio {
+			reasons = append(reasons, app.Rejection{Code: "excessive_caps", Field: field.name, Message: "too many capital letters"})
+		}
+	}
+	return reasons
+}
+
+// Duplicates refuses an ad whose title and text repeat another ad of the
+// same author, ignoring case and spacing.
+type Duplicates struct{}
+
+func (Duplicates) Check(ctx context.Context, ad ads.Ad, authorAds []ads.Ad) []app.Rejection {
+	title, text := normalizeText(ad.Title), normalizeText(ad.Text)
+	for _, other := range authorAds {
+		if normalizeText(other.Title) == title && normalizeText(other.Text) == text {
+			return []app.Rejection{{
+				Code:    "duplicate",
+				Field:   "text",
+				Message: fmt.Sprintf("same as ad %d", other.ID),
+			}}
+		}
+	}
+	return nil
+}
+
+type field struct {
+	name  string
+	value string
+}
+
+func fields(ad ads.Ad) []field {
+	return []field{{"title", ad.Title}, {"text", ad.Text}}
+}
+
+func splitWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func normalizeWord(w string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(w)), "ё", "е")
+}
+
+func normalizeText(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// watchInterval is how often Watch runs the checks to detect a change.
+const watchInterval = time.Second
+
+// grpcServer implements grpc.health.v1.Health on top of a Checker. The
+// empty service name is the whole server, the other known names share its
+// status.
+type grpcServer struct {
+	healthpb.UnimplementedHealthServer
+	checker  *Checker
+	services map[string]bool
+}
+
+func NewGRPCServer(c *Checker, services ...string) healthpb.HealthServer {
+	known := map[string]bool{"": true}
+	for _, s := range services {
+		known[s] = true
+	}
+	return &grpcServer{checker: c, services: known}
+}
+
+func (s *grpcServer) status(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	if !s.services[service] {
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, status.Errorf(codes.NotFound, "unknown service %q", service)
+	}
+	if err := s.checker.Ready(ctx); err != nil {
+		return healthpb.HealthCheckResponse_NOT_SERVING, nil
+	}
+	return healthpb.HealthCheckResponse_SERVING, nil
+}
+
+func (s *grpcServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	st, err := s.status(ctx, req.GetService())
+	if err != nil {
+		return nil, err
+	}
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+// Watch sends the current status and then every change of it. Unknown
+// services are reported as SERVICE_UNKNOWN instead of failing, as the
+// protocol asks. The stream ends once the shutdown status is sent, so that
+// it does not hold up the graceful stop of the server.
+func (s *grpcServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		st, _ := s.status(stream.Context(), req.GetService())
+		if st != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+		if s.checker.ShuttingDown() {
+			return nil
+		}
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Package health tells the orchestrator whether the service is alive and
+// whether it should get traffic.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var ErrShuttingDown = errors.New("shutting down")
+
+// checkTimeout bounds one dependency check, a probe must answer quickly
+// even when the storage hangs.
+const checkTimeout = 2 * time.Second
+
+// Check is a dependency the service can not serve without.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Pinger is implemented by storage backends that hold a connection.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PingCheck pings every repository that implements Pinger, the in-memory
+// ones do not and are always reachable.
+func PingCheck(name string, repos ...any) Check {
+	return Check{Name: name, Run: func(ctx context.Context) error {
+		for _, repo := range repos {
+			if p, ok := repo.(Pinger); ok {
+				if err := p.Ping(ctx); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}}
+}
+
+type Checker struct {
+	checks       []Check
+	shuttingDown atomic.Bool
+}
+
+func New(checks ...Check) *Checker {
+	return &Checker{checks: checks}
+}
+
+// Shutdown makes the service not ready for good, it is called at the start
+// of the graceful shutdown so that load balancers stop sending requests.
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether Shutdown was called.
+func (c *Checker) ShuttingDown() bool {
+	return c.shuttingDown.Load()
+}
+
+// Ready runs the checks and returns the failed ones.
+func (c *Checker) Ready(ctx context.Context) error {
+	if c.shuttingDown.Load() {
+		return ErrShuttingDown
+	}
+	var failed []string
+	for _, check := range c.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := check.Run(checkCtx)
+		cancel()
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", check.Name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "; "))
+	}
+	return nil
+}
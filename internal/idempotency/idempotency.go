@@ -0,0 +1,121 @@
+// Package idempotency remembers the results of requests sent with an
+// idempotency key, so a retried request gets the first result instead of
+// running twice.
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrKeyReused is returned for a key that came with another request.
+	ErrKeyReused = errors.New("idempotency key was used with a different request")
+	// ErrInProgress is returned while the first request of the key runs.
+	ErrInProgress = errors.New("a request with this idempotency key is in progress")
+)
+
+// sweepInterval is how often the expired results are dropped.
+const sweepInterval = time.Minute
+
+type Option func(*Store)
+
+func WithClock(now func() time.Time) Option {
+	return func(s *Store) {
+		s.now = now
+	}
+}
+
+// Store keeps a result per key for ttl after the request finished. Only
+// successful results are kept, a failed request may be retried with the
+// same key and runs again.
+type Store struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]*entry
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+type entry struct {
+	fingerprint string
+	done        bool
+	result      any
+	expires     time.Time
+}
+
+func New(ttl time.Duration, opts ...Option) *Store {
+	s := &Store{ttl: ttl, entries: make(map[string]*entry), now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.lastSweep = s.now()
+	return s
+}
+
+// Fingerprint hashes the parts of a request that must match on a retry.
+func Fingerprint(parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Begin claims key for the request with fingerprint. It returns the stored
+// result and true for a retry of a finished request, otherwise the caller
+// runs the request and reports it with Finish or Release.
+func (s *Store) Begin(key string, fingerprint string) (any, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+	e, ok := s.entries[key]
+	if ok && e.done && !now.Before(e.expires) {
+		ok = false
+	}
+	if !ok {
+		s.entries[key] = &entry{fingerprint: fingerprint}
+		return nil, false, nil
+	}
+	if e.fingerprint != fingerprint {
+		return nil, false, ErrKeyReused
+	}
+	if !e.done {
+		return nil, false, ErrInProgress
+	}
+	return e.result, true, nil
+}
+
+// Finish stores the result of the request that claimed key.
+func (s *Store) Finish(key string, result any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok && !e.done {
+		e.done, e.result, e.expires = true, result, s.now().Add(s.ttl)
+	}
+}
+
+// Release forgets key after its request failed.
+func (s *Store) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok && !e.done {
+		delete(s.entries, key)
+	}
+}
+
+func (s *Store) sweep(now time.Time) {
+	for key, e := range s.entries {
+		if e.done && !now.Before(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
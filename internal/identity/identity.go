@@ -0,0 +1,29 @@
+// Package identity carries the authenticated client of a request through
+// context.Context.
+package identity
+
+import "context"
+
+type clientKey struct{}
+
+// WithClient stores the name of the client the transport authenticated.
+func WithClient(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, clientKey{}, name)
+}
+
+func Client(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(clientKey{}).(string)
+	return name, ok && name != ""
+}
+
+type userKey struct{}
+
+// WithUser stores the ID of the user the request acts for.
+func WithUser(ctx context.Context, id int64) context.Context {
+	return context.WithValue(ctx, userKey{}, id)
+}
+
+func User(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(userKey{}).(int64)
+	return id, ok
+}
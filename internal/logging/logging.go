@@ -0,0 +1,95 @@
+// Package logging sets up structured JSON logs and carries the request ID
+// through context.Context, so every line logged for a request has it.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// RequestIDHeader is accepted from and returned to HTTP clients.
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDMetadata is the gRPC metadata key of the request ID.
+	RequestIDMetadata = "x-request-id"
+
+	maxRequestIDLen = 128
+)
+
+type requestIDKey struct{}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("logging: can not read random bytes: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// RequestIDFrom keeps the ID sent by the client when it is sane and makes
+// a new one otherwise, so clients can not write arbitrary text to the logs.
+func RequestIDFrom(id string) string {
+	if id == "" || len(id) > maxRequestIDLen {
+		return NewRequestID()
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r)) {
+			return NewRequestID()
+		}
+	}
+	return id
+}
+
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(s))
+	return level, err
+}
+
+// New returns a JSON logger that adds the request and trace IDs of the
+// context to every record logged with one of the ...Context methods.
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(NewHandler(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})))
+}
+
+func NewHandler(h slog.Handler) slog.Handler {
+	return handler{h}
+}
+
+type handler struct {
+	slog.Handler
+}
+
+func (h handler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return handler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h handler) WithGroup(name string) slog.Handler {
+	return handler{h.Handler.WithGroup(name)}
+}
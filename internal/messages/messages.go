@@ -0,0 +1,29 @@
+package messages
+
+import "time"
+
+// Conversation is a thread between a buyer and the author of an ad.
+// There is at most one conversation per (AdID, BuyerID) pair.
+type Conversation struct {
+	ID         int64
+	AdID       int64
+	BuyerID    int64
+	SellerID   int64
+	DateCreate time.Time
+	DateUpdate time.Time
+}
+
+type Message struct {
+	ID             int64
+	ConversationID int64
+	SenderID       int64
+	Text           string
+	Read           bool
+	DateCreate     time.Time
+	DateRead       time.Time
+}
+
+// HasParticipant reports whether the user is either side of the conversation.
+func (c Conversation) HasParticipant(userID int64) bool {
+	return c.BuyerID == userID || c.SellerID == userID
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+)
+
+var (
+	adsDesc   = prometheus.NewDesc("ads_by_status", "Ads stored, by status.", []string{"status"}, nil)
+	usersDesc = prometheus.NewDesc("users_registered", "Users registered.", nil, nil)
+)
+
+// domainCollector reads the counts from the repositories on every scrape,
+// so they can never drift from the stored data.
+type domainCollector struct {
+	adRepo   app.AdRepository
+	userRepo app.UserRepository
+}
+
+func NewDomainCollector(adRepo app.AdRepository, userRepo app.UserRepository) prometheus.Collector {
+	return domainCollector{adRepo: adRepo, userRepo: userRepo}
+}
+
+func (c domainCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- adsDesc
+	ch <- usersDesc
+}
+
+func (c domainCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	all, err := c.adRepo.GetAllAds(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "metrics: can not count ads", "error", err)
+		ch <- prometheus.NewInvalidMetric(adsDesc, err)
+	} else {
+		counts := make(map[ads.Status]int, len(ads.Statuses()))
+		for _, ad := range all {
+			counts[ad.Status]++
+		}
+		for _, status := range ads.Statuses() {
+			ch <- prometheus.MustNewConstMetric(adsDesc, prometheus.GaugeValue, float64(counts[status]), string(status))
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(usersDesc, prometheus.GaugeValue, float64(len(c.userRepo.GetUsers(ctx))))
+}
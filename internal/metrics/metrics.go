@@ -0,0 +1,102 @@
+// Package metrics collects the Prometheus metrics of the service and
+// serves them in the text exposition format.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics owns a registry, so tests and several servers in one process do
+// not clash on the global one.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	httpInFlight *prometheus.GaugeVec
+
+	grpcRequests *prometheus.CounterVec
+	grpcDuration *prometheus.HistogramVec
+	grpcInFlight *prometheus.GaugeVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests handled, by route and status code.",
+		}, []string{"method", "route", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time to handle an HTTP request.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		httpInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "HTTP requests being handled.",
+		}, []string{"method", "route"}),
+		grpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_handled_total",
+			Help: "gRPC calls handled, by method and status code.",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_handling_seconds",
+			Help:    "Time to handle a gRPC call.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method"}),
+		grpcInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "grpc_server_in_flight",
+			Help: "gRPC calls being handled.",
+		}, []string{"method"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpDuration, m.httpInFlight,
+		m.grpcRequests, m.grpcDuration, m.grpcInFlight,
+	)
+	return m
+}
+
+// MustRegister adds collectors of other packages to the registry.
+func (m *Metrics) MustRegister(cs ...prometheus.Collector) {
+	m.registry.MustRegister(cs...)
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// TrackHTTP counts a request as in flight until done is called with the
+// status of the response.
+func (m *Metrics) TrackHTTP(method string, route string) (done func(status int)) {
+	start := time.Now()
+	inFlight := m.httpInFlight.WithLabelValues(method, route)
+	inFlight.Inc()
+	return func(status int) {
+		inFlight.Dec()
+		m.httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+		m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	}
+}
+
+// TrackGRPC is TrackHTTP for a gRPC method, code is the name of the
+// status code.
+func (m *Metrics) TrackGRPC(method string) (done func(code string)) {
+	start := time.Now()
+	inFlight := m.grpcInFlight.WithLabelValues(method)
+	inFlight.Inc()
+	return func(code string) {
+		inFlight.Dec()
+		m.grpcDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		m.grpcRequests.WithLabelValues(method, code).Inc()
+	}
+}
@@ -0,0 +1,71 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"homework9/internal/ads"
+)
+
+// adView is the expand and fields of GetAdRequest and ListAdsRequest.
+type adView struct {
+	expandAuthor bool
+	// fields are the top-level fields to keep, nil keeps all of them
+	fields map[protoreflect.Name]bool
+}
+
+func newAdView(expand []string, mask *fieldmaskpb.FieldMask) (adView, error) {
+	var v adView
+	for _, name := range expand {
+		if name != "author" {
+			return adView{}, status.Error(codes.InvalidArgument, fmt.Sprintf("expand: unknown relation %q", name))
+		}
+		v.expandAuthor = true
+	}
+	if len(mask.GetPaths()) == 0 {
+		return v, nil
+	}
+	if !mask.IsValid(&AdResponse{}) {
+		return adView{}, status.Error(codes.InvalidArgument, fmt.Sprintf("fields: unknown field in %v", mask.GetPaths()))
+	}
+	v.fields = map[protoreflect.Name]bool{"id": true}
+	for _, path := range mask.GetPaths() {
+		top, _, _ := strings.Cut(path, ".")
+		v.fields[protoreflect.Name(top)] = true
+	}
+	return v, nil
+}
+
+func (s Server) present(ctx context.Context, v adView, list []ads.Ad) []*AdResponse {
+	authors := make(map[int64]*AdAuthor)
+	resp := make([]*AdResponse, len(list))
+	for i, ad := range list {
+		resp[i] = newAdResponse(ad)
+		if v.expandAuthor {
+			author, ok := authors[ad.AuthorID]
+			if !ok {
+				// the author may be deleted, the ad is sent without it then
+				if user, err := s.a.GetUser(ctx, ad.AuthorID); err == nil {
+					author = &AdAuthor{Id: user.ID, Nickname: user.Nickname}
+				}
+				authors[ad.AuthorID] = author
+			}
+			resp[i].Author = author
+		}
+		if v.fields != nil {
+			m := resp[i].ProtoReflect()
+			m.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+				if !v.fields[fd.Name()] {
+					m.Clear(fd)
+				}
+				return true
+			})
+		}
+	}
+	return resp
+}
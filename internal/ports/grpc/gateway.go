@@ -0,0 +1,37 @@
+package grpc
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewGateway serves the unary RPCs of s as JSON over HTTP on the routes of
+// the google.api.http options in service.proto. The calls skip the gRPC
+// interceptors, the HTTP server wraps the gateway in its own middleware.
+func NewGateway(ctx context.Context, s AdServiceServer) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		// snake_case field names, as in the /api/v1 routes
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeader),
+	)
+	if err := RegisterAdServiceHandlerServer(ctx, mux, s); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
+
+// outgoingHeader passes retry-after as the HTTP header, the rest of the
+// response metadata gets the gateway prefix.
+func outgoingHeader(key string) (string, bool) {
+	if strings.EqualFold(key, "retry-after") {
+		return "Retry-After", true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
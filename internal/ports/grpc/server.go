@@ -1,12 +1,26 @@
 package grpc
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpclib "google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework9/internal/ads"
 	"homework9/internal/app"
+	"homework9/internal/identity"
+	"homework9/internal/messages"
+	"homework9/internal/ratelimit"
+	"homework9/internal/reports"
+	"homework9/internal/transfer"
+	"homework9/internal/users"
+	"strconv"
 )
 
 type Server struct {
@@ -15,68 +29,116 @@ type Server struct {
 }
 
 func (s Server) CreateAd(ctx context.Context, request *CreateAdRequest) (*AdResponse, error) {
+	if err := bindActor(ctx, &request.UserId); err != nil {
+		return nil, err
+	}
 	_, err := s.a.GetUser(ctx, request.GetUserId())
 	if err != nil {
 		return nil, status.Error(codes.Unauthenticated, err.Error())
 	}
 	ad, err := s.a.CreateAd(ctx, request.Title, request.Text, request.UserId)
 	if err != nil {
+		var rejected *app.ContentRejectedError
+		if errors.As(err, &rejected) {
+			return nil, rejectedStatus(rejected)
+		}
 		if errors.Is(err, app.ErrValidationFail) {
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
+		if errors.Is(err, app.ErrWrongUser) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		var quota *app.QuotaExceededError
+		if errors.As(err, &quota) {
+			return nil, quotaStatus(ctx, quota)
+		}
 		return nil, status.Error(codes.Internal, err.Error())
 	}
-	newAd := AdResponse{Id: ad.ID, Title: ad.Title, Text: ad.Text, AuthorId: ad.AuthorID, Published: false}
-	return &newAd, nil
+	return newAdResponse(ad), nil
 }
 
 func (s Server) ChangeAdStatus(ctx context.Context, request *ChangeAdStatusRequest) (*AdResponse, error) {
+	if err := bindActor(ctx, &request.UserId); err != nil {
+		return nil, err
+	}
 	_, err := s.a.GetUser(ctx, request.UserId)
 	if err != nil {
 		return nil, status.Error(codes.Unauthenticated, err.Error())
 	}
-	ad, err := s.a.ChangeAdStatus(ctx, request.AdId, request.UserId, request.Published)
+	var ad ads.Ad
+	if request.Published && request.PublishAt != nil {
+		ad, err = s.a.ScheduleAd(ctx, request.AdId, request.UserId, request.PublishAt.AsTime())
+	} else {
+		ad, err = s.a.ChangeAdStatus(ctx, request.AdId, request.UserId, request.Published)
+	}
 	if err != nil {
 		if errors.Is(err, app.ErrValidationFail) {
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
+		if errors.Is(err, app.ErrInvalidTransition) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
 		if errors.Is(err, app.ErrWrongUser) {
 			return nil, status.Error(codes.PermissionDenied, err.Error())
 		}
+		return nil, status.Error(codes.Internal, err.Error())
 	}
-	newAd := AdResponse{Id: ad.ID, Title: ad.Title, Text: ad.Text, AuthorId: ad.AuthorID, Published: ad.Published}
-	return &newAd, nil
+	return newAdResponse(ad), nil
 }
 
 func (s Server) UpdateAd(ctx context.Context, request *UpdateAdRequest) (*AdResponse, error) {
+	if err := bindActor(ctx, &request.UserId); err != nil {
+		return nil, err
+	}
 	_, err := s.a.GetUser(ctx, request.UserId)
 	if err != nil {
 		return nil, status.Error(codes.Unauthenticated, err.Error())
 	}
 	ad, err := s.a.UpdateAd(ctx, request.AdId, request.UserId, request.Title, request.Text)
 	if err != nil {
+		var rejected *app.ContentRejectedError
+		if errors.As(err, &rejected) {
+			return nil, rejectedStatus(rejected)
+		}
 		if errors.Is(err, app.ErrValidationFail) {
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
 		if errors.Is(err, app.ErrWrongUser) {
 			return nil, status.Error(codes.PermissionDenied, err.Error())
 		}
+		return nil, status.Error(codes.Internal, err.Error())
 	}
-	newAd := AdResponse{Id: ad.ID, Title: ad.Title, Text: ad.Text, AuthorId: ad.AuthorID, Published: ad.Published}
-	return &newAd, nil
+	return newAdResponse(ad), nil
 }
 
-func (s Server) ListAds(ctx context.Context, empty *emptypb.Empty) (*ListAdResponse, error) {
-	ads, err := s.a.GetAds(ctx)
+func (s Server) GetAd(ctx context.Context, request *GetAdRequest) (*AdResponse, error) {
+	view, err := newAdView(request.Expand, request.Fields)
 	if err != nil {
-		return nil, status.Error(codes.Unknown, err.Error())
+		return nil, err
+	}
+	ad, err := s.a.GetAd(ctx, request.AdId, viewer(ctx))
+	if err != nil {
+		if errors.Is(err, app.ErrValidationFail) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, app.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
 	}
-	adsList := make([]*AdResponse, 0)
-	for _, Ad := range ads {
-		ad := &AdResponse{Id: Ad.ID, Title: Ad.Title, Text: Ad.Text, AuthorId: Ad.AuthorID, Published: Ad.Published}
-		adsList = append(adsList, ad)
+	return s.present(ctx, view, []ads.Ad{ad})[0], nil
+}
+
+func (s Server) ListAds(ctx context.Context, request *ListAdsRequest) (*ListAdResponse, error) {
+	view, err := newAdView(request.Expand, request.Fields)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.a.GetAds(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unknown, err.Error())
 	}
-	return &ListAdResponse{List: adsList}, nil
+	return &ListAdResponse{List: s.present(ctx, view, list)}, nil
 }
 
 func (s Server) CreateUser(ctx context.Context, request *CreateUserRequest) (*UserResponse, error) {
@@ -84,8 +146,7 @@ func (s Server) CreateUser(ctx context.Context, request *CreateUserRequest) (*Us
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
-	userReq := &UserResponse{Id: user.ID, Nickname: user.Nickname, Email: user.Email}
-	return userReq, nil
+	return newUserResponse(user), nil
 }
 
 func (s Server) GetUser(ctx context.Context, request *GetUserRequest) (*UserResponse, error) {
@@ -93,22 +154,30 @@ func (s Server) GetUser(ctx context.Context, request *GetUserRequest) (*UserResp
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
-	User := UserResponse{Id: user.ID, Nickname: user.Nickname, Email: user.Email}
-	return &User, nil
+	return newUserResponse(user), nil
 }
 
 func (s Server) DeleteUser(ctx context.Context, request *DeleteUserRequest) (*emptypb.Empty, error) {
-	err := s.a.DeleteUser(ctx, request.Id)
+	if err := bindActor(ctx, &request.ActorId); err != nil {
+		return nil, err
+	}
+	err := s.a.DeleteUser(ctx, request.ActorId, request.Id)
 	if err != nil {
+		if errors.Is(err, app.ErrWrongUser) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 	return &emptypb.Empty{}, nil
 }
 
 func (s Server) DeleteAd(ctx context.Context, request *DeleteAdRequest) (*emptypb.Empty, error) {
-	_, err := s.a.GetUser(ctx, request.AdId)
+	if err := bindActor(ctx, &request.AuthorId); err != nil {
+		return nil, err
+	}
+	_, err := s.a.GetUser(ctx, request.AuthorId)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
 	}
 	err = s.a.DeleteAd(ctx, request.AdId, request.AuthorId)
 	if err != nil {
@@ -120,6 +189,557 @@ func (s Server) DeleteAd(ctx context.Context, request *DeleteAdRequest) (*emptyp
 	return &emptypb.Empty{}, nil
 }
 
+func (s Server) SendMessageToAd(ctx context.Context, request *SendMessageToAdRequest) (*MessageResponse, error) {
+	if err := bindActor(ctx, &request.UserId); err != nil {
+		return nil, err
+	}
+	msg, err := s.a.SendMessageToAd(ctx, request.AdId, request.UserId, request.Text)
+	if err != nil {
+		if errors.Is(err, app.ErrValidationFail) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, app.ErrWrongUser) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(err, app.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return newMessageResponse(msg), nil
+}
+
+func (s Server) SendMessage(ctx context.Context, request *SendMessageRequest) (*MessageResponse, error) {
+	if err := bindActor(ctx, &request.UserId); err != nil {
+		return nil, err
+	}
+	msg, err := s.a.SendMessage(ctx, request.ConversationId, request.UserId, request.Text)
+	if err != nil {
+		if errors.Is(err, app.ErrValidationFail) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, app.ErrWrongUser) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(err, app.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return newMessageResponse(msg), nil
+}
+
+func (s Server) ListMessages(ctx context.Context, request *ListMessagesRequest) (*ListMessagesResponse, error) {
+	if err := bindActor(ctx, &request.UserId); err != nil {
+		return nil, err
+	}
+	msgs, err := s.a.GetMessages(ctx, request.ConversationId, request.UserId)
+	if err != nil {
+		if errors.Is(err, app.ErrWrongUser) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(err, app.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	list := make([]*MessageResponse, 0, len(msgs))
+	for _, msg := range msgs {
+		list = append(list, newMessageResponse(msg))
+	}
+	return &ListMessagesResponse{List: list}, nil
+}
+
+// bindActor makes the actor field of a request name the user of the
+// x-user-id metadata, so that the gateway and gRPC clients act for the user
+// the middleware authenticated. Without the metadata the field is taken as
+// sent, a field naming another user is refused.
+func bindActor(ctx context.Context, field *int64) error {
+	id, ok := identity.User(ctx)
+	if !ok {
+		return nil
+	}
+	if *field != 0 && *field != id {
+		return status.Errorf(codes.PermissionDenied, "actor %d does not match the x-user-id %d", *field, id)
+	}
+	*field = id
+	return nil
+}
+
+// viewer is the user of the x-user-id metadata, reads without it are
+// anonymous.
+func viewer(ctx context.Context) int64 {
+	if id, ok := identity.User(ctx); ok {
+		return id
+	}
+	return app.Anonymous
+}
+
+func (s Server) ListInbox(ctx context.Context, request *ListInboxRequest) (*ListConversationsResponse, error) {
+	// ListInboxRequest has no actor field, x-user-id names the reader when set
+	actorID, ok := identity.User(ctx)
+	if !ok {
+		actorID = request.UserId
+	}
+	convs, err := s.a.GetInbox(ctx, actorID, request.UserId)
+	if err != nil {
+		if errors.Is(err, app.ErrWrongUser) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(err, app.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return newListConversationsResponse(convs), nil
+}
+
+func (s Server) ListAdConversations(ctx context.Context, request *ListAdConversationsRequest) (*ListConversationsResponse, error) {
+	if err := bindActor(ctx, &request.UserId); err != nil {
+		return nil, err
+	}
+	convs, err := s.a.GetAdConversations(ctx, request.AdId, request.UserId)
+	if err != nil {
+		if errors.Is(err, app.ErrWrongUser) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(err, app.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return newListConversationsResponse(convs), nil
+}
+
+func (s Server) SetUserRole(ctx context.Context, request *SetUserRoleRequest) (*UserResponse, error) {
+	if err := bindActor(ctx, &request.AdminId); err != nil {
+		return nil, err
+	}
+	user, err := s.a.SetUserRole(ctx, request.AdminId, request.UserId, users.Role(request.Role))
+	if err != nil {
+		if errors.Is(err, app.ErrValidationFail) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, app.ErrWrongUser) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return newUserResponse(user), nil
+}
+
+func (s Server) BanUser(ctx context.Context, request *BanUserRequest) (*UserResponse, error) {
+	if err := bindActor(ctx, &request.AdminId); err != nil {
+		return nil, err
+	}
+	user, err := s.a.BanUser(ctx, request.AdminId, request.UserId, request.Banned)
+	if err != nil {
+		if errors.Is(err, app.ErrWrongUser) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return newUserResponse(user), nil
+}
+
+func (s Server) ListModerationQueue(ctx context.Context, request *ModerationQueueRequest) (*ListAdResponse, error) {
+	if err := bindActor(ctx, &request.ModeratorId); err != nil {
+		return nil, err
+	}
+	ads, err := s.a.GetModerationQueue(ctx, request.ModeratorId)
+	if err != nil {
+		if errors.Is(err, app.ErrWrongUser) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return newListAdResponse(ads), nil
+}
+
+func (s Server) ApproveAd(ctx context.Context, request *ApproveAdRequest) (*AdResponse, error) {
+	if err := bindActor(ctx, &request.ModeratorId); err != nil {
+		return nil, err
+	}
+	ad, err := s.a.ApproveAd(ctx, request.AdId, request.ModeratorId)
+	if err != nil {
+		if errors.Is(err, app.ErrWrongUser) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(err, app.ErrInvalidTransition) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return newAdResponse(ad), nil
+}
+
+func (s Server) RejectAd(ctx context.Context, request *RejectAdRequest) (*AdResponse, error) {
+	if err := bindActor(ctx, &request.ModeratorId); err != nil {
+		return nil, err
+	}
+	ad, err := s.a.RejectAd(ctx, request.AdId, request.ModeratorId, request.Reason)
+	if err != nil {
+		if errors.Is(err, app.ErrValidationFail) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, app.ErrWrongUser) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(err, app.ErrInvalidTransition) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return newAdResponse(ad), nil
+}
+
+func (s Server) ReportAd(ctx context.Context, request *ReportAdRequest) (*ReportResponse, error) {
+	if err := bindActor(ctx, &request.UserId); err != nil {
+		return nil, err
+	}
+	report, err := s.a.ReportAd(ctx, request.AdId, request.UserId, reports.Reason(request.Reason), request.Comment)
+	if err != nil {
+		if errors.Is(err, app.ErrValidationFail) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, app.ErrWrongUser) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &ReportResponse{
+		Id:         report.ID,
+		AdId:       report.AdID,
+		ReporterId: report.ReporterID,
+		Reason:     string(report.Reason),
+		Comment:    report.Comment,
+		DateCreate: timestamppb.New(report.DateCreate),
+	}, nil
+}
+
+func (s Server) ListReportedAds(ctx context.Context, request *ModerationQueueRequest) (*ListReportSummariesResponse, error) {
+	if err := bindActor(ctx, &request.ModeratorId); err != nil {
+		return nil, err
+	}
+	summaries, err := s.a.GetReportedAds(ctx, request.ModeratorId)
+	if err != nil {
+		if errors.Is(err, app.ErrWrongUser) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	list := make([]*ReportSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		reasons := make(map[string]int32, len(summary.Reasons))
+		for reason, n := range summary.Reasons {
+			reasons[string(reason)] = int32(n)
+		}
+		list = append(list, &ReportSummary{
+			AdId:       summary.AdID,
+			Reports:    int32(summary.Reports),
+			Reporters:  int32(summary.Reporters),
+			Reasons:    reasons,
+			LastReport: timestamppb.New(summary.LastReport),
+		})
+	}
+	return &ListReportSummariesResponse{List: list}, nil
+}
+
+func (s Server) ResolveReports(ctx context.Context, request *ResolveReportsRequest) (*AdResponse, error) {
+	if err := bindActor(ctx, &request.ModeratorId); err != nil {
+		return nil, err
+	}
+	ad, err := s.a.ResolveReports(ctx, request.AdId, request.ModeratorId, reports.Resolution(request.Resolution), request.Comment)
+	if err != nil {
+		if errors.Is(err, app.ErrValidationFail) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, app.ErrWrongUser) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(err, app.ErrInvalidTransition) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return newAdResponse(ad), nil
+}
+
+func (s Server) RenewAd(ctx context.Context, request *RenewAdRequest) (*AdResponse, error) {
+	if err := bindActor(ctx, &request.UserId); err != nil {
+		return nil, err
+	}
+	ad, err := s.a.RenewAd(ctx, request.AdId, request.UserId)
+	if err != nil {
+		if errors.Is(err, app.ErrWrongUser) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(err, app.ErrInvalidTransition) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return newAdResponse(ad), nil
+}
+
+func (s Server) CancelScheduledAd(ctx context.Context, request *CancelScheduledAdRequest) (*AdResponse, error) {
+	if err := bindActor(ctx, &request.UserId); err != nil {
+		return nil, err
+	}
+	ad, err := s.a.CancelScheduledAd(ctx, request.AdId, request.UserId)
+	if err != nil {
+		if errors.Is(err, app.ErrWrongUser) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(err, app.ErrInvalidTransition) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return newAdResponse(ad), nil
+}
+
+func (s Server) CreateAds(ctx context.Context, request *CreateAdsRequest) (*BatchResponse, error) {
+	if err := bindActor(ctx, &request.UserId); err != nil {
+		return nil, err
+	}
+	items := make([]app.NewAd, len(request.Ads))
+	for i, item := range request.Ads {
+		items[i] = app.NewAd{Title: item.Title, Text: item.Text}
+	}
+	results, err := s.a.CreateAds(ctx, request.UserId, items, request.Atomic)
+	var quota *app.QuotaExceededError
+	if errors.As(err, &quota) {
+		return nil, quotaStatus(ctx, quota)
+	}
+	return newBatchResponse(results, true, err)
+}
+
+func (s Server) ChangeAdsStatus(ctx context.Context, request *ChangeAdsStatusRequest) (*BatchResponse, error) {
+	if err := bindActor(ctx, &request.UserId); err != nil {
+		return nil, err
+	}
+	results, err := s.a.ChangeAdsStatus(ctx, request.AdIds, request.UserId, request.Published, request.Atomic)
+	return newBatchResponse(results, true, err)
+}
+
+func (s Server) DeleteAds(ctx context.Context, request *DeleteAdsRequest) (*BatchResponse, error) {
+	if err := bindActor(ctx, &request.UserId); err != nil {
+		return nil, err
+	}
+	results, err := s.a.DeleteAds(ctx, request.AdIds, request.UserId, request.Atomic)
+	return newBatchResponse(results, false, err)
+}
+
+func (s Server) ExportData(request *ExportRequest, stream AdService_ExportDataServer) error {
+	format, err := transfer.ParseFormat(request.Format)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	ctx := stream.Context()
+	if err := bindActor(ctx, &request.AdminId); err != nil {
+		return err
+	}
+	userList, err := s.a.ExportUsers(ctx, request.AdminId)
+	if err != nil {
+		return status.Error(errorCode(err), err.Error())
+	}
+	adList, err := s.a.ExportAds(ctx, request.AdminId)
+	if err != nil {
+		return status.Error(errorCode(err), err.Error())
+	}
+	enc := transfer.NewEncoder(chunkWriter{stream}, format)
+	for _, user := range userList {
+		if err := enc.Encode(transfer.Record{Kind: transfer.KindUser, User: user}); err != nil {
+			return err
+		}
+	}
+	for _, ad := range adList {
+		if err := enc.Encode(transfer.Record{Kind: transfer.KindAd, Ad: ad}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunkWriter sends every write as its own chunk, the encoders write one
+// record at a time.
+type chunkWriter struct {
+	stream AdService_ExportDataServer
+}
+
+func (w chunkWriter) Write(p []byte) (int, error) {
+	data := make([]byte, len(p))
+	copy(data, p)
+	if err := w.stream.Send(&ExportChunk{Data: data}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s Server) ImportData(ctx context.Context, request *ImportRequest) (*ImportResponse, error) {
+	if err := bindActor(ctx, &request.AdminId); err != nil {
+		return nil, err
+	}
+	format, err := transfer.ParseFormat(request.Format)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	batch, err := transfer.ReadAll(transfer.NewDecoder(bytes.NewReader(request.Data), format))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	res, err := s.a.Import(ctx, request.AdminId, batch.Users, batch.Ads, request.PreserveIds)
+	if err != nil {
+		return nil, status.Error(errorCode(err), err.Error())
+	}
+	resp := &ImportResponse{}
+	for _, err := range res.Users {
+		if err == nil {
+			resp.Users++
+		}
+	}
+	for _, err := range res.Ads {
+		if err == nil {
+			resp.Ads++
+		}
+	}
+	for _, e := range batch.LineErrors(res.Users, res.Ads) {
+		resp.Errors = append(resp.Errors, &ImportLineError{Line: int32(e.Line), Error: e.Err.Error()})
+	}
+	return resp, nil
+}
+
+// errorCode maps an app error to the code of the single-item RPCs.
+func errorCode(err error) codes.Code {
+	switch {
+	case err == nil:
+		return codes.OK
+	case errors.Is(err, app.ErrWrongUser):
+		return codes.PermissionDenied
+	case errors.Is(err, app.ErrValidationFail):
+		return codes.InvalidArgument
+	case errors.Is(err, app.ErrInvalidTransition):
+		return codes.FailedPrecondition
+	case errors.Is(err, app.ErrBatchAborted):
+		return codes.Aborted
+	case errors.Is(err, app.ErrQuotaExceeded):
+		return codes.ResourceExhausted
+	}
+	return codes.Internal
+}
+
+// newBatchResponse keeps the per-item results of an aborted batch in the
+// response instead of turning the abort into a status error.
+func newBatchResponse(results []app.BatchResult, withAds bool, err error) (*BatchResponse, error) {
+	if err != nil && !errors.Is(err, app.ErrBatchAborted) {
+		return nil, status.Error(errorCode(err), err.Error())
+	}
+	resp := &BatchResponse{Results: make([]*BatchItemResult, len(results)), Aborted: err != nil}
+	for i, res := range results {
+		item := &BatchItemResult{Index: int32(i), Code: errorCode(res.Err).String()}
+		if res.Err != nil {
+			item.Error = res.Err.Error()
+		} else if withAds {
+			item.Ad = newAdResponse(res.Ad)
+		}
+		resp.Results[i] = item
+	}
+	return resp, nil
+}
+
+// rejectedStatus carries the content filter reasons as BadRequest details.
+func rejectedStatus(err *app.ContentRejectedError) error {
+	st := status.New(codes.InvalidArgument, err.Error())
+	violations := make([]*errdetails.BadRequest_FieldViolation, len(err.Reasons))
+	for i, r := range err.Reasons {
+		violations[i] = &errdetails.BadRequest_FieldViolation{Field: r.Field, Description: r.Code + ": " + r.Message}
+	}
+	detailed, detailsErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// quotaStatus tells the client when the quota is renewed, in RetryInfo and
+// in the retry-after header like the rate limiter does.
+func quotaStatus(ctx context.Context, err *app.QuotaExceededError) error {
+	_ = grpclib.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(ratelimit.RetryAfterSeconds(err.RetryAfter))))
+	st := status.New(codes.ResourceExhausted, err.Error())
+	detailed, detailsErr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(err.RetryAfter)})
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func newAdResponse(ad ads.Ad) *AdResponse {
+	resp := &AdResponse{
+		Id:           ad.ID,
+		Title:        ad.Title,
+		Text:         ad.Text,
+		AuthorId:     ad.AuthorID,
+		Published:    ad.Published(),
+		Status:       string(ad.Status),
+		RejectReason: ad.RejectReason,
+	}
+	if !ad.ExpiresAt.IsZero() {
+		resp.ExpiresAt = timestamppb.New(ad.ExpiresAt)
+	}
+	if ad.Scheduled() {
+		resp.PublishAt = timestamppb.New(ad.PublishAt)
+	}
+	if !ad.DateCreate.IsZero() {
+		resp.DateCreate = timestamppb.New(ad.DateCreate)
+		resp.DateUpdate = timestamppb.New(ad.DateUpdate)
+	}
+	return resp
+}
+
+func newListAdResponse(list []ads.Ad) *ListAdResponse {
+	adsList := make([]*AdResponse, 0, len(list))
+	for _, ad := range list {
+		adsList = append(adsList, newAdResponse(ad))
+	}
+	return &ListAdResponse{List: adsList}
+}
+
+func newUserResponse(user users.User) *UserResponse {
+	return &UserResponse{
+		Id:       user.ID,
+		Nickname: user.Nickname,
+		Email:    user.Email,
+		Role:     string(user.Role),
+		Banned:   user.Banned,
+	}
+}
+
+func newMessageResponse(msg messages.Message) *MessageResponse {
+	return &MessageResponse{
+		Id:             msg.ID,
+		ConversationId: msg.ConversationID,
+		SenderId:       msg.SenderID,
+		Text:           msg.Text,
+		Read:           msg.Read,
+		DateCreate:     timestamppb.New(msg.DateCreate),
+	}
+}
+
+func newListConversationsResponse(convs []messages.Conversation) *ListConversationsResponse {
+	list := make([]*ConversationResponse, 0, len(convs))
+	for _, conv := range convs {
+		list = append(list, &ConversationResponse{
+			Id:         conv.ID,
+			AdId:       conv.AdID,
+			BuyerId:    conv.BuyerID,
+			SellerId:   conv.SellerID,
+			DateUpdate: timestamppb.New(conv.DateUpdate),
+		})
+	}
+	return &ListConversationsResponse{List: list}
+}
+
 func NewService(a app.App) AdServiceServer {
 	return &Server{a: a}
 }
@@ -1,4 +1,8 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v3.15.8
+// source: service.proto
 
 package grpc
 
@@ -15,18 +19,71 @@ import (
 // Requires gRPC-Go v1.32.0 or later.
 const _ = grpc.SupportPackageIsVersion7
 
+const (
+	AdService_CreateAd_FullMethodName            = "/ad.AdService/CreateAd"
+	AdService_GetAd_FullMethodName               = "/ad.AdService/GetAd"
+	AdService_ChangeAdStatus_FullMethodName      = "/ad.AdService/ChangeAdStatus"
+	AdService_UpdateAd_FullMethodName            = "/ad.AdService/UpdateAd"
+	AdService_ListAds_FullMethodName             = "/ad.AdService/ListAds"
+	AdService_CreateUser_FullMethodName          = "/ad.AdService/CreateUser"
+	AdService_GetUser_FullMethodName             = "/ad.AdService/GetUser"
+	AdService_DeleteUser_FullMethodName          = "/ad.AdService/DeleteUser"
+	AdService_DeleteAd_FullMethodName            = "/ad.AdService/DeleteAd"
+	AdService_SendMessageToAd_FullMethodName     = "/ad.AdService/SendMessageToAd"
+	AdService_SendMessage_FullMethodName         = "/ad.AdService/SendMessage"
+	AdService_ListMessages_FullMethodName        = "/ad.AdService/ListMessages"
+	AdService_ListInbox_FullMethodName           = "/ad.AdService/ListInbox"
+	AdService_ListAdConversations_FullMethodName = "/ad.AdService/ListAdConversations"
+	AdService_SetUserRole_FullMethodName         = "/ad.AdService/SetUserRole"
+	AdService_BanUser_FullMethodName             = "/ad.AdService/BanUser"
+	AdService_ListModerationQueue_FullMethodName = "/ad.AdService/ListModerationQueue"
+	AdService_ApproveAd_FullMethodName           = "/ad.AdService/ApproveAd"
+	AdService_RejectAd_FullMethodName            = "/ad.AdService/RejectAd"
+	AdService_ReportAd_FullMethodName            = "/ad.AdService/ReportAd"
+	AdService_ListReportedAds_FullMethodName     = "/ad.AdService/ListReportedAds"
+	AdService_ResolveReports_FullMethodName      = "/ad.AdService/ResolveReports"
+	AdService_RenewAd_FullMethodName             = "/ad.AdService/RenewAd"
+	AdService_CancelScheduledAd_FullMethodName   = "/ad.AdService/CancelScheduledAd"
+	AdService_CreateAds_FullMethodName           = "/ad.AdService/CreateAds"
+	AdService_ChangeAdsStatus_FullMethodName     = "/ad.AdService/ChangeAdsStatus"
+	AdService_DeleteAds_FullMethodName           = "/ad.AdService/DeleteAds"
+	AdService_ExportData_FullMethodName          = "/ad.AdService/ExportData"
+	AdService_ImportData_FullMethodName          = "/ad.AdService/ImportData"
+)
+
 // AdServiceClient is the client API for AdService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type AdServiceClient interface {
 	CreateAd(ctx context.Context, in *CreateAdRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	GetAd(ctx context.Context, in *GetAdRequest, opts ...grpc.CallOption) (*AdResponse, error)
 	ChangeAdStatus(ctx context.Context, in *ChangeAdStatusRequest, opts ...grpc.CallOption) (*AdResponse, error)
 	UpdateAd(ctx context.Context, in *UpdateAdRequest, opts ...grpc.CallOption) (*AdResponse, error)
-	ListAds(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListAdResponse, error)
+	ListAds(ctx context.Context, in *ListAdsRequest, opts ...grpc.CallOption) (*ListAdResponse, error)
 	CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*UserResponse, error)
 	GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*UserResponse, error)
 	DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	DeleteAd(ctx context.Context, in *DeleteAdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	SendMessageToAd(ctx context.Context, in *SendMessageToAdRequest, opts ...grpc.CallOption) (*MessageResponse, error)
+	SendMessage(ctx context.Context, in *SendMessageRequest, opts ...grpc.CallOption) (*MessageResponse, error)
+	ListMessages(ctx context.Context, in *ListMessagesRequest, opts ...grpc.CallOption) (*ListMessagesResponse, error)
+	ListInbox(ctx context.Context, in *ListInboxRequest, opts ...grpc.CallOption) (*ListConversationsResponse, error)
+	ListAdConversations(ctx context.Context, in *ListAdConversationsRequest, opts ...grpc.CallOption) (*ListConversationsResponse, error)
+	SetUserRole(ctx context.Context, in *SetUserRoleRequest, opts ...grpc.CallOption) (*UserResponse, error)
+	BanUser(ctx context.Context, in *BanUserRequest, opts ...grpc.CallOption) (*UserResponse, error)
+	ListModerationQueue(ctx context.Context, in *ModerationQueueRequest, opts ...grpc.CallOption) (*ListAdResponse, error)
+	ApproveAd(ctx context.Context, in *ApproveAdRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	RejectAd(ctx context.Context, in *RejectAdRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	ReportAd(ctx context.Context, in *ReportAdRequest, opts ...grpc.CallOption) (*ReportResponse, error)
+	ListReportedAds(ctx context.Context, in *ModerationQueueRequest, opts ...grpc.CallOption) (*ListReportSummariesResponse, error)
+	ResolveReports(ctx context.Context, in *ResolveReportsRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	RenewAd(ctx context.Context, in *RenewAdRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	CancelScheduledAd(ctx context.Context, in *CancelScheduledAdRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	CreateAds(ctx context.Context, in *CreateAdsRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	ChangeAdsStatus(ctx context.Context, in *ChangeAdsStatusRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	DeleteAds(ctx context.Context, in *DeleteAdsRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	ExportData(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (AdService_ExportDataClient, error)
+	ImportData(ctx context.Context, in *ImportRequest, opts ...grpc.CallOption) (*ImportResponse, error)
 }
 
 type adServiceClient struct {
@@ -39,7 +96,16 @@ func NewAdServiceClient(cc grpc.ClientConnInterface) AdServiceClient {
 
 func (c *adServiceClient) CreateAd(ctx context.Context, in *CreateAdRequest, opts ...grpc.CallOption) (*AdResponse, error) {
 	out := new(AdResponse)
-	err := c.cc.Invoke(ctx, "/ad.AdService/CreateAd", in, out, opts...)
+	err := c.cc.Invoke(ctx, AdService_CreateAd_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) GetAd(ctx context.Context, in *GetAdRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_GetAd_FullMethodName, in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -48,7 +114,7 @@ func (c *adServiceClient) CreateAd(ctx context.Context, in *CreateAdRequest, opt
 
 func (c *adServiceClient) ChangeAdStatus(ctx context.Context, in *ChangeAdStatusRequest, opts ...grpc.CallOption) (*AdResponse, error) {
 	out := new(AdResponse)
-	err := c.cc.Invoke(ctx, "/ad.AdService/ChangeAdStatus", in, out, opts...)
+	err := c.cc.Invoke(ctx, AdService_ChangeAdStatus_FullMethodName, in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -57,16 +123,16 @@ func (c *adServiceClient) ChangeAdStatus(ctx context.Context, in *ChangeAdStatus
 
 func (c *adServiceClient) UpdateAd(ctx context.Context, in *UpdateAdRequest, opts ...grpc.CallOption) (*AdResponse, error) {
 	out := new(AdResponse)
-	err := c.cc.Invoke(ctx, "/ad.AdService/UpdateAd", in, out, opts...)
+	err := c.cc.Invoke(ctx, AdService_UpdateAd_FullMethodName, in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *adServiceClient) ListAds(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListAdResponse, error) {
+func (c *adServiceClient) ListAds(ctx context.Context, in *ListAdsRequest, opts ...grpc.CallOption) (*ListAdResponse, error) {
 	out := new(ListAdResponse)
-	err := c.cc.Invoke(ctx, "/ad.AdService/ListAds", in, out, opts...)
+	err := c.cc.Invoke(ctx, AdService_ListAds_FullMethodName, in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -75,7 +141,7 @@ func (c *adServiceClient) ListAds(ctx context.Context, in *emptypb.Empty, opts .
 
 func (c *adServiceClient) CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*UserResponse, error) {
 	out := new(UserResponse)
-	err := c.cc.Invoke(ctx, "/ad.AdService/CreateUser", in, out, opts...)
+	err := c.cc.Invoke(ctx, AdService_CreateUser_FullMethodName, in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -84,7 +150,7 @@ func (c *adServiceClient) CreateUser(ctx context.Context, in *CreateUserRequest,
 
 func (c *adServiceClient) GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*UserResponse, error) {
 	out := new(UserResponse)
-	err := c.cc.Invoke(ctx, "/ad.AdService/GetUser", in, out, opts...)
+	err := c.cc.Invoke(ctx, AdService_GetUser_FullMethodName, in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -93,7 +159,7 @@ func (c *adServiceClient) GetUser(ctx context.Context, in *GetUserRequest, opts
 
 func (c *adServiceClient) DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	out := new(emptypb.Empty)
-	err := c.cc.Invoke(ctx, "/ad.AdService/DeleteUser", in, out, opts...)
+	err := c.cc.Invoke(ctx, AdService_DeleteUser_FullMethodName, in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -102,7 +168,210 @@ func (c *adServiceClient) DeleteUser(ctx context.Context, in *DeleteUserRequest,
 
 func (c *adServiceClient) DeleteAd(ctx context.Context, in *DeleteAdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	out := new(emptypb.Empty)
-	err := c.cc.Invoke(ctx, "/ad.AdService/DeleteAd", in, out, opts...)
+	err := c.cc.Invoke(ctx, AdService_DeleteAd_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) SendMessageToAd(ctx context.Context, in *SendMessageToAdRequest, opts ...grpc.CallOption) (*MessageResponse, error) {
+	out := new(MessageResponse)
+	err := c.cc.Invoke(ctx, AdService_SendMessageToAd_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) SendMessage(ctx context.Context, in *SendMessageRequest, opts ...grpc.CallOption) (*MessageResponse, error) {
+	out := new(MessageResponse)
+	err := c.cc.Invoke(ctx, AdService_SendMessage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListMessages(ctx context.Context, in *ListMessagesRequest, opts ...grpc.CallOption) (*ListMessagesResponse, error) {
+	out := new(ListMessagesResponse)
+	err := c.cc.Invoke(ctx, AdService_ListMessages_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListInbox(ctx context.Context, in *ListInboxRequest, opts ...grpc.CallOption) (*ListConversationsResponse, error) {
+	out := new(ListConversationsResponse)
+	err := c.cc.Invoke(ctx, AdService_ListInbox_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListAdConversations(ctx context.Context, in *ListAdConversationsRequest, opts ...grpc.CallOption) (*ListConversationsResponse, error) {
+	out := new(ListConversationsResponse)
+	err := c.cc.Invoke(ctx, AdService_ListAdConversations_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) SetUserRole(ctx context.Context, in *SetUserRoleRequest, opts ...grpc.CallOption) (*UserResponse, error) {
+	out := new(UserResponse)
+	err := c.cc.Invoke(ctx, AdService_SetUserRole_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) BanUser(ctx context.Context, in *BanUserRequest, opts ...grpc.CallOption) (*UserResponse, error) {
+	out := new(UserResponse)
+	err := c.cc.Invoke(ctx, AdService_BanUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListModerationQueue(ctx context.Context, in *ModerationQueueRequest, opts ...grpc.CallOption) (*ListAdResponse, error) {
+	out := new(ListAdResponse)
+	err := c.cc.Invoke(ctx, AdService_ListModerationQueue_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ApproveAd(ctx context.Context, in *ApproveAdRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_ApproveAd_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) RejectAd(ctx context.Context, in *RejectAdRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_RejectAd_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ReportAd(ctx context.Context, in *ReportAdRequest, opts ...grpc.CallOption) (*ReportResponse, error) {
+	out := new(ReportResponse)
+	err := c.cc.Invoke(ctx, AdService_ReportAd_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListReportedAds(ctx context.Context, in *ModerationQueueRequest, opts ...grpc.CallOption) (*ListReportSummariesResponse, error) {
+	out := new(ListReportSummariesResponse)
+	err := c.cc.Invoke(ctx, AdService_ListReportedAds_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ResolveReports(ctx context.Context, in *ResolveReportsRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_ResolveReports_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) RenewAd(ctx context.Context, in *RenewAdRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_RenewAd_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) CancelScheduledAd(ctx context.Context, in *CancelScheduledAdRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_CancelScheduledAd_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) CreateAds(ctx context.Context, in *CreateAdsRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, AdService_CreateAds_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ChangeAdsStatus(ctx context.Context, in *ChangeAdsStatusRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, AdService_ChangeAdsStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) DeleteAds(ctx context.Context, in *DeleteAdsRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, AdService_DeleteAds_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ExportData(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (AdService_ExportDataClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AdService_ServiceDesc.Streams[0], AdService_ExportData_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adServiceExportDataClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AdService_ExportDataClient interface {
+	Recv() (*ExportChunk, error)
+	grpc.ClientStream
+}
+
+type adServiceExportDataClient struct {
+	grpc.ClientStream
+}
+
+func (x *adServiceExportDataClient) Recv() (*ExportChunk, error) {
+	m := new(ExportChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *adServiceClient) ImportData(ctx context.Context, in *ImportRequest, opts ...grpc.CallOption) (*ImportResponse, error) {
+	out := new(ImportResponse)
+	err := c.cc.Invoke(ctx, AdService_ImportData_FullMethodName, in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -114,13 +383,34 @@ func (c *adServiceClient) DeleteAd(ctx context.Context, in *DeleteAdRequest, opt
 // for forward compatibility
 type AdServiceServer interface {
 	CreateAd(context.Context, *CreateAdRequest) (*AdResponse, error)
+	GetAd(context.Context, *GetAdRequest) (*AdResponse, error)
 	ChangeAdStatus(context.Context, *ChangeAdStatusRequest) (*AdResponse, error)
 	UpdateAd(context.Context, *UpdateAdRequest) (*AdResponse, error)
-	ListAds(context.Context, *emptypb.Empty) (*ListAdResponse, error)
+	ListAds(context.Context, *ListAdsRequest) (*ListAdResponse, error)
 	CreateUser(context.Context, *CreateUserRequest) (*UserResponse, error)
 	GetUser(context.Context, *GetUserRequest) (*UserResponse, error)
 	DeleteUser(context.Context, *DeleteUserRequest) (*emptypb.Empty, error)
 	DeleteAd(context.Context, *DeleteAdRequest) (*emptypb.Empty, error)
+	SendMessageToAd(context.Context, *SendMessageToAdRequest) (*MessageResponse, error)
+	SendMessage(context.Context, *SendMessageRequest) (*MessageResponse, error)
+	ListMessages(context.Context, *ListMessagesRequest) (*ListMessagesResponse, error)
+	ListInbox(context.Context, *ListInboxRequest) (*ListConversationsResponse, error)
+	ListAdConversations(context.Context, *ListAdConversationsRequest) (*ListConversationsResponse, error)
+	SetUserRole(context.Context, *SetUserRoleRequest) (*UserResponse, error)
+	BanUser(context.Context, *BanUserRequest) (*UserResponse, error)
+	ListModerationQueue(context.Context, *ModerationQueueRequest) (*ListAdResponse, error)
+	ApproveAd(context.Context, *ApproveAdRequest) (*AdResponse, error)
+	RejectAd(context.Context, *RejectAdRequest) (*AdResponse, error)
+	ReportAd(context.Context, *ReportAdRequest) (*ReportResponse, error)
+	ListReportedAds(context.Context, *ModerationQueueRequest) (*ListReportSummariesResponse, error)
+	ResolveReports(context.Context, *ResolveReportsRequest) (*AdResponse, error)
+	RenewAd(context.Context, *RenewAdRequest) (*AdResponse, error)
+	CancelScheduledAd(context.Context, *CancelScheduledAdRequest) (*AdResponse, error)
+	CreateAds(context.Context, *CreateAdsRequest) (*BatchResponse, error)
+	ChangeAdsStatus(context.Context, *ChangeAdsStatusRequest) (*BatchResponse, error)
+	DeleteAds(context.Context, *DeleteAdsRequest) (*BatchResponse, error)
+	ExportData(*ExportRequest, AdService_ExportDataServer) error
+	ImportData(context.Context, *ImportRequest) (*ImportResponse, error)
 	mustEmbedUnimplementedAdServiceServer()
 }
 
@@ -131,13 +421,16 @@ type UnimplementedAdServiceServer struct {
 func (UnimplementedAdServiceServer) CreateAd(context.Context, *CreateAdRequest) (*AdResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateAd not implemented")
 }
+func (UnimplementedAdServiceServer) GetAd(context.Context, *GetAdRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAd not implemented")
+}
 func (UnimplementedAdServiceServer) ChangeAdStatus(context.Context, *ChangeAdStatusRequest) (*AdResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ChangeAdStatus not implemented")
 }
 func (UnimplementedAdServiceServer) UpdateAd(context.Context, *UpdateAdRequest) (*AdResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateAd not implemented")
 }
-func (UnimplementedAdServiceServer) ListAds(context.Context, *emptypb.Empty) (*ListAdResponse, error) {
+func (UnimplementedAdServiceServer) ListAds(context.Context, *ListAdsRequest) (*ListAdResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListAds not implemented")
 }
 func (UnimplementedAdServiceServer) CreateUser(context.Context, *CreateUserRequest) (*UserResponse, error) {
@@ -152,6 +445,66 @@ func (UnimplementedAdServiceServer) DeleteUser(context.Context, *DeleteUserReque
 func (UnimplementedAdServiceServer) DeleteAd(context.Context, *DeleteAdRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteAd not implemented")
 }
+func (UnimplementedAdServiceServer) SendMessageToAd(context.Context, *SendMessageToAdRequest) (*MessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendMessageToAd not implemented")
+}
+func (UnimplementedAdServiceServer) SendMessage(context.Context, *SendMessageRequest) (*MessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendMessage not implemented")
+}
+func (UnimplementedAdServiceServer) ListMessages(context.Context, *ListMessagesRequest) (*ListMessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMessages not implemented")
+}
+func (UnimplementedAdServiceServer) ListInbox(context.Context, *ListInboxRequest) (*ListConversationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListInbox not implemented")
+}
+func (UnimplementedAdServiceServer) ListAdConversations(context.Context, *ListAdConversationsRequest) (*ListConversationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAdConversations not implemented")
+}
+func (UnimplementedAdServiceServer) SetUserRole(context.Context, *SetUserRoleRequest) (*UserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetUserRole not implemented")
+}
+func (UnimplementedAdServiceServer) BanUser(context.Context, *BanUserRequest) (*UserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BanUser not implemented")
+}
+func (UnimplementedAdServiceServer) ListModerationQueue(context.Context, *ModerationQueueRequest) (*ListAdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListModerationQueue not implemented")
+}
+func (UnimplementedAdServiceServer) ApproveAd(context.Context, *ApproveAdRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApproveAd not implemented")
+}
+func (UnimplementedAdServiceServer) RejectAd(context.Context, *RejectAdRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RejectAd not implemented")
+}
+func (UnimplementedAdServiceServer) ReportAd(context.Context, *ReportAdRequest) (*ReportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportAd not implemented")
+}
+func (UnimplementedAdServiceServer) ListReportedAds(context.Context, *ModerationQueueRequest) (*ListReportSummariesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReportedAds not implemented")
+}
+func (UnimplementedAdServiceServer) ResolveReports(context.Context, *ResolveReportsRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolveReports not implemented")
+}
+func (UnimplementedAdServiceServer) RenewAd(context.Context, *RenewAdRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenewAd not implemented")
+}
+func (UnimplementedAdServiceServer) CancelScheduledAd(context.Context, *CancelScheduledAdRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelScheduledAd not implemented")
+}
+func (UnimplementedAdServiceServer) CreateAds(context.Context, *CreateAdsRequest) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAds not implemented")
+}
+func (UnimplementedAdServiceServer) ChangeAdsStatus(context.Context, *ChangeAdsStatusRequest) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangeAdsStatus not implemented")
+}
+func (UnimplementedAdServiceServer) DeleteAds(context.Context, *DeleteAdsRequest) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAds not implemented")
+}
+func (UnimplementedAdServiceServer) ExportData(*ExportRequest, AdService_ExportDataServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportData not implemented")
+}
+func (UnimplementedAdServiceServer) ImportData(context.Context, *ImportRequest) (*ImportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportData not implemented")
+}
 func (UnimplementedAdServiceServer) mustEmbedUnimplementedAdServiceServer() {}
 
 // UnsafeAdServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -175,7 +528,7 @@ func _AdService_CreateAd_Handler(srv interface{}, ctx context.Context, dec func(
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/ad.AdService/CreateAd",
+		FullMethod: AdService_CreateAd_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(AdServiceServer).CreateAd(ctx, req.(*CreateAdRequest))
@@ -183,6 +536,24 @@ func _AdService_CreateAd_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AdService_GetAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).GetAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_GetAd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).GetAd(ctx, req.(*GetAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _AdService_ChangeAdStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ChangeAdStatusRequest)
 	if err := dec(in); err != nil {
@@ -193,7 +564,7 @@ func _AdService_ChangeAdStatus_Handler(srv interface{}, ctx context.Context, dec
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/ad.AdService/ChangeAdStatus",
+		FullMethod: AdService_ChangeAdStatus_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(AdServiceServer).ChangeAdStatus(ctx, req.(*ChangeAdStatusRequest))
@@ -211,7 +582,7 @@ func _AdService_UpdateAd_Handler(srv interface{}, ctx context.Context, dec func(
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/ad.AdService/UpdateAd",
+		FullMethod: AdService_UpdateAd_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(AdServiceServer).UpdateAd(ctx, req.(*UpdateAdRequest))
@@ -220,7 +591,7 @@ func _AdService_UpdateAd_Handler(srv interface{}, ctx context.Context, dec func(
 }
 
 func _AdService_ListAds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(emptypb.Empty)
+	in := new(ListAdsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -229,10 +600,10 @@ func _AdService_ListAds_Handler(srv interface{}, ctx context.Context, dec func(i
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/ad.AdService/ListAds",
+		FullMethod: AdService_ListAds_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AdServiceServer).ListAds(ctx, req.(*emptypb.Empty))
+		return srv.(AdServiceServer).ListAds(ctx, req.(*ListAdsRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -247,7 +618,7 @@ func _AdService_CreateUser_Handler(srv interface{}, ctx context.Context, dec fun
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/ad.AdService/CreateUser",
+		FullMethod: AdService_CreateUser_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(AdServiceServer).CreateUser(ctx, req.(*CreateUserRequest))
@@ -265,7 +636,7 @@ func _AdService_GetUser_Handler(srv interface{}, ctx context.Context, dec func(i
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/ad.AdService/GetUser",
+		FullMethod: AdService_GetUser_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(AdServiceServer).GetUser(ctx, req.(*GetUserRequest))
@@ -283,7 +654,7 @@ func _AdService_DeleteUser_Handler(srv interface{}, ctx context.Context, dec fun
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/ad.AdService/DeleteUser",
+		FullMethod: AdService_DeleteUser_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(AdServiceServer).DeleteUser(ctx, req.(*DeleteUserRequest))
@@ -301,7 +672,7 @@ func _AdService_DeleteAd_Handler(srv interface{}, ctx context.Context, dec func(
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/ad.AdService/DeleteAd",
+		FullMethod: AdService_DeleteAd_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(AdServiceServer).DeleteAd(ctx, req.(*DeleteAdRequest))
@@ -309,6 +680,369 @@ func _AdService_DeleteAd_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AdService_SendMessageToAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendMessageToAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).SendMessageToAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_SendMessageToAd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).SendMessageToAd(ctx, req.(*SendMessageToAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_SendMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).SendMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_SendMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).SendMessage(ctx, req.(*SendMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMessagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListMessages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListMessages(ctx, req.(*ListMessagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListInbox_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListInboxRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListInbox(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListInbox_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListInbox(ctx, req.(*ListInboxRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListAdConversations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAdConversationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListAdConversations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListAdConversations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListAdConversations(ctx, req.(*ListAdConversationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_SetUserRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetUserRoleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).SetUserRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_SetUserRole_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).SetUserRole(ctx, req.(*SetUserRoleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_BanUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BanUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).BanUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_BanUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).BanUser(ctx, req.(*BanUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListModerationQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModerationQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListModerationQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListModerationQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListModerationQueue(ctx, req.(*ModerationQueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ApproveAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApproveAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ApproveAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ApproveAd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ApproveAd(ctx, req.(*ApproveAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_RejectAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RejectAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).RejectAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_RejectAd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).RejectAd(ctx, req.(*RejectAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ReportAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ReportAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ReportAd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ReportAd(ctx, req.(*ReportAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListReportedAds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModerationQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListReportedAds(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListReportedAds_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListReportedAds(ctx, req.(*ModerationQueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ResolveReports_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveReportsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ResolveReports(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ResolveReports_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ResolveReports(ctx, req.(*ResolveReportsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_RenewAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenewAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).RenewAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_RenewAd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).RenewAd(ctx, req.(*RenewAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_CancelScheduledAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelScheduledAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).CancelScheduledAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_CancelScheduledAd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).CancelScheduledAd(ctx, req.(*CancelScheduledAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_CreateAds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAdsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).CreateAds(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_CreateAds_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).CreateAds(ctx, req.(*CreateAdsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ChangeAdsStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangeAdsStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ChangeAdsStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ChangeAdsStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ChangeAdsStatus(ctx, req.(*ChangeAdsStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_DeleteAds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAdsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).DeleteAds(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_DeleteAds_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).DeleteAds(ctx, req.(*DeleteAdsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ExportData_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdServiceServer).ExportData(m, &adServiceExportDataServer{stream})
+}
+
+type AdService_ExportDataServer interface {
+	Send(*ExportChunk) error
+	grpc.ServerStream
+}
+
+type adServiceExportDataServer struct {
+	grpc.ServerStream
+}
+
+func (x *adServiceExportDataServer) Send(m *ExportChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _AdService_ImportData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ImportData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ImportData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ImportData(ctx, req.(*ImportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // AdService_ServiceDesc is the grpc.ServiceDesc for AdService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -320,6 +1054,10 @@ var AdService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CreateAd",
 			Handler:    _AdService_CreateAd_Handler,
 		},
+		{
+			MethodName: "GetAd",
+			Handler:    _AdService_GetAd_Handler,
+		},
 		{
 			MethodName: "ChangeAdStatus",
 			Handler:    _AdService_ChangeAdStatus_Handler,
@@ -348,7 +1086,89 @@ var AdService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteAd",
 			Handler:    _AdService_DeleteAd_Handler,
 		},
+		{
+			MethodName: "SendMessageToAd",
+			Handler:    _AdService_SendMessageToAd_Handler,
+		},
+		{
+			MethodName: "SendMessage",
+			Handler:    _AdService_SendMessage_Handler,
+		},
+		{
+			MethodName: "ListMessages",
+			Handler:    _AdService_ListMessages_Handler,
+		},
+		{
+			MethodName: "ListInbox",
+			Handler:    _AdService_ListInbox_Handler,
+		},
+		{
+			MethodName: "ListAdConversations",
+			Handler:    _AdService_ListAdConversations_Handler,
+		},
+		{
+			MethodName: "SetUserRole",
+			Handler:    _AdService_SetUserRole_Handler,
+		},
+		{
+			MethodName: "BanUser",
+			Handler:    _AdService_BanUser_Handler,
+		},
+		{
+			MethodName: "ListModerationQueue",
+			Handler:    _AdService_ListModerationQueue_Handler,
+		},
+		{
+			MethodName: "ApproveAd",
+			Handler:    _AdService_ApproveAd_Handler,
+		},
+		{
+			MethodName: "RejectAd",
+			Handler:    _AdService_RejectAd_Handler,
+		},
+		{
+			MethodName: "ReportAd",
+			Handler:    _AdService_ReportAd_Handler,
+		},
+		{
+			MethodName: "ListReportedAds",
+			Handler:    _AdService_ListReportedAds_Handler,
+		},
+		{
+			MethodName: "ResolveReports",
+			Handler:    _AdService_ResolveReports_Handler,
+		},
+		{
+			MethodName: "RenewAd",
+			Handler:    _AdService_RenewAd_Handler,
+		},
+		{
+			MethodName: "CancelScheduledAd",
+			Handler:    _AdService_CancelScheduledAd_Handler,
+		},
+		{
+			MethodName: "CreateAds",
+			Handler:    _AdService_CreateAds_Handler,
+		},
+		{
+			MethodName: "ChangeAdsStatus",
+			Handler:    _AdService_ChangeAdsStatus_Handler,
+		},
+		{
+			MethodName: "DeleteAds",
+			Handler:    _AdService_DeleteAds_Handler,
+		},
+		{
+			MethodName: "ImportData",
+			Handler:    _AdService_ImportData_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportData",
+			Handler:       _AdService_ExportData_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "service.proto",
 }
@@ -0,0 +1,290 @@
+package grpcv2
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/identity"
+	"homework9/internal/ratelimit"
+	"homework9/internal/users"
+	"strconv"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var errNoActor = status.Error(codes.Unauthenticated, "missing x-user-id metadata")
+
+type Server struct {
+	a app.App
+	UnimplementedAdServiceServer
+}
+
+func NewService(a app.App) AdServiceServer {
+	return &Server{a: a}
+}
+
+// actor is the existing user of the x-user-id metadata.
+func (s Server) actor(ctx context.Context) (int64, error) {
+	id, ok := identity.User(ctx)
+	if !ok {
+		return 0, errNoActor
+	}
+	if _, err := s.a.GetUser(ctx, id); err != nil {
+		return 0, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return id, nil
+}
+
+func (s Server) CreateAd(ctx context.Context, request *CreateAdRequest) (*Ad, error) {
+	userID, err := s.actor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ad, err := s.a.CreateAd(ctx, request.Title, request.Text, userID)
+	if err != nil {
+		return nil, errorStatus(ctx, err)
+	}
+	return newAd(ad), nil
+}
+
+func (s Server) GetAd(ctx context.Context, request *GetAdRequest) (*Ad, error) {
+	viewer, ok := identity.User(ctx)
+	if !ok {
+		viewer = app.Anonymous
+	}
+	ad, err := s.a.GetAd(ctx, request.Id, viewer)
+	if err != nil {
+		return nil, errorStatus(ctx, err)
+	}
+	return newAd(ad), nil
+}
+
+func (s Server) ListAds(ctx context.Context, request *ListAdsRequest) (*ListAdsResponse, error) {
+	size := int(request.PageSize)
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	if size > maxPageSize {
+		size = maxPageSize
+	}
+	offset := 0
+	if request.PageToken != "" {
+		var err error
+		offset, err = strconv.Atoi(request.PageToken)
+		if err != nil || offset < 0 {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+	}
+
+	var list []ads.Ad
+	var err error
+	if request.AuthorId != nil {
+		list, err = s.a.GetAdsPrams(ctx, map[string]any{"author_id": request.GetAuthorId()})
+	} else {
+		list, err = s.a.GetAds(ctx)
+	}
+	if err != nil {
+		return nil, errorStatus(ctx, err)
+	}
+
+	// a token past the end gets an empty last page, the ads it was made for
+	// may be gone; clamping also keeps offset+size from overflowing
+	offset = min(offset, len(list))
+
+	resp := &ListAdsResponse{Ads: make([]*Ad, 0, size), TotalSize: int32(len(list))}
+	for i := offset; i < len(list) && i < offset+size; i++ {
+		resp.Ads = append(resp.Ads, newAd(list[i]))
+	}
+	if offset+size < len(list) {
+		resp.NextPageToken = strconv.Itoa(offset + size)
+	}
+	return resp, nil
+}
+
+func (s Server) UpdateAd(ctx context.Context, request *UpdateAdRequest) (*Ad, error) {
+	userID, err := s.actor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ad, err := s.a.GetAd(ctx, request.Id, userID)
+	if err != nil {
+		return nil, errorStatus(ctx, err)
+	}
+	title, text := ad.Title, ad.Text
+	if request.Title != nil {
+		title = request.GetTitle()
+	}
+	if request.Text != nil {
+		text = request.GetText()
+	}
+	ad, err = s.a.UpdateAd(ctx, request.Id, userID, title, text)
+	if err != nil {
+		return nil, errorStatus(ctx, err)
+	}
+	return newAd(ad), nil
+}
+
+func (s Server) PublishAd(ctx context.Context, request *PublishAdRequest) (*Ad, error) {
+	userID, err := s.actor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ad ads.Ad
+	if request.PublishTime != nil {
+		ad, err = s.a.ScheduleAd(ctx, request.Id, userID, request.PublishTime.AsTime())
+	} else {
+		ad, err = s.a.ChangeAdStatus(ctx, request.Id, userID, true)
+	}
+	if err != nil {
+		return nil, errorStatus(ctx, err)
+	}
+	return newAd(ad), nil
+}
+
+func (s Server) UnpublishAd(ctx context.Context, request *UnpublishAdRequest) (*Ad, error) {
+	userID, err := s.actor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ad, err := s.a.ChangeAdStatus(ctx, request.Id, userID, false)
+	if err != nil {
+		return nil, errorStatus(ctx, err)
+	}
+	return newAd(ad), nil
+}
+
+func (s Server) DeleteAd(ctx context.Context, request *DeleteAdRequest) (*emptypb.Empty, error) {
+	userID, err := s.actor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.a.DeleteAd(ctx, request.Id, userID); err != nil {
+		return nil, errorStatus(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s Server) CreateUser(ctx context.Context, request *CreateUserRequest) (*User, error) {
+	user, err := s.a.CreateUser(ctx, request.Nickname, request.Email)
+	if err != nil {
+		return nil, errorStatus(ctx, err)
+	}
+	return newUser(user), nil
+}
+
+func (s Server) GetUser(ctx context.Context, request *GetUserRequest) (*User, error) {
+	user, err := s.a.GetUser(ctx, request.Id)
+	if err != nil {
+		return nil, errorStatus(ctx, err)
+	}
+	return newUser(user), nil
+}
+
+func (s Server) DeleteUser(ctx context.Context, request *DeleteUserRequest) (*emptypb.Empty, error) {
+	actorID, err := s.actor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.a.DeleteUser(ctx, actorID, request.Id); err != nil {
+		return nil, errorStatus(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// errorStatus maps every app error the same way in all methods, unlike
+// ad.AdService where each method picks its own codes.
+func errorStatus(ctx context.Context, err error) error {
+	var rejected *app.ContentRejectedError
+	if errors.As(err, &rejected) {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(rejected.Reasons))
+		for i, r := range rejected.Reasons {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: r.Field, Description: r.Code + ": " + r.Message}
+		}
+		return withDetails(status.New(codes.InvalidArgument, err.Error()), &errdetails.BadRequest{FieldViolations: violations})
+	}
+	var quota *app.QuotaExceededError
+	if errors.As(err, &quota) {
+		_ = grpclib.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(ratelimit.RetryAfterSeconds(quota.RetryAfter))))
+		return withDetails(status.New(codes.ResourceExhausted, err.Error()), &errdetails.RetryInfo{RetryDelay: durationpb.New(quota.RetryAfter)})
+	}
+
+	code := codes.Internal
+	switch {
+	case errors.Is(err, app.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, app.ErrWrongUser):
+		code = codes.PermissionDenied
+	case errors.Is(err, app.ErrValidationFail):
+		code = codes.InvalidArgument
+	case errors.Is(err, app.ErrInvalidTransition):
+		code = codes.FailedPrecondition
+	}
+	return status.Error(code, err.Error())
+}
+
+func withDetails(st *status.Status, details protoiface.MessageV1) error {
+	detailed, err := st.WithDetails(details)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func newAd(ad ads.Ad) *Ad {
+	resp := &Ad{
+		Id:           ad.ID,
+		Title:        ad.Title,
+		Text:         ad.Text,
+		AuthorId:     ad.AuthorID,
+		Status:       adStatus(ad.Status),
+		RejectReason: ad.RejectReason,
+		CreateTime:   timestamppb.New(ad.DateCreate),
+		UpdateTime:   timestamppb.New(ad.DateUpdate),
+	}
+	if !ad.ExpiresAt.IsZero() {
+		resp.ExpireTime = timestamppb.New(ad.ExpiresAt)
+	}
+	if ad.Scheduled() {
+		resp.PublishTime = timestamppb.New(ad.PublishAt)
+	}
+	return resp
+}
+
+func adStatus(s ads.Status) AdStatus {
+	switch s {
+	case ads.StatusDraft:
+		return AdStatus_AD_STATUS_DRAFT
+	case ads.StatusPendingReview:
+		return AdStatus_AD_STATUS_PENDING_REVIEW
+	case ads.StatusPublished:
+		return AdStatus_AD_STATUS_PUBLISHED
+	case ads.StatusRejected:
+		return AdStatus_AD_STATUS_REJECTED
+	case ads.StatusArchived:
+		return AdStatus_AD_STATUS_ARCHIVED
+	}
+	return AdStatus_AD_STATUS_UNSPECIFIED
+}
+
+func newUser(user users.User) *User {
+	return &User{
+		Id:       user.ID,
+		Nickname: user.Nickname,
+		Email:    user.Email,
+		Role:     string(user.Role),
+		Banned:   user.Banned,
+	}
+}
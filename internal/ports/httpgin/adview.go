@@ -0,0 +1,114 @@
+package httpgin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+)
+
+// adFields are the keys of adResponse clients may select with ?fields=.
+var adFields = func() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(adResponse{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		fields[name] = true
+	}
+	return fields
+}()
+
+// adView is how the read routes present ads: ?expand=author embeds the
+// author, ?fields=title,status keeps only the listed keys and ad_id.
+type adView struct {
+	expandAuthor bool
+	fields       map[string]bool
+}
+
+func parseAdView(c *gin.Context) (adView, error) {
+	var v adView
+	for _, name := range queryList(c, "expand") {
+		if name != "author" {
+			return adView{}, fmt.Errorf("expand: unknown relation %q", name)
+		}
+		v.expandAuthor = true
+	}
+	for _, name := range queryList(c, "fields") {
+		if !adFields[name] {
+			return adView{}, fmt.Errorf("fields: unknown field %q", name)
+		}
+		if v.fields == nil {
+			v.fields = map[string]bool{"ad_id": true}
+		}
+		v.fields[name] = true
+	}
+	return v, nil
+}
+
+// queryList reads a list sent as key=a,b or as key=a&key=b.
+func queryList(c *gin.Context, key string) []string {
+	var list []string
+	for _, value := range c.QueryArray(key) {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+func (v adView) present(c *gin.Context, a app.App, list []ads.Ad) []any {
+	authors := make(map[int64]*authorResponse)
+	data := make([]any, len(list))
+	for i := range list {
+		resp := newAdResponse(&list[i])
+		if v.expandAuthor {
+			author, ok := authors[resp.AuthorID]
+			if !ok {
+				// the author may be deleted, the ad is shown without it then
+				if user, err := a.GetUser(c, resp.AuthorID); err == nil {
+					author = &authorResponse{ID: user.ID, Nickname: user.Nickname}
+				}
+				authors[resp.AuthorID] = author
+			}
+			resp.Author = author
+		}
+		data[i] = v.selectFields(resp)
+	}
+	return data
+}
+
+func (v adView) selectFields(resp adResponse) any {
+	if v.fields == nil {
+		return resp
+	}
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		return resp
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return resp
+	}
+	selected := make(map[string]json.RawMessage, len(v.fields))
+	for name := range v.fields {
+		if value, ok := all[name]; ok {
+			selected[name] = value
+		}
+	}
+	return selected
+}
+
+func writeAdView(c *gin.Context, a app.App, v adView, ad ads.Ad) {
+	c.JSON(http.StatusOK, AdViewSuccessResponse(v.present(c, a, []ads.Ad{ad})[0]))
+}
+
+func writeAdsView(c *gin.Context, a app.App, v adView, list []ads.Ad) {
+	c.JSON(http.StatusOK, AdViewSuccessResponse(v.present(c, a, list)))
+}
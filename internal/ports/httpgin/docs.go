@@ -0,0 +1,47 @@
+package httpgin
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPISpec describes every route of AppRouter, TestOpenAPICoversRoutes
+// fails when a route is added without it.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// docsPage renders openapi.json with Swagger UI, the page itself has no
+// other dependencies on the service.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Ads API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "openapi.json", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
+
+// Метод для получения спецификации OpenAPI
+func openAPI() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", openAPISpec)
+	}
+}
+
+// Страница интерактивной документации API
+func docs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+	}
+}
@@ -4,7 +4,15 @@ import (
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"homework9/internal/ads"
 	"homework9/internal/app"
+	"homework9/internal/health"
+	"homework9/internal/identity"
+	"homework9/internal/ratelimit"
+	"homework9/internal/reports"
+	"homework9/internal/transfer"
+	"homework9/internal/users"
+	"homework9/middleware"
 	"net/http"
 	"strconv"
 	"time"
@@ -18,8 +26,17 @@ func createAd(a app.App) gin.HandlerFunc {
 		if err != nil {
 			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
 		}
-		ad, err := a.CreateAd(c, reqBody.Title, reqBody.Text, reqBody.UserID)
+		userID, ok := actor(c, reqBody.UserID)
+		if !ok {
+			return
+		}
+		ad, err := a.CreateAd(c, reqBody.Title, reqBody.Text, userID)
 		if err != nil {
+			var rejected *app.ContentRejectedError
+			if errors.As(err, &rejected) {
+				c.JSON(http.StatusBadRequest, AdRejectedResponse(rejected))
+				return
+			}
 			if errors.Is(err, app.ErrWrongUser) {
 				c.JSON(http.StatusForbidden, AdErrorResponse(err))
 				return
@@ -28,6 +45,11 @@ func createAd(a app.App) gin.HandlerFunc {
 				c.JSON(http.StatusBadRequest, AdErrorResponse(err))
 				return
 			}
+			if errors.Is(err, app.ErrQuotaExceeded) {
+				setRetryAfter(c, err)
+				c.JSON(http.StatusTooManyRequests, AdErrorResponse(err))
+				return
+			}
 			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
 			return
 		}
@@ -43,17 +65,30 @@ func changeAdStatus(a app.App) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
 			return
 		}
+		userID, ok := actor(c, reqBody.UserID)
+		if !ok {
+			return
+		}
 		adID, err := strconv.ParseInt(c.Param("ad_id"), 10, 64)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
 			return
 		}
-		ad, err := a.ChangeAdStatus(c, adID, reqBody.UserID, reqBody.Published)
+		var ad ads.Ad
+		if reqBody.Published && reqBody.PublishAt != nil {
+			ad, err = a.ScheduleAd(c, adID, userID, *reqBody.PublishAt)
+		} else {
+			ad, err = a.ChangeAdStatus(c, adID, userID, reqBody.Published)
+		}
 		if err != nil {
 			if errors.Is(err, app.ErrWrongUser) {
 				c.JSON(http.StatusForbidden, AdErrorResponse(err))
 				return
 			}
+			if errors.Is(err, app.ErrInvalidTransition) {
+				c.JSON(http.StatusConflict, AdErrorResponse(err))
+				return
+			}
 			if errors.Is(err, app.ErrValidationFail) {
 				c.JSON(http.StatusBadRequest, AdErrorResponse(err))
 				return
@@ -73,6 +108,10 @@ func updateAd(a app.App) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
 			return
 		}
+		userID, ok := actor(c, reqBody.UserID)
+		if !ok {
+			return
+		}
 
 		adID, err := strconv.ParseInt(c.Param("ad_id"), 10, 64)
 		if err != nil {
@@ -80,8 +119,13 @@ func updateAd(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		ad, err := a.UpdateAd(c, int64(adID), reqBody.UserID, reqBody.Title, reqBody.Text)
+		ad, err := a.UpdateAd(c, int64(adID), userID, reqBody.Title, reqBody.Text)
 		if err != nil {
+			var rejected *app.ContentRejectedError
+			if errors.As(err, &rejected) {
+				c.JSON(http.StatusBadRequest, AdRejectedResponse(rejected))
+				return
+			}
 			if errors.Is(err, app.ErrWrongUser) {
 				c.JSON(http.StatusForbidden, AdErrorResponse(err))
 				return
@@ -117,34 +161,102 @@ func createUser(a app.App) gin.HandlerFunc {
 	}
 }
 
+// Метод для удаления пользователя (самим пользователем или администратором)
 func deleteUser(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody getUserRequest
+		if _, ok := bindLegacyBody(c, &reqBody); !ok {
+			return
+		}
+		actorID, ok := actor(c, reqBody.UserId)
+		if !ok {
+			return
+		}
+		userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		}
+		err = a.DeleteUser(c, actorID, userID)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongUser) {
+				c.JSON(http.StatusForbidden, UserErrorResponse(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, UserErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusOK, UserSuccessDelete())
+	}
+}
+
+// Метод для назначения роли пользователю (только для администратора)
+func setUserRole(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody setUserRoleRequest
 		if err := c.BindJSON(&reqBody); err != nil {
 			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
 			return
 		}
+		adminID, ok := actor(c, reqBody.AdminID)
+		if !ok {
+			return
+		}
 		userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
 			return
 		}
-		err = a.DeleteUser(c, userID)
+		user, err := a.SetUserRole(c, adminID, userID, users.Role(reqBody.Role))
 		if err != nil {
+			if errors.Is(err, app.ErrWrongUser) {
+				c.JSON(http.StatusForbidden, UserErrorResponse(err))
+				return
+			}
+			if errors.Is(err, app.ErrValidationFail) {
+				c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+				return
+			}
 			c.JSON(http.StatusInternalServerError, UserErrorResponse(err))
 			return
 		}
-		c.JSON(http.StatusOK, UserSuccessDelete())
+		c.JSON(http.StatusOK, UserSuccessResponse(&user))
 	}
 }
 
-func getUser(a app.App) gin.HandlerFunc {
+// Метод для блокировки и разблокировки пользователя (только для администратора)
+func banUser(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var reqBody getUserRequest
+		var reqBody banUserRequest
 		if err := c.BindJSON(&reqBody); err != nil {
 			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
 			return
 		}
+		adminID, ok := actor(c, reqBody.AdminID)
+		if !ok {
+			return
+		}
+		userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		}
+		user, err := a.BanUser(c, adminID, userID, reqBody.Banned)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongUser) {
+				c.JSON(http.StatusForbidden, UserErrorResponse(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, UserErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusOK, UserSuccessResponse(&user))
+	}
+}
+
+func getUser(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ignoreLegacyBody(c)
 		userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
@@ -165,36 +277,42 @@ func getUser(a app.App) gin.HandlerFunc {
 
 func getAd(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var reqBody getAdID
-		if err := c.BindJSON(&reqBody); err != nil {
+		view, err := parseAdView(c)
+		if err != nil {
 			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
 			return
 		}
+		ignoreLegacyBody(c)
 		adID, err := strconv.ParseInt(c.Param("ad_id"), 10, 64)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
 			return
 		}
-		ad, err := a.GetAd(c, adID)
+		ad, err := a.GetAd(c, adID, viewer(c))
 		if err != nil {
 			if errors.Is(err, app.ErrValidationFail) {
 				c.JSON(http.StatusBadRequest, UserErrorResponse(err))
 				return
 			}
+			if errors.Is(err, app.ErrNotFound) {
+				c.JSON(http.StatusNotFound, UserErrorResponse(err))
+				return
+			}
 			c.JSON(http.StatusInternalServerError, UserErrorResponse(err))
 			return
 		}
-		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+		writeAdView(c, a, view, ad)
 	}
 }
 
 func getAdByTitle(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var reqBody getTitle
-		if err := c.BindJSON(&reqBody); err != nil {
+		view, err := parseAdView(c)
+		if err != nil {
 			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
 			return
 		}
+		ignoreLegacyBody(c)
 		title := c.Param("title")
 		ad, err := a.GetAdByTitle(c, title)
 		if err != nil {
@@ -205,22 +323,73 @@ func getAdByTitle(a app.App) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, UserErrorResponse(err))
 			return
 		}
-		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+		writeAdView(c, a, view, ad)
 	}
 }
 
+// Метод для получения списка объявлений, фильтры author_id, published и date_create (RFC 3339) передаются в query, как и expand=author и fields
 func getAds(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ad, err := a.GetAds(c)
+		view, err := parseAdView(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+		params, err := adFilters(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+		var ad []ads.Ad
+		if len(params) == 0 {
+			ad, err = a.GetAds(c)
+		} else {
+			ad, err = a.GetAdsPrams(c, params)
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
 			return
 		}
-		c.JSON(http.StatusOK, AdsSuccessResponse(ad))
+		writeAdsView(c, a, view, ad)
+	}
+}
+
+// adFilters reads the filters of the ad listing from the query.
+func adFilters(c *gin.Context) (map[string]any, error) {
+	params := make(map[string]any)
+	if v, ok := c.GetQuery("author_id"); ok {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("author_id: %w", err)
+		}
+		params["author_id"] = id
+	}
+	if v, ok := c.GetQuery("published"); ok {
+		published, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("published: %w", err)
+		}
+		params["published"] = published
+	}
+	if v, ok := c.GetQuery("date_create"); ok {
+		date, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("date_create: %w", err)
+		}
+		params["date_create"] = date.UTC()
 	}
+	return params, nil
 }
+
+// Устаревший метод фильтрации объявлений, заменён на GET /ads с query-параметрами
 func getAdsFilter(a app.App) gin.HandlerFunc {
+	list := getAds(a)
 	return func(c *gin.Context) {
+		deprecated(c)
+		if !hasBody(c) {
+			list(c)
+			return
+		}
 		var reqBody paramsAdRequest
 		if err := c.BindJSON(&reqBody); err != nil {
 			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
@@ -266,10 +435,14 @@ func getAdsFilter(a app.App) gin.HandlerFunc {
 func deleteAd(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody deleteAdRequest
-		if err := c.BindJSON(&reqBody); err != nil {
-			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+		if _, ok := bindLegacyBody(c, &reqBody); !ok {
+			return
 		}
-		_, err := a.GetUser(c, int64(reqBody.UserId))
+		userID, ok := actor(c, reqBody.UserId)
+		if !ok {
+			return
+		}
+		_, err := a.GetUser(c, userID)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, AdErrorResponse(err))
 			return
@@ -285,9 +458,13 @@ func deleteAd(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		err = a.DeleteAd(c, int64(adID), reqBody.UserId)
+		err = a.DeleteAd(c, int64(adID), userID)
 
 		if err != nil {
+			if errors.Is(err, app.ErrWrongUser) {
+				c.JSON(http.StatusForbidden, AdErrorResponse(err))
+				return
+			}
 			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
 			return
 		}
@@ -295,3 +472,720 @@ func deleteAd(a app.App) gin.HandlerFunc {
 		c.JSON(http.StatusOK, AdSuccessDelete())
 	}
 }
+
+// Метод для отправки сообщения автору объявления (создаёт переписку при первом сообщении)
+func sendMessageToAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody sendMessageRequest
+		if err := c.BindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, MessageErrorResponse(err))
+			return
+		}
+		userID, ok := actor(c, reqBody.UserID)
+		if !ok {
+			return
+		}
+		adID, err := strconv.ParseInt(c.Param("ad_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, MessageErrorResponse(err))
+			return
+		}
+		msg, err := a.SendMessageToAd(c, adID, userID, reqBody.Text)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongUser) {
+				c.JSON(http.StatusForbidden, MessageErrorResponse(err))
+				return
+			}
+			if errors.Is(err, app.ErrValidationFail) {
+				c.JSON(http.StatusBadRequest, MessageErrorResponse(err))
+				return
+			}
+			if errors.Is(err, app.ErrNotFound) {
+				c.JSON(http.StatusNotFound, MessageErrorResponse(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, MessageErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusOK, MessageSuccessResponse(&msg))
+	}
+}
+
+// Метод для отправки сообщения в существующую переписку
+func sendMessage(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody sendMessageRequest
+		if err := c.BindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, MessageErrorResponse(err))
+			return
+		}
+		userID, ok := actor(c, reqBody.UserID)
+		if !ok {
+			return
+		}
+		conversationID, err := strconv.ParseInt(c.Param("conversation_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, MessageErrorResponse(err))
+			return
+		}
+		msg, err := a.SendMessage(c, conversationID, userID, reqBody.Text)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongUser) {
+				c.JSON(http.StatusForbidden, MessageErrorResponse(err))
+				return
+			}
+			if errors.Is(err, app.ErrValidationFail) {
+				c.JSON(http.StatusBadRequest, MessageErrorResponse(err))
+				return
+			}
+			if errors.Is(err, app.ErrNotFound) {
+				c.JSON(http.StatusNotFound, MessageErrorResponse(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, MessageErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusOK, MessageSuccessResponse(&msg))
+	}
+}
+
+// Метод для чтения переписки (сообщения собеседника помечаются прочитанными)
+func getMessages(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody getUserRequest
+		if _, ok := bindLegacyBody(c, &reqBody); !ok {
+			return
+		}
+		userID, ok := actor(c, reqBody.UserId)
+		if !ok {
+			return
+		}
+		conversationID, err := strconv.ParseInt(c.Param("conversation_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, MessageErrorResponse(err))
+			return
+		}
+		msgs, err := a.GetMessages(c, conversationID, userID)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongUser) {
+				c.JSON(http.StatusForbidden, MessageErrorResponse(err))
+				return
+			}
+			if errors.Is(err, app.ErrNotFound) {
+				c.JSON(http.StatusNotFound, MessageErrorResponse(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, MessageErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusOK, MessagesSuccessResponse(msgs))
+	}
+}
+
+// Метод для получения списка переписок пользователя (самим пользователем или администратором)
+func getInbox(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID, ok := actor(c, nil)
+		if !ok {
+			return
+		}
+		userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, MessageErrorResponse(err))
+			return
+		}
+		convs, err := a.GetInbox(c, actorID, userID)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongUser) {
+				c.JSON(http.StatusForbidden, MessageErrorResponse(err))
+				return
+			}
+			if errors.Is(err, app.ErrNotFound) {
+				c.JSON(http.StatusNotFound, MessageErrorResponse(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, MessageErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusOK, ConversationsSuccessResponse(convs))
+	}
+}
+
+// Метод для получения всех переписок по объявлению (только для автора)
+func getAdConversations(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody getUserRequest
+		if _, ok := bindLegacyBody(c, &reqBody); !ok {
+			return
+		}
+		userID, ok := actor(c, reqBody.UserId)
+		if !ok {
+			return
+		}
+		adID, err := strconv.ParseInt(c.Param("ad_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, MessageErrorResponse(err))
+			return
+		}
+		convs, err := a.GetAdConversations(c, adID, userID)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongUser) {
+				c.JSON(http.StatusForbidden, MessageErrorResponse(err))
+				return
+			}
+			if errors.Is(err, app.ErrNotFound) {
+				c.JSON(http.StatusNotFound, MessageErrorResponse(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, MessageErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusOK, ConversationsSuccessResponse(convs))
+	}
+}
+
+// Метод для получения очереди объявлений на модерации
+func getModerationQueue(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		view, err := parseAdView(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+		var reqBody moderatorRequest
+		if _, ok := bindLegacyBody(c, &reqBody); !ok {
+			return
+		}
+		moderatorID, ok := actor(c, reqBody.ModeratorID)
+		if !ok {
+			return
+		}
+		ads, err := a.GetModerationQueue(c, moderatorID)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongUser) {
+				c.JSON(http.StatusForbidden, AdErrorResponse(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
+			return
+		}
+		writeAdsView(c, a, view, ads)
+	}
+}
+
+// Метод для одобрения объявления модератором
+func approveAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody moderatorRequest
+		if _, ok := bindLegacyBody(c, &reqBody); !ok {
+			return
+		}
+		moderatorID, ok := actor(c, reqBody.ModeratorID)
+		if !ok {
+			return
+		}
+		adID, err := strconv.ParseInt(c.Param("ad_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+		ad, err := a.ApproveAd(c, adID, moderatorID)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongUser) {
+				c.JSON(http.StatusForbidden, AdErrorResponse(err))
+				return
+			}
+			if errors.Is(err, app.ErrInvalidTransition) {
+				c.JSON(http.StatusConflict, AdErrorResponse(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+// Метод для отклонения объявления модератором с указанием причины
+func rejectAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody rejectAdRequest
+		if err := c.BindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+		moderatorID, ok := actor(c, reqBody.ModeratorID)
+		if !ok {
+			return
+		}
+		adID, err := strconv.ParseInt(c.Param("ad_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+		ad, err := a.RejectAd(c, adID, moderatorID, reqBody.Reason)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongUser) {
+				c.JSON(http.StatusForbidden, AdErrorResponse(err))
+				return
+			}
+			if errors.Is(err, app.ErrValidationFail) {
+				c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+				return
+			}
+			if errors.Is(err, app.ErrInvalidTransition) {
+				c.JSON(http.StatusConflict, AdErrorResponse(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+// Метод для жалобы на объявление
+func reportAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody reportAdRequest
+		if err := c.BindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ReportErrorResponse(err))
+			return
+		}
+		userID, ok := actor(c, reqBody.UserID)
+		if !ok {
+			return
+		}
+		adID, err := strconv.ParseInt(c.Param("ad_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ReportErrorResponse(err))
+			return
+		}
+		report, err := a.ReportAd(c, adID, userID, reports.Reason(reqBody.Reason), reqBody.Comment)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongUser) {
+				c.JSON(http.StatusForbidden, ReportErrorResponse(err))
+				return
+			}
+			if errors.Is(err, app.ErrValidationFail) {
+				c.JSON(http.StatusBadRequest, ReportErrorResponse(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, ReportErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusOK, ReportSuccessResponse(&report))
+	}
+}
+
+// Метод для получения объявлений с открытыми жалобами
+func getReportedAds(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody moderatorRequest
+		if _, ok := bindLegacyBody(c, &reqBody); !ok {
+			return
+		}
+		moderatorID, ok := actor(c, reqBody.ModeratorID)
+		if !ok {
+			return
+		}
+		list, err := a.GetReportedAds(c, moderatorID)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongUser) {
+				c.JSON(http.StatusForbidden, ReportErrorResponse(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, ReportErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusOK, ReportSummariesSuccessResponse(list))
+	}
+}
+
+// Метод для закрытия жалоб на объявление (dismissed - жалобы отклонены, rejected - объявление снято)
+func resolveReports(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody resolveReportsRequest
+		if err := c.BindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ReportErrorResponse(err))
+			return
+		}
+		moderatorID, ok := actor(c, reqBody.ModeratorID)
+		if !ok {
+			return
+		}
+		adID, err := strconv.ParseInt(c.Param("ad_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ReportErrorResponse(err))
+			return
+		}
+		ad, err := a.ResolveReports(c, adID, moderatorID, reports.Resolution(reqBody.Resolution), reqBody.Comment)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongUser) {
+				c.JSON(http.StatusForbidden, ReportErrorResponse(err))
+				return
+			}
+			if errors.Is(err, app.ErrValidationFail) {
+				c.JSON(http.StatusBadRequest, ReportErrorResponse(err))
+				return
+			}
+			if errors.Is(err, app.ErrInvalidTransition) {
+				c.JSON(http.StatusConflict, ReportErrorResponse(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, ReportErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+// Метод для продления срока публикации объявления
+func renewAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody getUserRequest
+		if _, ok := bindLegacyBody(c, &reqBody); !ok {
+			return
+		}
+		userID, ok := actor(c, reqBody.UserId)
+		if !ok {
+			return
+		}
+		adID, err := strconv.ParseInt(c.Param("ad_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+		ad, err := a.RenewAd(c, adID, userID)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongUser) {
+				c.JSON(http.StatusForbidden, AdErrorResponse(err))
+				return
+			}
+			if errors.Is(err, app.ErrInvalidTransition) {
+				c.JSON(http.StatusConflict, AdErrorResponse(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+// Метод для отмены отложенной публикации объявления
+func cancelScheduledAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody getUserRequest
+		if _, ok := bindLegacyBody(c, &reqBody); !ok {
+			return
+		}
+		userID, ok := actor(c, reqBody.UserId)
+		if !ok {
+			return
+		}
+		adID, err := strconv.ParseInt(c.Param("ad_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+		ad, err := a.CancelScheduledAd(c, adID, userID)
+		if err != nil {
+			if errors.Is(err, app.ErrWrongUser) {
+				c.JSON(http.StatusForbidden, AdErrorResponse(err))
+				return
+			}
+			if errors.Is(err, app.ErrInvalidTransition) {
+				c.JSON(http.StatusConflict, AdErrorResponse(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+// errorStatus maps an app error to the status code of the single-item routes.
+func errorStatus(err error) int {
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, app.ErrWrongUser):
+		return http.StatusForbidden
+	case errors.Is(err, app.ErrValidationFail):
+		return http.StatusBadRequest
+	case errors.Is(err, app.ErrInvalidTransition), errors.Is(err, app.ErrBatchAborted):
+		return http.StatusConflict
+	case errors.Is(err, app.ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// setRetryAfter tells the client when its ad quota is renewed.
+func setRetryAfter(c *gin.Context, err error) {
+	var quota *app.QuotaExceededError
+	if errors.As(err, &quota) {
+		c.Header("Retry-After", strconv.Itoa(ratelimit.RetryAfterSeconds(quota.RetryAfter)))
+	}
+}
+
+var errNoActor = errors.New("missing " + middleware.UserIDHeader + " header")
+var errActorMismatch = errors.New("the user in the body does not match the " + middleware.UserIDHeader + " header")
+
+// actor is the user the request acts for, middleware.Actor takes it from
+// the X-User-ID header. Old clients send the ID in the body instead, legacy
+// is used then and the response is marked deprecated; it is nil when the
+// request did not carry it. A legacy ID naming someone other than the
+// header is refused. It reports false after answering 401 or 403.
+func actor(c *gin.Context, legacy *int64) (int64, bool) {
+	if id, ok := identity.User(c.Request.Context()); ok {
+		if legacy != nil && *legacy != id {
+			c.JSON(http.StatusForbidden, AdErrorResponse(errActorMismatch))
+			return 0, false
+		}
+		return id, true
+	}
+	if legacy == nil {
+		c.JSON(http.StatusUnauthorized, AdErrorResponse(errNoActor))
+		return 0, false
+	}
+	deprecated(c)
+	return *legacy, true
+}
+
+// viewer is the user of the X-User-ID header, reads without it are
+// anonymous.
+func viewer(c *gin.Context) int64 {
+	if id, ok := identity.User(c.Request.Context()); ok {
+		return id
+	}
+	return app.Anonymous
+}
+
+// queryActor is actor for the routes whose old clients send the ID in the
+// query parameter key.
+func queryActor(c *gin.Context, key string) (int64, bool) {
+	value, sent := c.GetQuery(key)
+	if !sent {
+		return actor(c, nil)
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, AdErrorResponse(fmt.Errorf("%s: %w", key, err)))
+		return 0, false
+	}
+	return actor(c, &id)
+}
+
+// queryIDs parses every value of the query parameter key.
+func queryIDs(c *gin.Context, key string) ([]int64, error) {
+	values := c.QueryArray(key)
+	ids := make([]int64, len(values))
+	for i, v := range values {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+func hasBody(c *gin.Context) bool {
+	return c.Request.ContentLength > 0 || len(c.Request.TransferEncoding) > 0
+}
+
+// bindLegacyBody reads the body old clients send to routes that take none
+// now, sent is false when there is no body. It reports false after
+// answering 400 to a malformed body.
+func bindLegacyBody(c *gin.Context, obj any) (sent bool, ok bool) {
+	if !hasBody(c) {
+		return false, true
+	}
+	deprecated(c)
+	if err := c.BindJSON(obj); err != nil {
+		c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+		return true, false
+	}
+	return true, true
+}
+
+// ignoreLegacyBody marks the requests of old clients that send a body the
+// route never read.
+func ignoreLegacyBody(c *gin.Context) {
+	if hasBody(c) {
+		deprecated(c)
+	}
+}
+
+// deprecated marks a response to a request in a form that is removed in
+// the next release.
+func deprecated(c *gin.Context) {
+	c.Header("Deprecation", "true")
+}
+
+// writeBatch writes per-item results. An aborted all-or-nothing batch gets
+// 409 with the results, so the client sees which items failed.
+func writeBatch(c *gin.Context, results []app.BatchResult, withAds bool, err error) {
+	if err != nil && !errors.Is(err, app.ErrBatchAborted) {
+		setRetryAfter(c, err)
+		c.JSON(errorStatus(err), AdErrorResponse(err))
+		return
+	}
+	c.JSON(errorStatus(err), BatchResponse(results, withAds, err))
+}
+
+// Метод для создания нескольких объявлений одним запросом
+func createAds(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody createAdsRequest
+		if err := c.BindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+		userID, ok := actor(c, reqBody.UserID)
+		if !ok {
+			return
+		}
+		items := make([]app.NewAd, len(reqBody.Ads))
+		for i, item := range reqBody.Ads {
+			items[i] = app.NewAd{Title: item.Title, Text: item.Text}
+		}
+		results, err := a.CreateAds(c, userID, items, reqBody.Atomic)
+		writeBatch(c, results, true, err)
+	}
+}
+
+// Метод для изменения статуса нескольких объявлений одним запросом
+func changeAdsStatus(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody changeAdsStatusRequest
+		if err := c.BindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+		userID, ok := actor(c, reqBody.UserID)
+		if !ok {
+			return
+		}
+		results, err := a.ChangeAdsStatus(c, reqBody.AdIDs, userID, reqBody.Published, reqBody.Atomic)
+		writeBatch(c, results, true, err)
+	}
+}
+
+// Метод для удаления нескольких объявлений одним запросом (id=1&id=2&atomic=true)
+func deleteAds(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody deleteAdsRequest
+		sent, ok := bindLegacyBody(c, &reqBody)
+		if !ok {
+			return
+		}
+		if !sent {
+			ids, err := queryIDs(c, "id")
+			if err != nil {
+				c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+				return
+			}
+			reqBody.AdIDs = ids
+			reqBody.Atomic = c.Query("atomic") == "true"
+		}
+		userID, ok := actor(c, reqBody.UserID)
+		if !ok {
+			return
+		}
+		results, err := a.DeleteAds(c, reqBody.AdIDs, userID, reqBody.Atomic)
+		writeBatch(c, results, false, err)
+	}
+}
+
+// Метод для выгрузки всех пользователей и объявлений в формате JSON Lines или CSV
+func exportData(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, ok := queryActor(c, "admin_id")
+		if !ok {
+			return
+		}
+		format, err := transfer.ParseFormat(c.Query("format"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+		userList, err := a.ExportUsers(c, adminID)
+		if err != nil {
+			c.JSON(errorStatus(err), AdErrorResponse(err))
+			return
+		}
+		adList, err := a.ExportAds(c, adminID)
+		if err != nil {
+			c.JSON(errorStatus(err), AdErrorResponse(err))
+			return
+		}
+
+		// выгрузка может писаться дольше http.write-timeout, ограничение снимается для неё
+		_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+		c.Header("Content-Type", format.ContentType())
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=export.%s", format))
+		c.Status(http.StatusOK)
+		enc := transfer.NewEncoder(c.Writer, format)
+		for _, user := range userList {
+			if err := enc.Encode(transfer.Record{Kind: transfer.KindUser, User: user}); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+		for _, ad := range adList {
+			if err := enc.Encode(transfer.Record{Kind: transfer.KindAd, Ad: ad}); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// Метод для загрузки пользователей и объявлений из выгрузки, ошибки возвращаются построчно
+func importData(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID, ok := queryActor(c, "admin_id")
+		if !ok {
+			return
+		}
+		format, err := transfer.ParseFormat(c.Query("format"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+		preserveIDs := c.Query("preserve_ids") == "true"
+		batch, err := transfer.ReadAll(transfer.NewDecoder(c.Request.Body, format))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+		res, err := a.Import(c, adminID, batch.Users, batch.Ads, preserveIDs)
+		if err != nil {
+			c.JSON(errorStatus(err), AdErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusOK, ImportSuccessResponse(batch, res))
+	}
+}
+
+// Метод для проверки, что процесс жив (liveness)
+func healthz() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, HealthResponse("ok", nil))
+	}
+}
+
+// Метод для проверки готовности принимать запросы (readiness), 503 во время остановки сервера
+func readyz(h *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := h.Ready(c); err != nil {
+			c.JSON(http.StatusServiceUnavailable, HealthResponse("not ready", err))
+			return
+		}
+		c.JSON(http.StatusOK, HealthResponse("ready", nil))
+	}
+}
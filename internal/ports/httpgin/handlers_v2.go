@@ -0,0 +1,311 @@
+package httpgin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/identity"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// errorV2Status maps an app error to the status and the code of /api/v2,
+// unlike errorStatus it tells missing resources apart.
+func errorV2Status(err error) (int, string) {
+	switch {
+	case errors.Is(err, app.ErrNotFound):
+		return http.StatusNotFound, codeNotFound
+	case errors.Is(err, app.ErrWrongUser):
+		return http.StatusForbidden, codeForbidden
+	case errors.Is(err, app.ErrValidationFail):
+		return http.StatusBadRequest, codeValidation
+	case errors.Is(err, app.ErrInvalidTransition):
+		return http.StatusConflict, codeConflict
+	case errors.Is(err, app.ErrQuotaExceeded):
+		return http.StatusTooManyRequests, codeQuotaExceeded
+	}
+	return http.StatusInternalServerError, codeInternal
+}
+
+func writeV2Error(c *gin.Context, err error) {
+	var rejected *app.ContentRejectedError
+	if errors.As(err, &rejected) {
+		c.JSON(http.StatusBadRequest, RejectedV2Response(rejected))
+		return
+	}
+	setRetryAfter(c, err)
+	status, code := errorV2Status(err)
+	c.JSON(status, ErrorV2Response(code, err.Error()))
+}
+
+func badRequestV2(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, ErrorV2Response(codeBadRequest, err.Error()))
+}
+
+// actorV2 is the user of the X-User-ID header, /api/v2 takes it from
+// nowhere else. It reports false after answering 401.
+func actorV2(c *gin.Context) (int64, bool) {
+	id, ok := identity.User(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorV2Response(codeUnauthenticated, errNoActor.Error()))
+	}
+	return id, ok
+}
+
+func pathIDV2(c *gin.Context, key string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param(key), 10, 64)
+	if err != nil {
+		badRequestV2(c, err)
+		return 0, false
+	}
+	return id, true
+}
+
+// Метод для создания объявления, автор берётся из X-User-ID
+func createAdV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody createAdV2Request
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			badRequestV2(c, err)
+			return
+		}
+		userID, ok := actorV2(c)
+		if !ok {
+			return
+		}
+		ad, err := a.CreateAd(c, reqBody.Title, reqBody.Text, userID)
+		if err != nil {
+			writeV2Error(c, err)
+			return
+		}
+		c.Header("Location", adLink(ad.ID))
+		c.JSON(http.StatusCreated, AdV2Response(ad))
+	}
+}
+
+// Метод для получения страницы опубликованных объявлений (page, per_page, author_id)
+func listAdsV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		meta := pageMeta{Page: 1, PerPage: defaultPerPage}
+		var err error
+		if v, ok := c.GetQuery("page"); ok {
+			if meta.Page, err = strconv.Atoi(v); err != nil || meta.Page < 1 {
+				badRequestV2(c, errors.New("page must be a positive number"))
+				return
+			}
+		}
+		if v, ok := c.GetQuery("per_page"); ok {
+			if meta.PerPage, err = strconv.Atoi(v); err != nil || meta.PerPage < 1 || meta.PerPage > maxPerPage {
+				badRequestV2(c, errors.New("per_page must be between 1 and "+strconv.Itoa(maxPerPage)))
+				return
+			}
+		}
+
+		var list []ads.Ad
+		if v, ok := c.GetQuery("author_id"); ok {
+			authorID, parseErr := strconv.ParseInt(v, 10, 64)
+			if parseErr != nil {
+				badRequestV2(c, parseErr)
+				return
+			}
+			list, err = a.GetAdsPrams(c, map[string]any{"author_id": authorID})
+		} else {
+			list, err = a.GetAds(c)
+		}
+		if err != nil {
+			writeV2Error(c, err)
+			return
+		}
+
+		meta.Total = len(list)
+		meta.TotalPages = (meta.Total + meta.PerPage - 1) / meta.PerPage
+		from := min((meta.Page-1)*meta.PerPage, meta.Total)
+		to := min(from+meta.PerPage, meta.Total)
+		c.JSON(http.StatusOK, AdsPageV2Response(list[from:to], meta, c.Request.URL.Query()))
+	}
+}
+
+// Метод для доступа к объявлению по ID
+func getAdV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, ok := pathIDV2(c, "ad_id")
+		if !ok {
+			return
+		}
+		ad, err := a.GetAd(c, adID, viewer(c))
+		if err != nil {
+			writeV2Error(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, AdV2Response(ad))
+	}
+}
+
+// Метод для частичного обновления заголовка(title) и текста(text) объявления
+func updateAdV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, ok := pathIDV2(c, "ad_id")
+		if !ok {
+			return
+		}
+		var reqBody updateAdV2Request
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			badRequestV2(c, err)
+			return
+		}
+		userID, ok := actorV2(c)
+		if !ok {
+			return
+		}
+		ad, err := a.GetAd(c, adID, userID)
+		if err != nil {
+			writeV2Error(c, err)
+			return
+		}
+		title, text := ad.Title, ad.Text
+		if reqBody.Title != nil {
+			title = *reqBody.Title
+		}
+		if reqBody.Text != nil {
+			text = *reqBody.Text
+		}
+		ad, err = a.UpdateAd(c, adID, userID, title, text)
+		if err != nil {
+			writeV2Error(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, AdV2Response(ad))
+	}
+}
+
+// Метод для публикации объявления, с publish_at публикация откладывается
+func publishAdV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, ok := pathIDV2(c, "ad_id")
+		if !ok {
+			return
+		}
+		var reqBody publishAdV2Request
+		if hasBody(c) {
+			if err := c.ShouldBindJSON(&reqBody); err != nil {
+				badRequestV2(c, err)
+				return
+			}
+		}
+		userID, ok := actorV2(c)
+		if !ok {
+			return
+		}
+		var ad ads.Ad
+		var err error
+		if reqBody.PublishAt != nil {
+			ad, err = a.ScheduleAd(c, adID, userID, *reqBody.PublishAt)
+		} else {
+			ad, err = a.ChangeAdStatus(c, adID, userID, true)
+		}
+		if err != nil {
+			writeV2Error(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, AdV2Response(ad))
+	}
+}
+
+// Метод для снятия объявления с публикации
+func unpublishAdV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, ok := pathIDV2(c, "ad_id")
+		if !ok {
+			return
+		}
+		userID, ok := actorV2(c)
+		if !ok {
+			return
+		}
+		ad, err := a.ChangeAdStatus(c, adID, userID, false)
+		if err != nil {
+			writeV2Error(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, AdV2Response(ad))
+	}
+}
+
+// Метод для удаления объявления
+func deleteAdV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, ok := pathIDV2(c, "ad_id")
+		if !ok {
+			return
+		}
+		userID, ok := actorV2(c)
+		if !ok {
+			return
+		}
+		if err := a.DeleteAd(c, adID, userID); err != nil {
+			writeV2Error(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// Метод для создания пользователя
+func createUserV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody createUserV2Request
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			badRequestV2(c, err)
+			return
+		}
+		user, err := a.CreateUser(c, reqBody.Nickname, reqBody.Email)
+		if err != nil {
+			writeV2Error(c, err)
+			return
+		}
+		c.Header("Location", userLink(user.ID))
+		c.JSON(http.StatusCreated, UserV2Response(user))
+	}
+}
+
+// Метод для доступа к пользователю по ID
+func getUserV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := pathIDV2(c, "user_id")
+		if !ok {
+			return
+		}
+		user, err := a.GetUser(c, userID)
+		if err != nil {
+			writeV2Error(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, UserV2Response(user))
+	}
+}
+
+// Метод для удаления пользователя (самим пользователем или администратором)
+func deleteUserV2(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID, ok := actorV2(c)
+		if !ok {
+			return
+		}
+		userID, ok := pathIDV2(c, "user_id")
+		if !ok {
+			return
+		}
+		if err := a.DeleteUser(c, actorID, userID); err != nil {
+			writeV2Error(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
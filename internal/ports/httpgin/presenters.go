@@ -1,15 +1,21 @@
 package httpgin
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/messages"
+	"homework9/internal/reports"
+	"homework9/internal/transfer"
 	"homework9/internal/users"
+	"time"
 )
 
 type createAdRequest struct {
 	Title  string `json:"title"`
 	Text   string `json:"text"`
-	UserID int64  `json:"user_id"`
+	UserID *int64 `json:"user_id"`
 }
 
 type createUserRequest struct {
@@ -17,55 +23,134 @@ type createUserRequest struct {
 	Email    string `json:"email"`
 }
 
-type getTitle struct {
-	Title string `json:"title"`
+type adResponse struct {
+	ID           int64      `json:"ad_id"`
+	Title        string     `json:"title"`
+	Text         string     `json:"text"`
+	AuthorID     int64      `json:"author_id"`
+	Published    bool       `json:"published"`
+	Status       string     `json:"status"`
+	RejectReason string     `json:"reject_reason,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	PublishAt    *time.Time `json:"publish_at,omitempty"`
+	DateCreate   time.Time  `json:"date_create"`
+	DateUpdate   time.Time  `json:"date_update"`
+	// Author is set with ?expand=author.
+	Author *authorResponse `json:"author,omitempty"`
 }
 
-type adResponse struct {
-	ID        int64  `json:"ad_id"`
-	Title     string `json:"title"`
-	Text      string `json:"text"`
-	AuthorID  int64  `json:"author_id"`
-	Published bool   `json:"published"`
+type authorResponse struct {
+	ID       int64  `json:"user_id"`
+	Nickname string `json:"nickname"`
 }
 
 type userResponse struct {
 	ID       int64  `json:"user_id"`
 	Nickname string `json:"nickname"`
 	Email    string `json:"email"`
+	Role     string `json:"role"`
+	Banned   bool   `json:"banned"`
 }
 
 type changeAdStatusRequest struct {
-	Published bool  `json:"published"`
-	UserID    int64 `json:"user_id"`
+	Published bool       `json:"published"`
+	UserID    *int64     `json:"user_id"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+type batchAdItem struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+type createAdsRequest struct {
+	UserID *int64        `json:"user_id"`
+	Atomic bool          `json:"atomic"`
+	Ads    []batchAdItem `json:"ads"`
+}
+
+type changeAdsStatusRequest struct {
+	UserID    *int64  `json:"user_id"`
+	Atomic    bool    `json:"atomic"`
+	AdIDs     []int64 `json:"ad_ids"`
+	Published bool    `json:"published"`
+}
+
+type deleteAdsRequest struct {
+	UserID *int64  `json:"user_id"`
+	Atomic bool    `json:"atomic"`
+	AdIDs  []int64 `json:"ad_ids"`
+}
+
+// batchItemResponse is the result of one batch item. Status is the HTTP
+// status the item would get from the single-item route.
+type batchItemResponse struct {
+	Index   int                 `json:"index"`
+	Status  int                 `json:"status"`
+	Error   string              `json:"error,omitempty"`
+	Reasons []rejectionResponse `json:"reasons,omitempty"`
+	Ad      *adResponse         `json:"ad,omitempty"`
+}
+
+type setUserRoleRequest struct {
+	AdminID *int64 `json:"admin_id"`
+	Role    string `json:"role"`
+}
+
+type banUserRequest struct {
+	AdminID *int64 `json:"admin_id"`
+	Banned  bool   `json:"banned"`
+}
+
+type moderatorRequest struct {
+	ModeratorID *int64 `json:"moderator_id"`
+}
+
+type rejectAdRequest struct {
+	ModeratorID *int64 `json:"moderator_id"`
+	Reason      string `json:"reason"`
 }
 
 type getUserRequest struct {
-	UserId int64 `json:"user_id"`
+	UserId *int64 `json:"user_id"`
 }
 type deleteAdRequest struct {
-	UserId int64 `json:"user_id"`
-	AdId   int64 `json:"ad_id"`
+	UserId *int64 `json:"user_id"`
+	AdId   int64  `json:"ad_id"`
 }
 
 type updateAdRequest struct {
 	Title  string `json:"title"`
 	Text   string `json:"text"`
-	UserID int64  `json:"user_id"`
+	UserID *int64 `json:"user_id"`
 }
-type getAdID struct {
-	ID int64 `json:"id"`
+
+func newAdResponse(ad *ads.Ad) adResponse {
+	resp := adResponse{
+		ID:           ad.ID,
+		Title:        ad.Title,
+		Text:         ad.Text,
+		AuthorID:     ad.AuthorID,
+		Published:    ad.Published(),
+		Status:       string(ad.Status),
+		RejectReason: ad.RejectReason,
+		DateCreate:   ad.DateCreate,
+		DateUpdate:   ad.DateUpdate,
+	}
+	if !ad.ExpiresAt.IsZero() {
+		expiresAt := ad.ExpiresAt
+		resp.ExpiresAt = &expiresAt
+	}
+	if ad.Scheduled() {
+		publishAt := ad.PublishAt
+		resp.PublishAt = &publishAt
+	}
+	return resp
 }
 
 func AdSuccessResponse(ad *ads.Ad) *gin.H {
 	return &gin.H{
-		"data": adResponse{
-			ID:        ad.ID,
-			Title:     ad.Title,
-			Text:      ad.Text,
-			AuthorID:  ad.AuthorID,
-			Published: ad.Published,
-		},
+		"data":  newAdResponse(ad),
 		"error": nil,
 	}
 }
@@ -76,6 +161,8 @@ func UserSuccessResponse(user *users.User) *gin.H {
 			ID:       user.ID,
 			Nickname: user.Nickname,
 			Email:    user.Email,
+			Role:     string(user.Role),
+			Banned:   user.Banned,
 		},
 		"error": nil,
 	}
@@ -99,6 +186,31 @@ func AdErrorResponse(err error) *gin.H {
 		"error": err.Error(),
 	}
 }
+
+type rejectionResponse struct {
+	Code    string `json:"code"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// AdRejectedResponse is the error envelope extended with the reasons the
+// content filters gave.
+func AdRejectedResponse(err *app.ContentRejectedError) *gin.H {
+	return &gin.H{
+		"data":    nil,
+		"error":   err.Error(),
+		"reasons": newRejectionResponses(err),
+	}
+}
+
+func newRejectionResponses(err *app.ContentRejectedError) []rejectionResponse {
+	reasons := make([]rejectionResponse, len(err.Reasons))
+	for i, r := range err.Reasons {
+		reasons[i] = rejectionResponse{Code: r.Code, Field: r.Field, Message: r.Message}
+	}
+	return reasons
+}
+
 func UserErrorResponse(err error) *gin.H {
 	return &gin.H{
 		"data":  nil,
@@ -107,14 +219,8 @@ func UserErrorResponse(err error) *gin.H {
 }
 func AdsSuccessResponse(ads []ads.Ad) *gin.H {
 	ans := make([]adResponse, len(ads))
-	for i, v := range ads {
-		ans[i] = adResponse{
-			ID:        v.ID,
-			Title:     v.Title,
-			Text:      v.Text,
-			AuthorID:  v.AuthorID,
-			Published: v.Published,
-		}
+	for i := range ads {
+		ans[i] = newAdResponse(&ads[i])
 	}
 	return &gin.H{
 		"data":  ans,
@@ -122,6 +228,241 @@ func AdsSuccessResponse(ads []ads.Ad) *gin.H {
 	}
 }
 
+// AdViewSuccessResponse wraps the ads adView presented.
+func AdViewSuccessResponse(data any) *gin.H {
+	return &gin.H{
+		"data":  data,
+		"error": nil,
+	}
+}
+
 type paramsAdRequest struct {
 	Params map[string]any `json:"params"`
 }
+
+type sendMessageRequest struct {
+	Text   string `json:"text"`
+	UserID *int64 `json:"user_id"`
+}
+
+type messageResponse struct {
+	ID             int64     `json:"message_id"`
+	ConversationID int64     `json:"conversation_id"`
+	SenderID       int64     `json:"sender_id"`
+	Text           string    `json:"text"`
+	Read           bool      `json:"read"`
+	DateCreate     time.Time `json:"date_create"`
+}
+
+type conversationResponse struct {
+	ID         int64     `json:"conversation_id"`
+	AdID       int64     `json:"ad_id"`
+	BuyerID    int64     `json:"buyer_id"`
+	SellerID   int64     `json:"seller_id"`
+	DateUpdate time.Time `json:"date_update"`
+}
+
+func newMessageResponse(msg *messages.Message) messageResponse {
+	return messageResponse{
+		ID:             msg.ID,
+		ConversationID: msg.ConversationID,
+		SenderID:       msg.SenderID,
+		Text:           msg.Text,
+		Read:           msg.Read,
+		DateCreate:     msg.DateCreate,
+	}
+}
+
+func MessageSuccessResponse(msg *messages.Message) *gin.H {
+	return &gin.H{
+		"data":  newMessageResponse(msg),
+		"error": nil,
+	}
+}
+
+func MessagesSuccessResponse(msgs []messages.Message) *gin.H {
+	ans := make([]messageResponse, len(msgs))
+	for i := range msgs {
+		ans[i] = newMessageResponse(&msgs[i])
+	}
+	return &gin.H{
+		"data":  ans,
+		"error": nil,
+	}
+}
+
+func ConversationsSuccessResponse(convs []messages.Conversation) *gin.H {
+	ans := make([]conversationResponse, len(convs))
+	for i, v := range convs {
+		ans[i] = conversationResponse{
+			ID:         v.ID,
+			AdID:       v.AdID,
+			BuyerID:    v.BuyerID,
+			SellerID:   v.SellerID,
+			DateUpdate: v.DateUpdate,
+		}
+	}
+	return &gin.H{
+		"data":  ans,
+		"error": nil,
+	}
+}
+
+func MessageErrorResponse(err error) *gin.H {
+	return &gin.H{
+		"data":  nil,
+		"error": err.Error(),
+	}
+}
+
+type reportAdRequest struct {
+	UserID  *int64 `json:"user_id"`
+	Reason  string `json:"reason"`
+	Comment string `json:"comment"`
+}
+
+type resolveReportsRequest struct {
+	ModeratorID *int64 `json:"moderator_id"`
+	Resolution  string `json:"resolution"`
+	Comment     string `json:"comment"`
+}
+
+type reportResponse struct {
+	ID         int64     `json:"report_id"`
+	AdID       int64     `json:"ad_id"`
+	ReporterID int64     `json:"reporter_id"`
+	Reason     string    `json:"reason"`
+	Comment    string    `json:"comment"`
+	DateCreate time.Time `json:"date_create"`
+}
+
+type reportSummaryResponse struct {
+	AdID       int64          `json:"ad_id"`
+	Reports    int            `json:"reports"`
+	Reporters  int            `json:"reporters"`
+	Reasons    map[string]int `json:"reasons"`
+	LastReport time.Time      `json:"last_report"`
+}
+
+func ReportSuccessResponse(report *reports.Report) *gin.H {
+	return &gin.H{
+		"data": reportResponse{
+			ID:         report.ID,
+			AdID:       report.AdID,
+			ReporterID: report.ReporterID,
+			Reason:     string(report.Reason),
+			Comment:    report.Comment,
+			DateCreate: report.DateCreate,
+		},
+		"error": nil,
+	}
+}
+
+func ReportSummariesSuccessResponse(list []reports.Summary) *gin.H {
+	ans := make([]reportSummaryResponse, len(list))
+	for i, v := range list {
+		reasons := make(map[string]int, len(v.Reasons))
+		for reason, n := range v.Reasons {
+			reasons[string(reason)] = n
+		}
+		ans[i] = reportSummaryResponse{
+			AdID:       v.AdID,
+			Reports:    v.Reports,
+			Reporters:  v.Reporters,
+			Reasons:    reasons,
+			LastReport: v.LastReport,
+		}
+	}
+	return &gin.H{
+		"data":  ans,
+		"error": nil,
+	}
+}
+
+func ReportErrorResponse(err error) *gin.H {
+	return &gin.H{
+		"data":  nil,
+		"error": err.Error(),
+	}
+}
+
+// BatchResponse wraps per-item results, err is set when an all-or-nothing
+// batch was aborted.
+func BatchResponse(results []app.BatchResult, withAds bool, err error) *gin.H {
+	items := make([]batchItemResponse, len(results))
+	for i, res := range results {
+		items[i] = batchItemResponse{Index: i, Status: errorStatus(res.Err)}
+		if res.Err != nil {
+			items[i].Error = res.Err.Error()
+			var rejected *app.ContentRejectedError
+			if errors.As(res.Err, &rejected) {
+				items[i].Reasons = newRejectionResponses(rejected)
+			}
+			continue
+		}
+		if withAds {
+			ad := newAdResponse(&results[i].Ad)
+			items[i].Ad = &ad
+		}
+	}
+	var errText any
+	if err != nil {
+		errText = err.Error()
+	}
+	return &gin.H{
+		"data":  items,
+		"error": errText,
+	}
+}
+
+type importErrorResponse struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type importResponse struct {
+	Users  int                   `json:"users"`
+	Ads    int                   `json:"ads"`
+	Errors []importErrorResponse `json:"errors"`
+}
+
+func ImportSuccessResponse(batch transfer.Batch, res app.ImportResult) *gin.H {
+	lineErrs := batch.LineErrors(res.Users, res.Ads)
+	resp := importResponse{
+		Users:  countStored(res.Users),
+		Ads:    countStored(res.Ads),
+		Errors: make([]importErrorResponse, len(lineErrs)),
+	}
+	for i, e := range lineErrs {
+		resp.Errors[i] = importErrorResponse{Line: e.Line, Error: e.Err.Error()}
+	}
+	return &gin.H{
+		"data":  resp,
+		"error": nil,
+	}
+}
+
+func countStored(errs []error) int {
+	n := 0
+	for _, err := range errs {
+		if err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+func HealthResponse(status string, err error) *gin.H {
+	var msg any
+	if err != nil {
+		msg = err.Error()
+	}
+	return &gin.H{
+		"data":  healthResponse{Status: status},
+		"error": msg,
+	}
+}
@@ -0,0 +1,187 @@
+package httpgin
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/users"
+)
+
+// V2Prefix is where the routes of AppRouterV2 are served, links in the
+// responses point there.
+const V2Prefix = "/api/v2"
+
+// Error codes of the /api/v2 envelope, clients switch on them instead of
+// the status or the message.
+const (
+	codeBadRequest      = "bad_request"
+	codeValidation      = "validation_failed"
+	codeContentRejected = "content_rejected"
+	codeUnauthenticated = "unauthenticated"
+	codeForbidden       = "forbidden"
+	codeNotFound        = "not_found"
+	codeConflict        = "conflict"
+	codeQuotaExceeded   = "quota_exceeded"
+	codeInternal        = "internal"
+)
+
+type links map[string]string
+
+type adV2Response struct {
+	ID           int64      `json:"id"`
+	Title        string     `json:"title"`
+	Text         string     `json:"text"`
+	AuthorID     int64      `json:"author_id"`
+	Status       string     `json:"status"`
+	RejectReason string     `json:"reject_reason,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	PublishAt    *time.Time `json:"publish_at,omitempty"`
+	Links        links      `json:"links"`
+}
+
+type userV2Response struct {
+	ID       int64  `json:"id"`
+	Nickname string `json:"nickname"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	Banned   bool   `json:"banned"`
+	Links    links  `json:"links"`
+}
+
+type pageMeta struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+type errorV2 struct {
+	Code    string              `json:"code"`
+	Message string              `json:"message"`
+	Details []rejectionResponse `json:"details,omitempty"`
+}
+
+type createAdV2Request struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// updateAdV2Request changes the fields that are sent.
+type updateAdV2Request struct {
+	Title *string `json:"title"`
+	Text  *string `json:"text"`
+}
+
+type publishAdV2Request struct {
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+type createUserV2Request struct {
+	Nickname string `json:"nickname"`
+	Email    string `json:"email"`
+}
+
+func adLink(id int64) string {
+	return fmt.Sprintf("%s/ads/%d", V2Prefix, id)
+}
+
+func userLink(id int64) string {
+	return fmt.Sprintf("%s/users/%d", V2Prefix, id)
+}
+
+func newAdV2Response(ad ads.Ad) adV2Response {
+	resp := adV2Response{
+		ID:           ad.ID,
+		Title:        ad.Title,
+		Text:         ad.Text,
+		AuthorID:     ad.AuthorID,
+		Status:       string(ad.Status),
+		RejectReason: ad.RejectReason,
+		CreatedAt:    ad.DateCreate,
+		UpdatedAt:    ad.DateUpdate,
+		Links: links{
+			"self":   adLink(ad.ID),
+			"author": userLink(ad.AuthorID),
+		},
+	}
+	if !ad.ExpiresAt.IsZero() {
+		resp.ExpiresAt = &ad.ExpiresAt
+	}
+	if ad.Scheduled() {
+		resp.PublishAt = &ad.PublishAt
+	}
+	// the transitions the author can ask for next
+	if ad.Published() {
+		resp.Links["unpublish"] = adLink(ad.ID) + "/unpublish"
+	} else if ad.Status.CanTransitionTo(ads.StatusPendingReview) {
+		resp.Links["publish"] = adLink(ad.ID) + "/publish"
+	}
+	return resp
+}
+
+func newUserV2Response(user users.User) userV2Response {
+	return userV2Response{
+		ID:       user.ID,
+		Nickname: user.Nickname,
+		Email:    user.Email,
+		Role:     string(user.Role),
+		Banned:   user.Banned,
+		Links: links{
+			"self": userLink(user.ID),
+			"ads":  V2Prefix + "/ads?author_id=" + strconv.FormatInt(user.ID, 10),
+		},
+	}
+}
+
+func AdV2Response(ad ads.Ad) *gin.H {
+	return &gin.H{"data": newAdV2Response(ad)}
+}
+
+func UserV2Response(user users.User) *gin.H {
+	return &gin.H{"data": newUserV2Response(user)}
+}
+
+// AdsPageV2Response is a page of list with the links to its neighbours,
+// query keeps the filters of the request.
+func AdsPageV2Response(list []ads.Ad, meta pageMeta, query url.Values) *gin.H {
+	data := make([]adV2Response, len(list))
+	for i, ad := range list {
+		data[i] = newAdV2Response(ad)
+	}
+	page := func(n int) string {
+		q := url.Values{}
+		for k, v := range query {
+			q[k] = v
+		}
+		q.Set("page", strconv.Itoa(n))
+		q.Set("per_page", strconv.Itoa(meta.PerPage))
+		return V2Prefix + "/ads?" + q.Encode()
+	}
+	pageLinks := links{
+		"self":  page(meta.Page),
+		"first": page(1),
+		"last":  page(max(meta.TotalPages, 1)),
+	}
+	if meta.Page > 1 {
+		pageLinks["prev"] = page(meta.Page - 1)
+	}
+	if meta.Page < meta.TotalPages {
+		pageLinks["next"] = page(meta.Page + 1)
+	}
+	return &gin.H{"data": data, "meta": meta, "links": pageLinks}
+}
+
+func ErrorV2Response(code string, message string) *gin.H {
+	return &gin.H{"error": errorV2{Code: code, Message: message}}
+}
+
+func RejectedV2Response(err *app.ContentRejectedError) *gin.H {
+	return &gin.H{"error": errorV2{Code: codeContentRejected, Message: err.Error(), Details: newRejectionResponses(err)}}
+}
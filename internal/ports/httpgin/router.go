@@ -3,19 +3,76 @@ package httpgin
 import (
 	"github.com/gin-gonic/gin"
 	"homework9/internal/app"
+	"homework9/internal/health"
+	"homework9/middleware"
 )
 
+// AppRouter serves the API of /api/v1. The ad reads answer with an ETag,
+// a client that sends it back in If-None-Match gets 304 while the ad is
+// unchanged.
 func AppRouter(r *gin.RouterGroup, a app.App) {
-	r.POST("/ads", createAd(a))                    // Метод для создания объявления (ad)
-	r.PUT("/ads/:ad_id/status", changeAdStatus(a)) // Метод для изменения статуса объявления (опубликовано - Published = true или снято с публикации Published = false)
-	r.PUT("/ads/:ad_id", updateAd(a))              // Метод для обновления текста(Text) или заголовка(Title) объявления
-	r.GET("/ads/:ad_id", getAd(a))                 // Метод для доступа к объявления по ID
-	r.GET("/ads/title/:title", getAdByTitle(a))    // Метод для доступа к объявлению по Title
-	r.GET("/ads", getAds(a))
-	r.GET("/ads/params", getAdsFilter(a))
-	r.DELETE("/ads/:ad_id", deleteAd(a))
+	r.POST("/ads", createAd(a))                                  // Метод для создания объявления (ad)
+	r.PUT("/ads/:ad_id/status", changeAdStatus(a))               // Метод для изменения статуса объявления (опубликовано - Published = true или снято с публикации Published = false)
+	r.PUT("/ads/:ad_id", updateAd(a))                            // Метод для обновления текста(Text) или заголовка(Title) объявления
+	r.PUT("/ads/:ad_id/renew", renewAd(a))                       // Метод для продления срока публикации объявления
+	r.DELETE("/ads/:ad_id/schedule", cancelScheduledAd(a))       // Метод для отмены отложенной публикации (publish_at) объявления
+	r.GET("/ads/:ad_id", middleware.ETag, getAd(a))              // Метод для доступа к объявления по ID
+	r.GET("/ads/title/:title", middleware.ETag, getAdByTitle(a)) // Метод для доступа к объявлению по Title
+	r.GET("/ads", middleware.ETag, getAds(a))                    // Метод для получения списка объявлений, фильтры передаются в query
+	r.GET("/ads/params", getAdsFilter(a))                        // Устаревший метод фильтрации объявлений, заменён на GET /ads
+	r.DELETE("/ads/:ad_id", deleteAd(a))                         // Метод для удаления объявления
+
+	r.POST("/ads/batch", createAds(a))             // Метод для создания нескольких объявлений
+	r.PUT("/ads/batch/status", changeAdsStatus(a)) // Метод для изменения статуса нескольких объявлений
+	r.DELETE("/ads/batch", deleteAds(a))           // Метод для удаления нескольких объявлений
 
 	r.POST("/users", createUser(a))            // Метод для создания пользователя (user)
 	r.DELETE("/users/:user_id", deleteUser(a)) // Метод для удаления пользователя (user)
 	r.GET("/users/:user_id", getUser(a))       // Метод для доступа к пользователю по ID
+
+	r.POST("/ads/:ad_id/messages", sendMessageToAd(a))                 // Метод для отправки сообщения автору объявления
+	r.GET("/ads/:ad_id/conversations", getAdConversations(a))          // Метод для доступа автора ко всем перепискам по объявлению
+	r.POST("/conversations/:conversation_id/messages", sendMessage(a)) // Метод для отправки сообщения в переписку
+	r.GET("/conversations/:conversation_id/messages", getMessages(a))  // Метод для чтения переписки
+	r.GET("/users/:user_id/conversations", getInbox(a))                // Метод для доступа к списку переписок пользователя
+
+	r.PUT("/admin/users/:user_id/role", setUserRole(a)) // Метод для назначения роли пользователю
+	r.PUT("/admin/users/:user_id/ban", banUser(a))      // Метод для блокировки пользователя
+	r.GET("/admin/export", exportData(a))               // Метод для выгрузки данных (format=jsonl|csv)
+	r.POST("/admin/import", importData(a))              // Метод для загрузки данных (format=jsonl|csv, preserve_ids=true)
+
+	r.GET("/moderation/ads", getModerationQueue(a))       // Метод для доступа к очереди модерации
+	r.PUT("/moderation/ads/:ad_id/approve", approveAd(a)) // Метод для одобрения объявления
+	r.PUT("/moderation/ads/:ad_id/reject", rejectAd(a))   // Метод для отклонения объявления с причиной
+
+	r.POST("/ads/:ad_id/reports", reportAd(a))                     // Метод для жалобы на объявление
+	r.GET("/moderation/reports", getReportedAds(a))                // Метод для доступа к объявлениям с жалобами
+	r.PUT("/moderation/reports/:ad_id/resolve", resolveReports(a)) // Метод для закрытия жалоб на объявление
+
+	r.GET("/openapi.json", openAPI()) // Метод для получения спецификации OpenAPI
+	r.GET("/docs", docs())            // Страница интерактивной документации API
+}
+
+// AppRouterV2 serves the same app as AppRouter with the resource model of
+// V2Prefix: the actor always comes from X-User-ID, ads carry timestamps and
+// links, lists are paginated and errors have codes.
+func AppRouterV2(r *gin.RouterGroup, a app.App) {
+	r.POST("/ads", createAdV2(a))                     // Метод для создания объявления
+	r.GET("/ads", middleware.ETag, listAdsV2(a))      // Метод для получения страницы объявлений (page, per_page, author_id)
+	r.GET("/ads/:ad_id", middleware.ETag, getAdV2(a)) // Метод для доступа к объявлению по ID
+	r.PATCH("/ads/:ad_id", updateAdV2(a))             // Метод для частичного обновления объявления
+	r.POST("/ads/:ad_id/publish", publishAdV2(a))     // Метод для публикации объявления (сразу или в publish_at)
+	r.POST("/ads/:ad_id/unpublish", unpublishAdV2(a)) // Метод для снятия объявления с публикации
+	r.DELETE("/ads/:ad_id", deleteAdV2(a))            // Метод для удаления объявления
+
+	r.POST("/users", createUserV2(a))            // Метод для создания пользователя
+	r.GET("/users/:user_id", getUserV2(a))       // Метод для доступа к пользователю по ID
+	r.DELETE("/users/:user_id", deleteUserV2(a)) // Метод для удаления пользователя
+}
+
+// HealthRouter adds the probes of the orchestrator, they live outside the
+// API prefix and skip its middleware.
+func HealthRouter(r gin.IRoutes, h *health.Checker) {
+	r.GET("/healthz", healthz()) // Метод для проверки, что процесс жив
+	r.GET("/readyz", readyz(h))  // Метод для проверки готовности принимать запросы
 }
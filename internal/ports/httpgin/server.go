@@ -2,26 +2,118 @@ package httpgin
 
 import (
 	"context"
+	"crypto/tls"
 	"homework9/middleware"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"homework9/internal/app"
+	"homework9/internal/health"
+	"homework9/internal/idempotency"
+	"homework9/internal/metrics"
+	"homework9/internal/ratelimit"
 )
 
 type Server struct {
-	svr *http.Server
+	svr     *http.Server
+	metrics *metrics.Metrics
+	health  *health.Checker
+	limiter *ratelimit.Limiter
+	idem    *idempotency.Store
+	gateway http.Handler
 }
 
-func NewHTTPServer(port string, a app.App) Server {
+type Option func(*Server)
+
+// WithTimeouts limits the time to read a request and to write a response.
+func WithTimeouts(read time.Duration, write time.Duration) Option {
+	return func(s *Server) {
+		s.svr.ReadTimeout = read
+		s.svr.WriteTimeout = write
+	}
+}
+
+// WithMetrics records the requests to the API and serves m at /metrics.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(s *Server) {
+		s.metrics = m
+	}
+}
+
+// WithHealth makes /readyz report the checks of h, without it the server
+// is ready as long as it runs.
+func WithHealth(h *health.Checker) Option {
+	return func(s *Server) {
+		s.health = h
+	}
+}
+
+// WithRateLimit answers 429 to clients that send too many API requests.
+func WithRateLimit(l *ratelimit.Limiter) Option {
+	return func(s *Server) {
+		s.limiter = l
+	}
+}
+
+// WithIdempotency replays the responses of POST requests retried with the
+// same Idempotency-Key from st.
+func WithIdempotency(st *idempotency.Store) Option {
+	return func(s *Server) {
+		s.idem = st
+	}
+}
+
+// WithTLS serves HTTPS with the certificates of cfg.
+func WithTLS(cfg *tls.Config) Option {
+	return func(s *Server) {
+		s.svr.TLSConfig = cfg
+	}
+}
+
+// WithGateway serves h under /v1 with the middleware of the API, h is the
+// gateway generated from the gRPC service.
+func WithGateway(h http.Handler) Option {
+	return func(s *Server) {
+		s.gateway = h
+	}
+}
+
+func NewHTTPServer(port string, a app.App, opts ...Option) Server {
+	s := Server{svr: &http.Server{Addr: port}, health: health.New()}
+	for _, opt := range opts {
+		opt(&s)
+	}
+
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
-	api := router.Group("/api/v1")
-	api.Use(middleware.Logger)
-	api.Use(middleware.Recover)
-	AppRouter(api, a)
-	return Server{&http.Server{Addr: port, Handler: router}}
+	// handlers pass *gin.Context to the app, it has to see the request ID
+	// that middleware.Logger puts into the request context
+	router.ContextWithFallback = true
+	HealthRouter(router, s.health)
+	mw := []gin.HandlerFunc{middleware.Recover}
+	if s.metrics != nil {
+		router.GET("/metrics", gin.WrapH(s.metrics.Handler()))
+		mw = append(mw, middleware.Metrics(s.metrics))
+	}
+	mw = append(mw, middleware.Tracing, middleware.Logger, middleware.Actor)
+	if s.limiter != nil {
+		mw = append(mw, middleware.RateLimit(s.limiter))
+	}
+	if s.idem != nil {
+		mw = append(mw, middleware.Idempotency(s.idem))
+	}
+	AppRouter(router.Group("/api/v1", mw...), a)
+	v2 := append([]gin.HandlerFunc{middleware.WithErrorFormat(func(code string, message string) any {
+		return ErrorV2Response(code, message)
+	})}, mw...)
+	AppRouterV2(router.Group(V2Prefix, v2...), a)
+	if s.gateway != nil {
+		router.Group("/v1", mw...).Any("/*path", gin.WrapH(s.gateway)) // Методы gRPC-шлюза, маршруты заданы в service.proto
+	}
+	s.svr.Handler = router
+	return s
 }
 
 func (s *Server) Handler() http.Handler {
@@ -33,5 +125,9 @@ func (s *Server) Shutdown(ctx context.Context) error {
 }
 
 func (s *Server) ListenAndServe() error {
+	if s.svr.TLSConfig != nil {
+		// the certificate comes from TLSConfig, it may be reloaded
+		return s.svr.ListenAndServeTLS("", "")
+	}
 	return s.svr.ListenAndServe()
 }
@@ -0,0 +1,113 @@
+// Package ratelimit keeps a token bucket per client and route.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the buckets that refilled completely are
+// dropped, a full bucket is the same as no bucket.
+const sweepInterval = time.Minute
+
+type Limit struct {
+	RPS   float64
+	Burst int
+}
+
+type Option func(*Limiter)
+
+func WithClock(now func() time.Time) Option {
+	return func(l *Limiter) {
+		l.now = now
+	}
+}
+
+// Limiter gives every client a bucket per route listed in routes and one
+// shared bucket with the default limit for all the other routes.
+type Limiter struct {
+	mu        sync.Mutex
+	def       Limit
+	routes    map[string]Limit
+	buckets   map[bucketKey]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+type bucketKey struct {
+	route  string
+	client string
+}
+
+type bucket struct {
+	limit  Limit
+	tokens float64
+	last   time.Time
+}
+
+func New(def Limit, routes map[string]Limit, opts ...Option) *Limiter {
+	l := &Limiter{def: def, routes: routes, buckets: make(map[bucketKey]*bucket), now: time.Now}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.lastSweep = l.now()
+	return l
+}
+
+// Allow takes a token from the bucket of the client for the route. When
+// the bucket is empty it returns false and the time until the next token.
+func (l *Limiter) Allow(route string, client string) (bool, time.Duration) {
+	limit, ok := l.routes[route]
+	if !ok {
+		route, limit = "", l.def
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+	key := bucketKey{route, client}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limit: limit, tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / limit.RPS * float64(time.Second))
+	return false, wait
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.RPS)
+		b.last = now
+	}
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// RetryAfterSeconds rounds the wait up to whole seconds for the
+// Retry-After header, which can not say "now".
+func RetryAfterSeconds(wait time.Duration) int {
+	s := int(math.Ceil(wait.Seconds()))
+	if s < 1 {
+		return 1
+	}
+	return s
+}
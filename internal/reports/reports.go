@@ -0,0 +1,94 @@
+package reports
+
+import (
+	"sort"
+	"time"
+)
+
+type Reason string
+
+const (
+	ReasonSpam       Reason = "spam"
+	ReasonFraud      Reason = "fraud"
+	ReasonProhibited Reason = "prohibited"
+	ReasonOffensive  Reason = "offensive"
+	ReasonDuplicate  Reason = "duplicate"
+	ReasonOther      Reason = "other"
+)
+
+func (r Reason) Valid() bool {
+	switch r {
+	case ReasonSpam, ReasonFraud, ReasonProhibited, ReasonOffensive, ReasonDuplicate, ReasonOther:
+		return true
+	}
+	return false
+}
+
+type Resolution string
+
+const (
+	ResolutionNone      Resolution = ""
+	ResolutionDismissed Resolution = "dismissed"
+	ResolutionRejected  Resolution = "rejected"
+)
+
+type Report struct {
+	ID         int64
+	AdID       int64
+	ReporterID int64
+	Reason     Reason
+	Comment    string
+	Resolution Resolution
+	DateCreate time.Time
+}
+
+func (r Report) Open() bool {
+	return r.Resolution == ResolutionNone
+}
+
+// Summary aggregates the open reports filed against one ad.
+type Summary struct {
+	AdID       int64
+	Reports    int
+	Reporters  int
+	Reasons    map[Reason]int
+	LastReport time.Time
+}
+
+// Summarize groups open reports by ad, most reported ads first.
+func Summarize(list []Report) []Summary {
+	byAd := make(map[int64]*Summary)
+	reporters := make(map[int64]map[int64]struct{})
+	order := make([]int64, 0)
+	for _, r := range list {
+		if !r.Open() {
+			continue
+		}
+		s, ok := byAd[r.AdID]
+		if !ok {
+			s = &Summary{AdID: r.AdID, Reasons: make(map[Reason]int)}
+			byAd[r.AdID] = s
+			reporters[r.AdID] = make(map[int64]struct{})
+			order = append(order, r.AdID)
+		}
+		s.Reports++
+		s.Reasons[r.Reason]++
+		reporters[r.AdID][r.ReporterID] = struct{}{}
+		if r.DateCreate.After(s.LastReport) {
+			s.LastReport = r.DateCreate
+		}
+	}
+	ans := make([]Summary, 0, len(order))
+	for _, adID := range order {
+		s := byAd[adID]
+		s.Reporters = len(reporters[adID])
+		ans = append(ans, *s)
+	}
+	sort.SliceStable(ans, func(i, j int) bool {
+		if ans[i].Reporters != ans[j].Reporters {
+			return ans[i].Reporters > ans[j].Reporters
+		}
+		return ans[i].AdID < ans[j].AdID
+	})
+	return ans
+}
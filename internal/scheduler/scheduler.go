@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"context"
+	"homework9/internal/logging"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Job is a piece of background work repeated every Interval.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+type Scheduler struct {
+	jobs []Job
+}
+
+func New(jobs ...Job) *Scheduler {
+	return &Scheduler{jobs: jobs}
+}
+
+// Run starts every job on its own ticker and blocks until ctx is done.
+// A failed run is logged and retried on the next tick. Run returns only
+// after the runs in progress have finished, so it can be used in the
+// graceful shutdown sequence.
+func (s *Scheduler) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			s.loop(ctx, job)
+		}(job)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// every run gets its own ID, so its log lines can be told apart
+			runCtx := logging.WithRequestID(ctx, logging.NewRequestID())
+			if err := job.Run(runCtx); err != nil && ctx.Err() == nil {
+				slog.ErrorContext(runCtx, "scheduler: job failed", "job", job.Name, "error", err)
+			}
+		}
+	}
+}
@@ -24,6 +24,9 @@ func TestChangeAdStatus(t *testing.T) {
 	response, err := client.createAd(0, "hello", "world")
 	assert.NoError(t, err)
 
+	_, err = client.changeAdStatus(0, response.Data.ID, false)
+	assert.ErrorIs(t, err, ErrConflict, "a draft is not published")
+
 	response, err = client.changeAdStatus(0, response.Data.ID, true)
 
 	assert.NoError(t, err)
@@ -33,9 +36,8 @@ func TestChangeAdStatus(t *testing.T) {
 	assert.NoError(t, err)
 	assert.False(t, response.Data.Published)
 
-	response, err = client.changeAdStatus(0, response.Data.ID, false)
-	assert.NoError(t, err)
-	assert.False(t, response.Data.Published)
+	_, err = client.changeAdStatus(0, response.Data.ID, false)
+	assert.ErrorIs(t, err, ErrConflict, "the ad is already unpublished")
 }
 
 func TestUpdateAd(t *testing.T) {
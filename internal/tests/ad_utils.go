@@ -4,15 +4,22 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"homework9/middleware"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 type adData struct {
-	ID        int64  `json:"id"`
-	Title     string `json:"title"`
-	Text      string `json:"text"`
-	AuthorID  int64  `json:"author_id"`
-	Published bool   `json:"published"`
+	ID           int64      `json:"id"`
+	Title        string     `json:"title"`
+	Text         string     `json:"text"`
+	AuthorID     int64      `json:"author_id"`
+	Published    bool       `json:"published"`
+	Status       string     `json:"status"`
+	RejectReason string     `json:"reject_reason"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	PublishAt    *time.Time `json:"publish_at"`
 }
 
 type adResponse struct {
@@ -145,6 +152,22 @@ func (tc *testClient) getAd(id int64) (adResponse, error) {
 	return response, nil
 }
 
+// getAdAs reads the ad as userID, ads that are not published are seen by
+// their author and the moderators only.
+func (tc *testClient) getAdAs(userID int64, id int64) (adResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d", id), nil)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Set(middleware.UserIDHeader, strconv.FormatInt(userID, 10))
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+	return response, nil
+}
+
 func (tc *testClient) getAdByTitle(title string) (adResponse, error) {
 	body := map[string]any{
 		"title": title,
@@ -182,3 +205,131 @@ func (tc *testClient) getAdsByParams(params map[string]interface{}) (adsResponse
 	}
 	return response, nil
 }
+
+func (tc *testClient) getModerationQueue(moderatorID int64) (adsResponse, error) {
+	body := map[string]any{
+		"moderator_id": moderatorID,
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return adsResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/moderation/ads", bytes.NewReader(data))
+	if err != nil {
+		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	var response adsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adsResponse{}, err
+	}
+	return response, nil
+}
+
+func (tc *testClient) approveAd(moderatorID int64, adID int64) (adResponse, error) {
+	body := map[string]any{
+		"moderator_id": moderatorID,
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/moderation/ads/%d/approve", adID), bytes.NewReader(data))
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+	return response, nil
+}
+
+func (tc *testClient) rejectAd(moderatorID int64, adID int64, reason string) (adResponse, error) {
+	body := map[string]any{
+		"moderator_id": moderatorID,
+		"reason":       reason,
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/moderation/ads/%d/reject", adID), bytes.NewReader(data))
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+	return response, nil
+}
+
+func (tc *testClient) renewAd(userID int64, adID int64) (adResponse, error) {
+	body := map[string]any{
+		"user_id": userID,
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/renew", adID), bytes.NewReader(data))
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+	return response, nil
+}
+
+func (tc *testClient) scheduleAd(userID int64, adID int64, publishAt time.Time) (adResponse, error) {
+	body := map[string]any{
+		"user_id":    userID,
+		"published":  true,
+		"publish_at": publishAt,
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/status", adID), bytes.NewReader(data))
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+	return response, nil
+}
+
+func (tc *testClient) cancelScheduledAd(userID int64, adID int64) (adResponse, error) {
+	body := map[string]any{
+		"user_id": userID,
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/schedule", adID), bytes.NewReader(data))
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+	return response, nil
+}
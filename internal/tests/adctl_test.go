@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/adctl"
+	"homework9/internal/app"
+	grpcPort "homework9/internal/ports/grpc"
+)
+
+func getAdctl(t *testing.T, opts ...grpc.ServerOption) (*adctl.CLI, *bytes.Buffer, context.Context) {
+	client, ctx := getGRPCTestClient(t, app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New()), opts...)
+	out := &bytes.Buffer{}
+	return &adctl.CLI{Client: client, Out: out, Output: "json"}, out, ctx
+}
+
+func runAdctl(t *testing.T, cli *adctl.CLI, out *bytes.Buffer, ctx context.Context, args string) string {
+	out.Reset()
+	err := cli.Run(ctx, strings.Fields(args))
+	assert.NoError(t, err, args)
+	return out.String()
+}
+
+func TestAdctlAds(t *testing.T) {
+	cli, out, ctx := getAdctl(t)
+	runAdctl(t, cli, out, ctx, "users create -nickname oleg -email oleg@mail.ru")
+	runAdctl(t, cli, out, ctx, "users create -nickname ivan -email ivan@mail.ru")
+
+	var ad map[string]any
+	err := json.Unmarshal([]byte(runAdctl(t, cli, out, ctx, "ads create -user-id 0 -title hello -text world")), &ad)
+	assert.NoError(t, err)
+	assert.Equal(t, "draft", ad["status"])
+	runAdctl(t, cli, out, ctx, "ads create -user-id 1 -title other -text ad")
+
+	runAdctl(t, cli, out, ctx, "ads publish 0 -user-id 0")
+	runAdctl(t, cli, out, ctx, "ads publish -user-id 1 1")
+	err = json.Unmarshal([]byte(runAdctl(t, cli, out, ctx, "ads update 0 -user-id 0 -title renamed -text world")), &ad)
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed", ad["title"])
+
+	var list []map[string]any
+	err = json.Unmarshal([]byte(runAdctl(t, cli, out, ctx, "ads list")), &list)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	err = json.Unmarshal([]byte(runAdctl(t, cli, out, ctx, "ads list -author-id 1")), &list)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "other", list[0]["title"])
+	err = json.Unmarshal([]byte(runAdctl(t, cli, out, ctx, "ads list -title RENAME")), &list)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	err = json.Unmarshal([]byte(runAdctl(t, cli, out, ctx, "ads get 1")), &ad)
+	assert.NoError(t, err)
+	assert.Equal(t, "published", ad["status"])
+
+	runAdctl(t, cli, out, ctx, "ads unpublish 1 -user-id 1")
+	err = cli.Run(ctx, []string{"ads", "get", "1"})
+	assert.Equal(t, codes.NotFound, status.Code(err), "unpublished ads are hidden")
+	err = json.Unmarshal([]byte(runAdctl(t, cli, out, ctx, "ads get 1 -user-id 1")), &ad)
+	assert.NoError(t, err)
+	assert.Equal(t, "archived", ad["status"], "the author finds unpublished ads")
+	err = cli.Run(ctx, []string{"ads", "get", "100"})
+	assert.Error(t, err)
+
+	err = cli.Run(ctx, []string{"ads", "delete", "0", "-user-id", "1"})
+	assert.Error(t, err)
+	assert.Equal(t, "ad 0 deleted\n", runAdctl(t, cli, out, ctx, "ads delete 0 -user-id 0"))
+}
+
+func TestAdctlOutputFormats(t *testing.T) {
+	cli, out, ctx := getAdctl(t)
+	runAdctl(t, cli, out, ctx, "users create -nickname oleg -email oleg@mail.ru")
+
+	cli.Output = "table"
+	table := runAdctl(t, cli, out, ctx, "users get 0")
+	assert.True(t, strings.HasPrefix(table, "ID  NICKNAME  EMAIL"))
+	assert.Contains(t, table, "oleg@mail.ru")
+
+	cli.Output = "yaml"
+	var user map[string]any
+	assert.NoError(t, yaml.Unmarshal([]byte(runAdctl(t, cli, out, ctx, "users get 0")), &user))
+	assert.Equal(t, "oleg", user["nickname"])
+	assert.Equal(t, "user", user["role"])
+
+	cli.Output = "xml"
+	assert.Error(t, cli.Run(ctx, []string{"users", "get", "0"}))
+}
+
+func TestAdctlUsage(t *testing.T) {
+	cli, _, ctx := getAdctl(t)
+	for _, args := range []string{"", "ads", "ads fly", "ads get", "ads get x", "ads create -title x", "ads delete 1", "users delete 1"} {
+		err := cli.Run(ctx, strings.Fields(args))
+		assert.ErrorIs(t, err, adctl.ErrUsage, args)
+	}
+}
+
+func TestAdctlConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "adctl.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("server: ads.example:50054\noutput: yaml\n"), 0o600))
+
+	cfg, err := adctl.LoadConfig(path, true)
+	assert.NoError(t, err)
+	assert.Equal(t, adctl.Config{Server: "ads.example:50054", Output: "yaml"}, cfg)
+
+	_, err = adctl.LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"), true)
+	assert.Error(t, err)
+	cfg, err = adctl.LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"), false)
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost:50054", cfg.Server)
+
+	t.Setenv("ADCTL_SERVER", "from-env:50054")
+	cfg, err = adctl.LoadConfig(path, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "from-env:50054", cfg.Server)
+}
+
+func TestAdctlUserIDMetadata(t *testing.T) {
+	var got []string
+	interceptor := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		got = md.Get("x-user-id")
+		return handler(ctx, req)
+	}
+	dial := serveGRPC(t, app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New()), grpc.UnaryInterceptor(interceptor))
+
+	ctx := context.Background()
+	conn, err := adctl.Dial(ctx, adctl.Config{Server: "bufnet"}, grpc.WithContextDialer(dial))
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	out := &bytes.Buffer{}
+	cli := adctl.CLI{Client: grpcPort.NewAdServiceClient(conn), Out: out, Output: "json"}
+	assert.NoError(t, cli.Run(ctx, strings.Fields("users create -nickname oleg -email oleg@mail.ru")))
+	assert.NoError(t, cli.Run(ctx, strings.Fields("ads create -user-id 0 -title hello -text world")))
+	assert.NoError(t, cli.Run(ctx, strings.Fields("ads get 0 -user-id 0")))
+	assert.Equal(t, []string{"0"}, got)
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/app"
+	grpcPort "homework9/internal/ports/grpc"
+)
+
+func TestAdTimestamps(t *testing.T) {
+	client := getRESTClient(t)
+	client.ok(http.MethodPost, "/users", `{"nickname":"nick","email":"mail"}`)
+	before := time.Now().UTC().Add(-time.Second)
+	client.as(0).ok(http.MethodPost, "/ads", `{"title":"hello","text":"world"}`)
+	client.as(0).ok(http.MethodPut, "/ads/0", `{"title":"hello","text":"new text"}`)
+
+	var ad map[string]any
+	require.NoError(t, json.Unmarshal(client.as(0).ok(http.MethodGet, "/ads/0", ""), &ad))
+	created, err := time.Parse(time.RFC3339, ad["date_create"].(string))
+	require.NoError(t, err)
+	updated, err := time.Parse(time.RFC3339, ad["date_update"].(string))
+	require.NoError(t, err)
+	assert.True(t, created.After(before))
+	assert.True(t, updated.After(created), "the update moved date_update")
+	assert.NotContains(t, ad, "author", "not expanded")
+}
+
+func TestAdExpandAndFields(t *testing.T) {
+	client := getRESTClient(t)
+	client.ok(http.MethodPost, "/users", `{"nickname":"alice","email":"alice@mail"}`)
+	client.ok(http.MethodPost, "/users", `{"nickname":"bob","email":"bob@mail"}`)
+	client.as(0).ok(http.MethodPost, "/ads", `{"title":"first","text":"text"}`)
+	client.as(1).ok(http.MethodPost, "/ads", `{"title":"second","text":"text"}`)
+	client.as(0).ok(http.MethodPut, "/ads/0/status", `{"published":true}`)
+	client.as(1).ok(http.MethodPut, "/ads/1/status", `{"published":true}`)
+
+	var ad map[string]any
+	require.NoError(t, json.Unmarshal(client.ok(http.MethodGet, "/ads/1?expand=author", ""), &ad))
+	assert.Equal(t, map[string]any{"user_id": float64(1), "nickname": "bob"}, ad["author"])
+
+	var list []map[string]any
+	require.NoError(t, json.Unmarshal(client.ok(http.MethodGet, "/ads?expand=author&fields=title,author", ""), &list))
+	require.Len(t, list, 2)
+	assert.Equal(t, map[string]any{
+		"ad_id":  float64(0),
+		"title":  "first",
+		"author": map[string]any{"user_id": float64(0), "nickname": "alice"},
+	}, list[0])
+
+	ad = nil
+	require.NoError(t, json.Unmarshal(client.ok(http.MethodGet, "/ads/title/second?fields=status&fields=date_create", ""), &ad))
+	assert.Len(t, ad, 3)
+	assert.Equal(t, "published", ad["status"])
+	assert.Contains(t, ad, "date_create")
+
+	client.as(1).ok(http.MethodDelete, "/users/1", "")
+	ad = nil
+	require.NoError(t, json.Unmarshal(client.ok(http.MethodGet, "/ads/1?expand=author", ""), &ad))
+	assert.NotContains(t, ad, "author", "the author is deleted")
+
+	for _, path := range []string{"/ads?fields=password", "/ads/0?expand=reports", "/moderation/ads?fields=nope"} {
+		resp, out := client.as(0).do(http.MethodGet, path, "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, path)
+		assert.NotNil(t, out.Error, path)
+	}
+}
+
+func TestGRPCAdExpandAndFields(t *testing.T) {
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New())
+	client, ctx := getGRPCTestClient(t, a)
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "nick", Email: "mail"})
+	require.NoError(t, err)
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: 0, Title: "hello", Text: "world"})
+	require.NoError(t, err)
+	_, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: 0, UserId: 0, Published: true})
+	require.NoError(t, err)
+
+	ad, err := client.GetAd(ctx, &grpcPort.GetAdRequest{AdId: 0})
+	require.NoError(t, err)
+	require.NotNil(t, ad.DateCreate)
+	assert.False(t, ad.DateUpdate.AsTime().Before(ad.DateCreate.AsTime()))
+	assert.Nil(t, ad.Author)
+
+	ad, err = client.GetAd(ctx, &grpcPort.GetAdRequest{AdId: 0, Expand: []string{"author"}, Fields: &fieldmaskpb.FieldMask{Paths: []string{"title", "author"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", ad.Title)
+	assert.Equal(t, "nick", ad.Author.GetNickname())
+	assert.Empty(t, ad.Text)
+	assert.Nil(t, ad.DateCreate)
+
+	list, err := client.ListAds(ctx, &grpcPort.ListAdsRequest{Fields: &fieldmaskpb.FieldMask{Paths: []string{"date_create"}}})
+	require.NoError(t, err)
+	require.Len(t, list.List, 1)
+	assert.NotNil(t, list.List[0].DateCreate)
+	assert.Empty(t, list.List[0].Title)
+
+	_, err = client.GetAd(ctx, &grpcPort.GetAdRequest{AdId: 0, Fields: &fieldmaskpb.FieldMask{Paths: []string{"password"}}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.ListAds(ctx, &grpcPort.ListAdsRequest{Expand: []string{"reports"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGatewayAdFields(t *testing.T) {
+	server := getGatewayServer(t)
+	gatewayCall(t, server, http.MethodPost, "/v1/users", `{"nickname":"nick","email":"mail"}`)
+	gatewayCall(t, server, http.MethodPost, "/v1/ads", `{"user_id":0,"title":"hello","text":"world"}`)
+	gatewayCall(t, server, http.MethodPut, "/v1/ads/0/status", `{"user_id":0,"published":true}`)
+
+	resp, ad := gatewayCall(t, server, http.MethodGet, "/v1/ads/0?expand=author&fields=title,author,date_create", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "hello", ad["title"])
+	assert.Equal(t, "nick", ad["author"].(map[string]any)["nickname"])
+	_, err := time.Parse(time.RFC3339, ad["date_create"].(string))
+	assert.NoError(t, err)
+}
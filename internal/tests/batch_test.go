@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"context"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchCreateAds(t *testing.T) {
+	client := getTestClient()
+	user, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+
+	code, resp, err := client.createAds(user.Data.ID, false, "first", "", "third")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, resp.Data, 3)
+	assert.Equal(t, http.StatusOK, resp.Data[0].Status)
+	assert.Equal(t, "first", resp.Data[0].Ad.Title)
+	assert.Equal(t, http.StatusBadRequest, resp.Data[1].Status)
+	assert.Nil(t, resp.Data[1].Ad)
+	assert.Equal(t, http.StatusOK, resp.Data[2].Status)
+
+	_, err = client.getAdByTitle("third")
+	assert.NoError(t, err)
+}
+
+func TestBatchCreateAdsAtomic(t *testing.T) {
+	client := getTestClient()
+	user, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+
+	code, resp, err := client.createAds(user.Data.ID, true, "first", "", "third")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.NotNil(t, resp.Error)
+	assert.Equal(t, http.StatusConflict, resp.Data[0].Status)
+	assert.Equal(t, http.StatusBadRequest, resp.Data[1].Status)
+	assert.Equal(t, http.StatusConflict, resp.Data[2].Status)
+
+	_, err = client.getAdByTitle("first")
+	assert.Error(t, err)
+}
+
+func TestBatchCreateAdsLimits(t *testing.T) {
+	client := getTestClient()
+	user, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+
+	code, _, err := client.createAds(user.Data.ID, false)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	admin, err := client.createAdmin("admin", "admin@mail.ru")
+	assert.NoError(t, err)
+	_, err = client.banUser(admin.Data.ID, user.Data.ID, true)
+	assert.NoError(t, err)
+	code, _, err = client.createAds(user.Data.ID, false, "first")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, code)
+}
+
+func TestBatchChangeAdsStatus(t *testing.T) {
+	client := getTestClient()
+	author, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+	other, err := client.createUser("other", "other@mail.ru")
+	assert.NoError(t, err)
+	_, _, err = client.createAds(author.Data.ID, false, "first", "second")
+	assert.NoError(t, err)
+	_, err = client.createAd(other.Data.ID, "foreign", "ad")
+	assert.NoError(t, err)
+
+	code, resp, err := client.changeAdsStatus(author.Data.ID, true, true, 0, 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, http.StatusForbidden, resp.Data[2].Status)
+	ads, err := client.getAds()
+	assert.NoError(t, err)
+	assert.Len(t, ads.Data, 0)
+
+	code, resp, err = client.changeAdsStatus(author.Data.ID, false, true, 0, 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, http.StatusOK, resp.Data[0].Status)
+	assert.True(t, resp.Data[0].Ad.Published)
+	assert.Equal(t, http.StatusForbidden, resp.Data[2].Status)
+	ads, err = client.getAds()
+	assert.NoError(t, err)
+	assert.Len(t, ads.Data, 2)
+}
+
+func TestBatchDeleteAds(t *testing.T) {
+	client := getTestClient()
+	author, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+	_, _, err = client.createAds(author.Data.ID, false, "first", "second")
+	assert.NoError(t, err)
+
+	code, resp, err := client.deleteAds(author.Data.ID, true, 0, 1, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, http.StatusConflict, resp.Data[0].Status)
+	_, err = client.getAdAs(author.Data.ID, 0)
+	assert.NoError(t, err)
+
+	code, resp, err = client.deleteAds(author.Data.ID, false, 0, 1, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, http.StatusOK, resp.Data[0].Status)
+	assert.Equal(t, http.StatusOK, resp.Data[1].Status)
+	assert.Equal(t, http.StatusInternalServerError, resp.Data[2].Status)
+	_, err = client.getAd(0)
+	assert.Error(t, err)
+}
+
+// failingAdRepo fails the status change of one ad, to see the batch undo
+// the ads changed before it.
+type failingAdRepo struct {
+	app.AdRepository
+	failID int64
+}
+
+func (r failingAdRepo) ChangeAdStatus(ctx context.Context, adID int64, Status ads.Status, Reason string) (ads.Ad, error) {
+	if adID == r.failID && Status == ads.StatusPendingReview {
+		return ads.Ad{}, context.DeadlineExceeded
+	}
+	return r.AdRepository.ChangeAdStatus(ctx, adID, Status, Reason)
+}
+
+func TestBatchChangeAdsStatusRollback(t *testing.T) {
+	ctx := context.Background()
+	a := app.NewApp(failingAdRepo{AdRepository: adrepo.New(), failID: 2}, userrepo.New(), messagerepo.New(), reportrepo.New())
+	user, err := a.CreateUser(ctx, "author", "author@mail.ru")
+	assert.NoError(t, err)
+	_, err = a.CreateAds(ctx, user.ID, []app.NewAd{{Title: "a", Text: "a"}, {Title: "b", Text: "b"}, {Title: "c", Text: "c"}}, true)
+	assert.NoError(t, err)
+
+	results, err := a.ChangeAdsStatus(ctx, []int64{0, 1, 2}, user.ID, true, true)
+	assert.ErrorIs(t, err, app.ErrBatchAborted)
+	assert.ErrorIs(t, results[0].Err, app.ErrBatchAborted)
+	assert.ErrorIs(t, results[2].Err, context.DeadlineExceeded)
+	for _, id := range []int64{0, 1, 2} {
+		ad, err := a.GetAd(ctx, id, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, ads.StatusDraft, ad.Status)
+		assert.True(t, ad.ExpiresAt.IsZero())
+	}
+}
+
+func TestBatchDuplicateIDs(t *testing.T) {
+	client := getTestClient()
+	author, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+	_, _, err = client.createAds(author.Data.ID, false, "first")
+	assert.NoError(t, err)
+
+	code, resp, err := client.changeAdsStatus(author.Data.ID, true, true, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, http.StatusBadRequest, resp.Data[1].Status)
+
+	code, resp, err = client.changeAdsStatus(author.Data.ID, false, true, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, http.StatusOK, resp.Data[0].Status)
+	assert.Equal(t, http.StatusBadRequest, resp.Data[1].Status)
+
+	code, resp, err = client.deleteAds(author.Data.ID, false, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, http.StatusOK, resp.Data[0].Status)
+	assert.Equal(t, http.StatusBadRequest, resp.Data[1].Status)
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type batchItemData struct {
+	Index  int     `json:"index"`
+	Status int     `json:"status"`
+	Error  string  `json:"error"`
+	Ad     *adData `json:"ad"`
+}
+
+type batchResponse struct {
+	Data  []batchItemData `json:"data"`
+	Error *string         `json:"error"`
+}
+
+// doBatch returns the HTTP status along with the results, batches answer
+// 409 with a body when an all-or-nothing batch is aborted.
+func (tc *testClient) doBatch(method string, path string, body map[string]any) (int, batchResponse, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return 0, batchResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+	req, err := http.NewRequest(method, tc.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return 0, batchResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	resp, err := tc.client.Do(req)
+	if err != nil {
+		return 0, batchResponse{}, fmt.Errorf("unexpected error: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, batchResponse{}, fmt.Errorf("unable to read response: %w", err)
+	}
+	var response batchResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return resp.StatusCode, batchResponse{}, fmt.Errorf("unable to unmarshal: %w", err)
+	}
+	return resp.StatusCode, response, nil
+}
+
+func (tc *testClient) createAds(userID int64, atomic bool, titles ...string) (int, batchResponse, error) {
+	items := make([]map[string]any, len(titles))
+	for i, title := range titles {
+		items[i] = map[string]any{"title": title, "text": "text of " + title}
+	}
+	return tc.doBatch(http.MethodPost, "/api/v1/ads/batch", map[string]any{
+		"user_id": userID,
+		"atomic":  atomic,
+		"ads":     items,
+	})
+}
+
+func (tc *testClient) changeAdsStatus(userID int64, atomic bool, published bool, adIDs ...int64) (int, batchResponse, error) {
+	return tc.doBatch(http.MethodPut, "/api/v1/ads/batch/status", map[string]any{
+		"user_id":   userID,
+		"atomic":    atomic,
+		"published": published,
+		"ad_ids":    adIDs,
+	})
+}
+
+func (tc *testClient) deleteAds(userID int64, atomic bool, adIDs ...int64) (int, batchResponse, error) {
+	return tc.doBatch(http.MethodDelete, "/api/v1/ads/batch", map[string]any{
+		"user_id": userID,
+		"atomic":  atomic,
+		"ad_ids":  adIDs,
+	})
+}
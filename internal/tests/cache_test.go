@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"homework9/internal/adapters/adcache"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/metrics"
+	"homework9/middleware"
+)
+
+// countingAdRepo counts the reads that reach the repository.
+type countingAdRepo struct {
+	app.AdRepository
+	getAd  int
+	getAds int
+}
+
+func (r *countingAdRepo) GetAd(ctx context.Context, index int64) (ads.Ad, error) {
+	r.getAd++
+	return r.AdRepository.GetAd(ctx, index)
+}
+
+func (r *countingAdRepo) GetAds(ctx context.Context) ([]ads.Ad, error) {
+	r.getAds++
+	return r.AdRepository.GetAds(ctx)
+}
+
+func TestAdCache(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	repo := &countingAdRepo{AdRepository: adrepo.New()}
+	cache := adcache.New(repo, 3, time.Minute, adcache.WithClock(clock.Now))
+	for i := 0; i < 3; i++ {
+		ad, err := cache.CreateAd(ctx, "title", "text", 0)
+		require.NoError(t, err)
+		_, err = cache.ChangeAdStatus(ctx, ad.ID, ads.StatusPublished, "")
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		ad, err := cache.GetAd(ctx, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "title", ad.Title)
+	}
+	assert.Equal(t, 1, repo.getAd, "the second read is a hit")
+
+	_, err := cache.UpdateAd(ctx, 0, "new title", "text")
+	require.NoError(t, err)
+	ad, err := cache.GetAd(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "new title", ad.Title, "UpdateAd drops the ad")
+	assert.Equal(t, 2, repo.getAd)
+
+	list, err := cache.GetAds(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	list[0].Title = "changed by the caller"
+	list, err = cache.GetAds(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "new title", list[0].Title, "callers get copies")
+	assert.Equal(t, 1, repo.getAds)
+
+	_, err = cache.ChangeAdStatus(ctx, 1, ads.StatusArchived, "")
+	require.NoError(t, err)
+	list, err = cache.GetAds(ctx)
+	require.NoError(t, err)
+	assert.Len(t, list, 2, "ChangeAdStatus drops the listing")
+	assert.Equal(t, 2, repo.getAds)
+
+	_, err = cache.GetAd(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, repo.getAd, "the listing of 3 ads pushed the ad out")
+
+	require.NoError(t, cache.DeleteAd(ctx, 0))
+	_, err = cache.GetAd(ctx, 0)
+	assert.ErrorIs(t, err, app.ErrNotFound, "DeleteAd drops the ad")
+
+	_, err = cache.GetAd(ctx, 2)
+	require.NoError(t, err)
+	clock.Advance(time.Minute)
+	_, err = cache.GetAd(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 6, repo.getAd, "the entry expired")
+
+	m := metrics.New()
+	m.MustRegister(cache)
+	body := scrape(t, m.Handler())
+	for _, line := range []string{
+		`ad_cache_requests_total{kind="ad",result="hit"} 1`,
+		`ad_cache_requests_total{kind="ad",result="miss"} 6`,
+		`ad_cache_requests_total{kind="published",result="hit"} 1`,
+		`ad_cache_requests_total{kind="published",result="miss"} 2`,
+		`ad_cache_evictions_total 1`,
+	} {
+		assert.Contains(t, body, line)
+	}
+}
+
+func getWithETag(t *testing.T, client *restClient, path string, etag string) (*http.Response, string) {
+	req, err := http.NewRequest(http.MethodGet, client.server.URL+path, nil)
+	require.NoError(t, err)
+	req.Header.Set(middleware.UserIDHeader, "0")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := client.server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestHTTPETag(t *testing.T) {
+	client := getRESTClient(t)
+	client.ok(http.MethodPost, "/users", `{"nickname":"nick","email":"mail"}`)
+	client.as(0).ok(http.MethodPost, "/ads", `{"title":"hello","text":"world"}`)
+	client.as(0).ok(http.MethodPut, "/ads/0/status", `{"published":true}`)
+
+	for _, path := range []string{"/api/v1/ads/0", "/api/v1/ads/title/hello", "/api/v1/ads", "/api/v2/ads/0", "/api/v2/ads"} {
+		resp, body := getWithETag(t, client, path, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode, path)
+		etag := resp.Header.Get("ETag")
+		require.NotEmpty(t, etag, path)
+		assert.Equal(t, "private, no-cache", resp.Header.Get("Cache-Control"), path)
+		assert.NotEmpty(t, body, path)
+
+		resp, body = getWithETag(t, client, path, `"other", `+etag)
+		assert.Equal(t, http.StatusNotModified, resp.StatusCode, path)
+		assert.Equal(t, etag, resp.Header.Get("ETag"), path)
+		assert.Empty(t, body, path)
+	}
+
+	resp, _ := getWithETag(t, client, "/api/v1/ads/0", "")
+	etag := resp.Header.Get("ETag")
+	client.as(0).ok(http.MethodPut, "/ads/0", `{"title":"hello","text":"new text"}`)
+	resp, body := getWithETag(t, client, "/api/v1/ads/0", etag)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "the ad changed")
+	assert.True(t, strings.Contains(body, "new text"))
+	assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+
+	resp, _ = getWithETag(t, client, "/api/v1/ads/7", "")
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("ETag"), "errors are not tagged")
+}
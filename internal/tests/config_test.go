@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"homework9/internal/config"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(key string) string {
+		return vars[key]
+	}
+}
+
+func writeConfig(t *testing.T, name string, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	return path
+}
+
+func TestConfigDefaults(t *testing.T) {
+	cfg, opts, err := config.Load(nil, env(nil))
+	assert.NoError(t, err)
+	assert.False(t, opts.PrintConfig)
+	assert.Equal(t, config.Default(), cfg)
+	assert.Equal(t, ":9000", cfg.HTTP.Addr)
+	assert.Equal(t, ":50054", cfg.GRPC.Addr)
+	assert.Equal(t, 30*time.Second, cfg.ShutdownTimeout.Duration)
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	path := writeConfig(t, "server.yaml", `
+http:
+  addr: ":8000"
+grpc:
+  addr: ":8001"
+log:
+  level: warn
+shutdown_timeout: 5s
+`)
+	cfg, _, err := config.Load(
+		[]string{"-config", path, "-grpc.addr", ":8003"},
+		env(map[string]string{"AD_GRPC_ADDR": ":8002", "AD_LOG_LEVEL": "debug"}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, ":8000", cfg.HTTP.Addr)
+	assert.Equal(t, ":8003", cfg.GRPC.Addr)
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, 5*time.Second, cfg.ShutdownTimeout.Duration)
+}
+
+func TestConfigTOML(t *testing.T) {
+	path := writeConfig(t, "server.toml", `
+admin_email = "admin@mail.ru"
+
+[rate_limit]
+enabled = true
+rps = 2.5
+burst = 5
+
+[rate_limit.routes."POST /api/v1/ads"]
+rps = 0.5
+burst = 1
+
+[moderation]
+premoderation = false
+`)
+	cfg, _, err := config.Load(nil, env(map[string]string{"AD_CONFIG": path}))
+	assert.NoError(t, err)
+	assert.Equal(t, "admin@mail.ru", cfg.AdminEmail)
+	assert.True(t, cfg.RateLimit.Enabled)
+	assert.Equal(t, 2.5, cfg.RateLimit.RPS)
+	assert.Equal(t, config.Limit{RPS: 0.5, Burst: 1}, cfg.RateLimit.Routes["POST /api/v1/ads"])
+	assert.False(t, cfg.Moderation.Premoderation)
+}
+
+func TestConfigLegacyEnv(t *testing.T) {
+	cfg, _, err := config.Load(nil, env(map[string]string{"ADMIN_EMAIL": "old@mail.ru", "BANNED_WORDS_FILE": "words.txt"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "old@mail.ru", cfg.AdminEmail)
+	assert.Equal(t, "words.txt", cfg.Moderation.BannedWordsFile)
+
+	cfg, _, err = config.Load(nil, env(map[string]string{"ADMIN_EMAIL": "old@mail.ru", "AD_ADMIN_EMAIL": "new@mail.ru"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "new@mail.ru", cfg.AdminEmail)
+}
+
+func TestConfigErrors(t *testing.T) {
+	cases := map[string]struct {
+		args []string
+		env  map[string]string
+		file string
+	}{
+		"bad flag":           {args: []string{"-no-such-flag"}},
+		"bad address":        {args: []string{"-http.addr", "9000"}},
+		"bad duration":       {env: map[string]string{"AD_SHUTDOWN_TIMEOUT": "soon"}},
+		"zero shutdown":      {args: []string{"-shutdown-timeout", "0s"}},
+		"unknown backend":    {args: []string{"-storage.backend", "postgres"}},
+		"unknown log level":  {args: []string{"-log.level", "loud"}},
+		"unknown exporter":   {args: []string{"-tracing.exporter", "jaeger"}},
+		"file exporter":      {args: []string{"-tracing.exporter", "file"}},
+		"cert without key":   {args: []string{"-tls.cert-file", "cert.pem"}},
+		"ca without cert":    {args: []string{"-tls.client-ca-file", "ca.pem"}},
+		"ca without clients": {args: []string{"-tls.cert-file", "cert.pem", "-tls.key-file", "key.pem", "-tls.client-ca-file", "ca.pem"}},
+		"bad rate limit":     {args: []string{"-rate-limit.enabled", "-rate-limit.rps", "0"}},
+		"unknown yaml key":   {file: "server.yaml"},
+		"missing file":       {args: []string{"-config", "/no/such/file.yaml"}},
+		"positional args":    {args: []string{"serve"}},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			args := c.args
+			if c.file != "" {
+				args = append(args, "-config", writeConfig(t, c.file, "htp:\n  addr: \":1\"\n"))
+			}
+			_, _, err := config.Load(args, env(c.env))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestConfigPrint(t *testing.T) {
+	cfg, opts, err := config.Load([]string{"-print-config", "-http.addr", ":7000"}, env(nil))
+	assert.NoError(t, err)
+	assert.True(t, opts.PrintConfig)
+
+	var buf bytes.Buffer
+	assert.NoError(t, cfg.Print(&buf))
+	assert.Contains(t, buf.String(), "addr: :7000")
+	assert.Contains(t, buf.String(), "shutdown_timeout: 30s")
+
+	path := writeConfig(t, "printed.yaml", buf.String())
+	again, _, err := config.Load([]string{"-config", path}, env(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, cfg, again)
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/app"
+	"homework9/internal/contentfilter"
+	grpcPort "homework9/internal/ports/grpc"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestContentFilterRejections(t *testing.T) {
+	client := getTestClient(app.WithContentFilters(contentfilter.Default()...))
+
+	tests := []struct {
+		name  string
+		title string
+		text  string
+		code  string
+	}{
+		{"banned english word", "Casino chips", "cheap", "banned_word"},
+		{"banned russian word", "Продам", "Настоящее КАЗИНО дома", "banned_word"},
+		{"link", "Bike", "details at https://example.com/bike", "contact_info"},
+		{"domain", "Bike", "details at bikes.ru", "contact_info"},
+		{"phone", "Bike", "call +7 (912) 345-67-89", "contact_info"},
+		{"caps", "SELLING MY BIKE TODAY", "good bike", "excessive_caps"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := json.Marshal(map[string]any{"user_id": 1, "title": tc.title, "text": tc.text})
+			assert.NoError(t, err)
+			resp, err := client.client.Post(client.baseURL+"/api/v1/ads", "application/json", bytes.NewReader(data))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+			var body errorResponse
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.NotEmpty(t, body.Reasons)
+			assert.Equal(t, tc.code, body.Reasons[0].Code)
+		})
+	}
+}
+
+func TestContentFilterDuplicate(t *testing.T) {
+	client := getTestClient(app.WithContentFilters(contentfilter.Default()...))
+
+	_, err := client.createAd(0, "Bike", "Almost new bike")
+	assert.NoError(t, err)
+	_, err = client.createAd(0, "bike", "almost  new bike")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.createAd(1, "Bike", "Almost new bike")
+	assert.NoError(t, err, "other authors may post the same text")
+
+	_, err = client.updateAd(0, 0, "Bike", "Almost new bike, price lowered")
+	assert.NoError(t, err, "updating an ad is not a duplicate of itself")
+}
+
+func TestContentFilterWordsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("# local list\nкотик\n"), 0o600))
+	words, err := contentfilter.LoadBannedWords(path)
+	assert.NoError(t, err)
+
+	client := getTestClient(app.WithContentFilters(words))
+	_, err = client.createAd(0, "Продам котика", "котик")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.createAd(0, "Casino", "allowed by the local list")
+	assert.NoError(t, err)
+
+	_, err = contentfilter.LoadBannedWords(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestGRRPCContentFilter(t *testing.T) {
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New(), app.WithContentFilters(contentfilter.Default()...))
+	client, ctx := getGRPCTestClient(t, a)
+
+	user, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@mail.ru"})
+	assert.NoError(t, err, "client.CreateUser")
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: user.Id, Title: "Bike", Text: "write to bike@mail.ru or www.bikes.com"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, d := range status.Convert(err).Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			violations = br.FieldViolations
+		}
+	}
+	assert.NotEmpty(t, violations)
+	assert.Equal(t, "text", violations[0].Field)
+	assert.True(t, strings.HasPrefix(violations[0].Description, "contact_info"))
+}
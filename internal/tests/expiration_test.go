@@ -0,0 +1,223 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/scheduler"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a clock the tests move by hand.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type recordingNotifier struct {
+	mu  sync.Mutex
+	ads []int64
+}
+
+func (n *recordingNotifier) NotifyExpiringSoon(_ context.Context, ad ads.Ad) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.ads = append(n.ads, ad.ID)
+	return nil
+}
+
+// failingNotifier fails for one ad and records the others.
+type failingNotifier struct {
+	recordingNotifier
+	failFor int64
+}
+
+var errNotifyFailed = errors.New("mail server is down")
+
+func (n *failingNotifier) NotifyExpiringSoon(ctx context.Context, ad ads.Ad) error {
+	if ad.ID == n.failFor {
+		return errNotifyFailed
+	}
+	return n.recordingNotifier.NotifyExpiringSoon(ctx, ad)
+}
+
+func newExpiringApp(clock *fakeClock, opts ...app.Option) app.App {
+	opts = append([]app.Option{app.WithClock(clock.Now), app.WithAdTTL(10 * 24 * time.Hour)}, opts...)
+	return app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New(), opts...)
+}
+
+func TestExpiresAtSetOnPublish(t *testing.T) {
+	client := getTestClient()
+	user, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+	ad, err := client.createAd(user.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+	assert.Nil(t, ad.Data.ExpiresAt)
+
+	ad, err = client.changeAdStatus(user.Data.ID, 0, true)
+	assert.NoError(t, err)
+	assert.NotNil(t, ad.Data.ExpiresAt)
+	assert.True(t, ad.Data.ExpiresAt.After(time.Now()))
+}
+
+func TestExpireAds(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	notifier := &recordingNotifier{}
+	a := newExpiringApp(clock, app.WithExpiryNotifier(notifier, 2*24*time.Hour))
+	user, err := a.CreateUser(ctx, "author", "author@mail.ru")
+	assert.NoError(t, err)
+	ad, err := a.CreateAd(ctx, "hello", "world", user.ID)
+	assert.NoError(t, err)
+	ad, err = a.ChangeAdStatus(ctx, ad.ID, user.ID, true)
+	assert.NoError(t, err)
+	assert.Equal(t, clock.Now().Add(10*24*time.Hour), ad.ExpiresAt)
+
+	clock.Advance(5 * 24 * time.Hour)
+	assert.NoError(t, a.ExpireAds(ctx))
+	assert.Empty(t, notifier.ads)
+
+	clock.Advance(4 * 24 * time.Hour)
+	assert.NoError(t, a.ExpireAds(ctx))
+	assert.NoError(t, a.ExpireAds(ctx))
+	assert.Equal(t, []int64{ad.ID}, notifier.ads)
+
+	clock.Advance(2 * 24 * time.Hour)
+	list, err := a.GetAds(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, list, 0)
+
+	assert.NoError(t, a.ExpireAds(ctx))
+	ad, err = a.GetAd(ctx, ad.ID, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ads.StatusArchived, ad.Status)
+}
+
+func TestExpireAdsSkipsFailedAds(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	notifier := &failingNotifier{failFor: 0}
+	a := newExpiringApp(clock, app.WithExpiryNotifier(notifier, 2*24*time.Hour))
+	user, err := a.CreateUser(ctx, "author", "author@mail.ru")
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		ad, err := a.CreateAd(ctx, "hello", "world", user.ID)
+		assert.NoError(t, err)
+		_, err = a.ChangeAdStatus(ctx, ad.ID, user.ID, true)
+		assert.NoError(t, err)
+	}
+
+	clock.Advance(9 * 24 * time.Hour)
+	err = a.ExpireAds(ctx)
+	assert.ErrorIs(t, err, errNotifyFailed)
+	assert.Equal(t, []int64{1}, notifier.ads, "the second ad is notified anyway")
+
+	notifier.failFor = -1
+	assert.NoError(t, a.ExpireAds(ctx))
+	assert.Equal(t, []int64{1, 0}, notifier.ads, "the failed ad is retried")
+}
+
+func TestRenewAd(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	a := newExpiringApp(clock)
+	user, err := a.CreateUser(ctx, "author", "author@mail.ru")
+	assert.NoError(t, err)
+	other, err := a.CreateUser(ctx, "other", "other@mail.ru")
+	assert.NoError(t, err)
+	ad, err := a.CreateAd(ctx, "hello", "world", user.ID)
+	assert.NoError(t, err)
+
+	_, err = a.RenewAd(ctx, ad.ID, user.ID)
+	assert.ErrorIs(t, err, app.ErrInvalidTransition)
+
+	_, err = a.ChangeAdStatus(ctx, ad.ID, user.ID, true)
+	assert.NoError(t, err)
+	clock.Advance(8 * 24 * time.Hour)
+
+	_, err = a.RenewAd(ctx, ad.ID, other.ID)
+	assert.ErrorIs(t, err, app.ErrWrongUser)
+	ad, err = a.RenewAd(ctx, ad.ID, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, clock.Now().Add(10*24*time.Hour), ad.ExpiresAt)
+
+	clock.Advance(11 * 24 * time.Hour)
+	assert.NoError(t, a.ExpireAds(ctx))
+	ad, err = a.RenewAd(ctx, ad.ID, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ads.StatusPublished, ad.Status)
+	assert.Equal(t, clock.Now().Add(10*24*time.Hour), ad.ExpiresAt)
+}
+
+func TestRenewAdHTTP(t *testing.T) {
+	client := getTestClient()
+	user, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+	other, err := client.createUser("other", "other@mail.ru")
+	assert.NoError(t, err)
+	_, err = client.createAd(user.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.renewAd(user.Data.ID, 0)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	_, err = client.changeAdStatus(user.Data.ID, 0, true)
+	assert.NoError(t, err)
+	_, err = client.renewAd(other.Data.ID, 0)
+	assert.ErrorIs(t, err, ErrForbidden)
+	ad, err := client.renewAd(user.Data.ID, 0)
+	assert.NoError(t, err)
+	assert.NotNil(t, ad.Data.ExpiresAt)
+}
+
+func TestSchedulerStopsOnCancel(t *testing.T) {
+	var runs atomic.Int32
+	s := scheduler.New(scheduler.Job{
+		Name:     "count",
+		Interval: time.Millisecond,
+		Run: func(ctx context.Context) error {
+			runs.Add(1)
+			return errors.New("keeps going")
+		},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("scheduler did not stop")
+	}
+}
@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/app"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+	"homework9/middleware"
+)
+
+// getGatewayServer serves the app with the gRPC gateway next to /api/v1.
+func getGatewayServer(t *testing.T, opts ...app.Option) *httptest.Server {
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New(), opts...)
+	gateway, err := grpcPort.NewGateway(context.Background(), grpcPort.NewService(a))
+	require.NoError(t, err)
+	server := httpgin.NewHTTPServer(":18080", a, httpgin.WithGateway(gateway))
+	testServer := httptest.NewServer(server.Handler())
+	t.Cleanup(testServer.Close)
+	return testServer
+}
+
+func gatewayCall(t *testing.T, server *httptest.Server, method string, path string, body string) (*http.Response, map[string]any) {
+	return gatewayCallAs(t, server, "", method, path, body)
+}
+
+// gatewayCallAs is gatewayCall with the X-User-ID header, unless userID is
+// empty.
+func gatewayCallAs(t *testing.T, server *httptest.Server, userID string, method string, path string, body string) (*http.Response, map[string]any) {
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if userID != "" {
+		req.Header.Set(middleware.UserIDHeader, userID)
+	}
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var out map[string]any
+	require.NoError(t, json.Unmarshal(data, &out), string(data))
+	return resp, out
+}
+
+func TestGateway(t *testing.T) {
+	server := getGatewayServer(t)
+
+	resp, user := gatewayCall(t, server, http.MethodPost, "/v1/users", `{"nickname":"nick","email":"mail"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "nick", user["nickname"])
+
+	resp, ad := gatewayCall(t, server, http.MethodPost, "/v1/ads", `{"user_id":0,"title":"hello","text":"world"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "hello", ad["title"])
+	assert.Equal(t, false, ad["published"], "unpopulated fields are sent")
+
+	resp, _ = gatewayCall(t, server, http.MethodPut, "/v1/ads/0/status", `{"user_id":0,"published":true}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, ad = gatewayCall(t, server, http.MethodGet, "/v1/ads/0", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "published", ad["status"])
+
+	resp, _ = gatewayCall(t, server, http.MethodGet, "/v1/ads/99", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, list := gatewayCall(t, server, http.MethodGet, "/v1/ads", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, list["list"], 1)
+
+	resp, batch := gatewayCall(t, server, http.MethodPost, "/v1/ads:batchCreate", `{"user_id":0,"ads":[{"title":"one","text":"1"},{"title":"","text":"2"}]}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	results := batch["results"].([]any)
+	require.Len(t, results, 2)
+	assert.Equal(t, "InvalidArgument", results[1].(map[string]any)["code"])
+
+	resp, _ = gatewayCall(t, server, http.MethodDelete, "/v1/ads/0?author_id=0", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestGatewayErrors(t *testing.T) {
+	server := getGatewayServer(t, app.WithDailyAdQuota(1))
+	resp, _ := gatewayCall(t, server, http.MethodPost, "/v1/users", `{"nickname":"nick","email":"mail"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, body := gatewayCall(t, server, http.MethodPost, "/v1/ads", `{"user_id":0,"title":"","text":"world"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.EqualValues(t, 3, body["code"], "the status of the RPC")
+
+	resp, _ = gatewayCall(t, server, http.MethodPut, "/v1/ads/0/status", `{"user_id":5,"published":true}`)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, _ = gatewayCall(t, server, http.MethodPost, "/v1/ads", `{"user_id":0,"title":"hello","text":"world"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = gatewayCall(t, server, http.MethodPost, "/v1/ads", `{"user_id":0,"title":"hello","text":"world"}`)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+
+	resp, _ = gatewayCall(t, server, http.MethodGet, "/v1/nothing", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestGatewayActor(t *testing.T) {
+	server := getGatewayServer(t)
+	for _, nick := range []string{"author", "other"} {
+		resp, _ := gatewayCall(t, server, http.MethodPost, "/v1/users", `{"nickname":"`+nick+`","email":"`+nick+`@mail.ru"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	resp, ad := gatewayCallAs(t, server, "1", http.MethodPost, "/v1/ads", `{"title":"hello","text":"world"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, "1", ad["author_id"], "the header names the actor when the body does not")
+
+	resp, _ = gatewayCallAs(t, server, "0", http.MethodPut, "/v1/ads/0/status", `{"user_id":1,"published":true}`)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "the body may not name another user")
+	resp, _ = gatewayCallAs(t, server, "1", http.MethodPut, "/v1/ads/0/status", `{"user_id":1,"published":true}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestGRPCGetAd(t *testing.T) {
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New())
+	client, ctx := getGRPCTestClient(t, a)
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "nick", Email: "mail"})
+	require.NoError(t, err)
+	created, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: 0, Title: "hello", Text: "world"})
+	require.NoError(t, err)
+
+	_, err = client.GetAd(ctx, &grpcPort.GetAdRequest{AdId: created.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err), "drafts are hidden from the others")
+	asAuthor := metadata.AppendToOutgoingContext(ctx, middleware.UserIDMetadata, "0")
+	ad, err := client.GetAd(asAuthor, &grpcPort.GetAdRequest{AdId: created.Id})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", ad.Title)
+	assert.False(t, ad.Published)
+
+	_, err = client.GetAd(ctx, &grpcPort.GetAdRequest{AdId: 100})
+	assert.Error(t, err)
+}
@@ -2,7 +2,6 @@ package tests
 
 import (
 	"context"
-	"google.golang.org/protobuf/types/known/emptypb"
 	"homework9/internal/adapters/userrepo"
 	"net"
 	"testing"
@@ -10,10 +9,16 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
 	"homework9/internal/app"
 	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/users"
 )
 
 func TestGRRPCCreateUser(t *testing.T) {
@@ -27,7 +32,7 @@ func TestGRRPCCreateUser(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New()))
+	svc := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -68,7 +73,7 @@ func TestGRRPCGetUser(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New()))
+	svc := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -111,7 +116,7 @@ func TestGRRPCDeleteUser(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New()))
+	svc := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -140,7 +145,11 @@ func TestGRRPCDeleteUser(t *testing.T) {
 	res, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: res.Id})
 	assert.NoError(t, err, "client.GetUser")
 	assert.Equal(t, "Oleg", res.Nickname)
-	_, err = client.DeleteUser(ctx, &grpcPort.DeleteUserRequest{Id: res.Id})
+	other, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Ivan", Email: "ivan@mail.ru"})
+	assert.NoError(t, err, "client.CreateUser")
+	_, err = client.DeleteUser(ctx, &grpcPort.DeleteUserRequest{Id: res.Id, ActorId: other.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.DeleteUser(ctx, &grpcPort.DeleteUserRequest{Id: res.Id, ActorId: res.Id})
 	assert.NoError(t, err, "client.DeleteUser")
 	_, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: res.Id})
 	assert.Error(t, err, "client.GetUser")
@@ -157,7 +166,7 @@ func TestGRRPCCreateAd(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New()))
+	svc := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -200,7 +209,7 @@ func TestGRRPCChangeAdStatus(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New()))
+	svc := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -248,7 +257,7 @@ func TestGRRPCUpdateAd(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New()))
+	svc := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -296,7 +305,7 @@ func TestGRRPCListAds(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New()))
+	svc := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -323,7 +332,7 @@ func TestGRRPCListAds(t *testing.T) {
 	res, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "alncalknd"})
 	assert.NoError(t, err, "client.CreateUser")
 
-	resList, err := client.ListAds(ctx, &emptypb.Empty{})
+	resList, err := client.ListAds(ctx, &grpcPort.ListAdsRequest{})
 	assert.NoError(t, err, "client.ListAd")
 	assert.Len(t, resList.List, 0)
 
@@ -338,13 +347,255 @@ func TestGRRPCListAds(t *testing.T) {
 	_, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{UserId: res.Id, Published: true, AdId: resAd.Id})
 	assert.NoError(t, err, "client.ChangeAdStatus")
 
-	resList, err = client.ListAds(ctx, &emptypb.Empty{})
+	resList, err = client.ListAds(ctx, &grpcPort.ListAdsRequest{})
 	assert.NoError(t, err, "client.ListAd")
 	assert.Len(t, resList.List, 2)
 
 	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: res.Id, Title: "hello3", Text: "world3"})
 	assert.NoError(t, err, "client.CreateAd")
-	resList, err = client.ListAds(ctx, &emptypb.Empty{})
+	resList, err = client.ListAds(ctx, &grpcPort.ListAdsRequest{})
 	assert.NoError(t, err, "client.ListAd")
 	assert.Len(t, resList.List, 2)
 }
+
+func TestGRRPCMessages(t *testing.T) {
+	client, ctx := getGRPCTestClient(t, app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New()))
+	seller, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@mail.ru"})
+	assert.NoError(t, err, "client.CreateUser")
+	buyer, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Ivan", Email: "ivan@mail.ru"})
+	assert.NoError(t, err, "client.CreateUser")
+	resAd, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: seller.Id, Title: "hello", Text: "world"})
+	assert.NoError(t, err, "client.CreateAd")
+	_, err = client.SendMessageToAd(ctx, &grpcPort.SendMessageToAdRequest{AdId: resAd.Id, UserId: buyer.Id, Text: "hi"})
+	assert.Equal(t, codes.NotFound, status.Code(err), "drafts take no messages")
+	_, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: resAd.Id, UserId: seller.Id, Published: true})
+	assert.NoError(t, err, "client.ChangeAdStatus")
+
+	msg, err := client.SendMessageToAd(ctx, &grpcPort.SendMessageToAdRequest{AdId: resAd.Id, UserId: buyer.Id, Text: "hi"})
+	assert.NoError(t, err, "client.SendMessageToAd")
+	assert.Equal(t, buyer.Id, msg.SenderId)
+
+	_, err = client.SendMessage(ctx, &grpcPort.SendMessageRequest{ConversationId: msg.ConversationId, UserId: seller.Id, Text: "hello"})
+	assert.NoError(t, err, "client.SendMessage")
+
+	resList, err := client.ListMessages(ctx, &grpcPort.ListMessagesRequest{ConversationId: msg.ConversationId, UserId: seller.Id})
+	assert.NoError(t, err, "client.ListMessages")
+	assert.Len(t, resList.List, 2)
+
+	inbox, err := client.ListInbox(ctx, &grpcPort.ListInboxRequest{UserId: buyer.Id})
+	assert.NoError(t, err, "client.ListInbox")
+	assert.Len(t, inbox.List, 1)
+
+	_, err = client.ListMessages(ctx, &grpcPort.ListMessagesRequest{ConversationId: 99, UserId: seller.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.ListAdConversations(ctx, &grpcPort.ListAdConversationsRequest{AdId: resAd.Id, UserId: buyer.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	convs, err := client.ListAdConversations(ctx, &grpcPort.ListAdConversationsRequest{AdId: resAd.Id, UserId: seller.Id})
+	assert.NoError(t, err, "client.ListAdConversations")
+	assert.Len(t, convs.List, 1)
+}
+
+func TestGRRPCRoles(t *testing.T) {
+	userRepo := userrepo.New()
+	client, ctx := getGRPCTestClient(t, app.NewApp(adrepo.New(), userRepo, messagerepo.New(), reportrepo.New()))
+	admin, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Admin", Email: "admin@mail.ru"})
+	assert.NoError(t, err, "client.CreateUser")
+	_, err = userRepo.SetUserRole(ctx, admin.Id, users.RoleAdmin)
+	assert.NoError(t, err, "userRepo.SetUserRole")
+	user, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@mail.ru"})
+	assert.NoError(t, err, "client.CreateUser")
+	assert.Equal(t, "user", user.Role)
+
+	_, err = client.SetUserRole(ctx, &grpcPort.SetUserRoleRequest{AdminId: user.Id, UserId: user.Id, Role: "admin"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	resUser, err := client.SetUserRole(ctx, &grpcPort.SetUserRoleRequest{AdminId: admin.Id, UserId: user.Id, Role: "moderator"})
+	assert.NoError(t, err, "client.SetUserRole")
+	assert.Equal(t, "moderator", resUser.Role)
+
+	resAd, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: user.Id, Title: "hello", Text: "world"})
+	assert.NoError(t, err, "client.CreateAd")
+
+	resUser, err = client.BanUser(ctx, &grpcPort.BanUserRequest{AdminId: admin.Id, UserId: user.Id, Banned: true})
+	assert.NoError(t, err, "client.BanUser")
+	assert.True(t, resUser.Banned)
+
+	_, err = client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{UserId: user.Id, AdId: resAd.Id, Title: "привет", Text: "мир"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.DeleteAd(ctx, &grpcPort.DeleteAdRequest{AdId: resAd.Id, AuthorId: admin.Id})
+	assert.NoError(t, err, "client.DeleteAd")
+}
+
+func TestGRRPCModeration(t *testing.T) {
+	userRepo := userrepo.New()
+	client, ctx := getGRPCTestClient(t, app.NewApp(adrepo.New(), userRepo, messagerepo.New(), reportrepo.New(), app.WithPremoderation()))
+
+	moderator, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Moderator", Email: "moderator@mail.ru"})
+	assert.NoError(t, err, "client.CreateUser")
+	_, err = userRepo.SetUserRole(ctx, moderator.Id, users.RoleModerator)
+	assert.NoError(t, err, "userRepo.SetUserRole")
+	user, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@mail.ru"})
+	assert.NoError(t, err, "client.CreateUser")
+
+	first, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: user.Id, Title: "hello", Text: "world"})
+	assert.NoError(t, err, "client.CreateAd")
+	second, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: user.Id, Title: "hello2", Text: "world2"})
+	assert.NoError(t, err, "client.CreateAd")
+	for _, id := range []int64{first.Id, second.Id} {
+		resAd, err := client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{UserId: user.Id, Published: true, AdId: id})
+		assert.NoError(t, err, "client.ChangeAdStatus")
+		assert.Equal(t, "pending_review", resAd.Status)
+	}
+
+	_, err = client.ListModerationQueue(ctx, &grpcPort.ModerationQueueRequest{ModeratorId: user.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	queue, err := client.ListModerationQueue(ctx, &grpcPort.ModerationQueueRequest{ModeratorId: moderator.Id})
+	assert.NoError(t, err, "client.ListModerationQueue")
+	assert.Len(t, queue.List, 2)
+
+	resAd, err := client.ApproveAd(ctx, &grpcPort.ApproveAdRequest{AdId: first.Id, ModeratorId: moderator.Id})
+	assert.NoError(t, err, "client.ApproveAd")
+	assert.True(t, resAd.Published)
+
+	resAd, err = client.RejectAd(ctx, &grpcPort.RejectAdRequest{AdId: second.Id, ModeratorId: moderator.Id, Reason: "duplicate"})
+	assert.NoError(t, err, "client.RejectAd")
+	assert.Equal(t, "rejected", resAd.Status)
+	assert.Equal(t, "duplicate", resAd.RejectReason)
+
+	_, err = client.RejectAd(ctx, &grpcPort.RejectAdRequest{AdId: first.Id, ModeratorId: moderator.Id, Reason: "too late"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	resList, err := client.ListAds(ctx, &grpcPort.ListAdsRequest{})
+	assert.NoError(t, err, "client.ListAds")
+	assert.Len(t, resList.List, 1)
+}
+
+func TestGRRPCReports(t *testing.T) {
+	userRepo := userrepo.New()
+	client, ctx := getGRPCTestClient(t, app.NewApp(adrepo.New(), userRepo, messagerepo.New(), reportrepo.New(), app.WithReportThreshold(1)))
+
+	moderator, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Moderator", Email: "moderator@mail.ru"})
+	assert.NoError(t, err, "client.CreateUser")
+	_, err = userRepo.SetUserRole(ctx, moderator.Id, users.RoleModerator)
+	assert.NoError(t, err, "userRepo.SetUserRole")
+	author, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@mail.ru"})
+	assert.NoError(t, err, "client.CreateUser")
+	reporter, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Ivan", Email: "ivan@mail.ru"})
+	assert.NoError(t, err, "client.CreateUser")
+
+	resAd, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: author.Id, Title: "hello", Text: "world"})
+	assert.NoError(t, err, "client.CreateAd")
+	_, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{UserId: author.Id, Published: true, AdId: resAd.Id})
+	assert.NoError(t, err, "client.ChangeAdStatus")
+
+	report, err := client.ReportAd(ctx, &grpcPort.ReportAdRequest{AdId: resAd.Id, UserId: reporter.Id, Reason: "fraud"})
+	assert.NoError(t, err, "client.ReportAd")
+	assert.Equal(t, "fraud", report.Reason)
+
+	resList, err := client.ListAds(ctx, &grpcPort.ListAdsRequest{})
+	assert.NoError(t, err, "client.ListAds")
+	assert.Len(t, resList.List, 0)
+
+	summaries, err := client.ListReportedAds(ctx, &grpcPort.ModerationQueueRequest{ModeratorId: moderator.Id})
+	assert.NoError(t, err, "client.ListReportedAds")
+	assert.Len(t, summaries.List, 1)
+	assert.Equal(t, int32(1), summaries.List[0].Reasons["fraud"])
+
+	_, err = client.ResolveReports(ctx, &grpcPort.ResolveReportsRequest{AdId: resAd.Id, ModeratorId: moderator.Id, Resolution: "ignored"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	resAd, err = client.ResolveReports(ctx, &grpcPort.ResolveReportsRequest{AdId: resAd.Id, ModeratorId: moderator.Id, Resolution: "rejected", Comment: "fraud"})
+	assert.NoError(t, err, "client.ResolveReports")
+	assert.Equal(t, "rejected", resAd.Status)
+}
+
+func TestGRRPCRenewAd(t *testing.T) {
+	client, ctx := getGRPCTestClient(t, app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New()))
+
+	user, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@mail.ru"})
+	assert.NoError(t, err, "client.CreateUser")
+	other, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Ivan", Email: "ivan@mail.ru"})
+	assert.NoError(t, err, "client.CreateUser")
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: user.Id, Title: "hello", Text: "world"})
+	assert.NoError(t, err, "client.CreateAd")
+	assert.Nil(t, ad.ExpiresAt)
+
+	_, err = client.RenewAd(ctx, &grpcPort.RenewAdRequest{AdId: ad.Id, UserId: user.Id})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	ad, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{UserId: user.Id, Published: true, AdId: ad.Id})
+	assert.NoError(t, err, "client.ChangeAdStatus")
+	assert.NotNil(t, ad.ExpiresAt)
+
+	_, err = client.RenewAd(ctx, &grpcPort.RenewAdRequest{AdId: ad.Id, UserId: other.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	renewed, err := client.RenewAd(ctx, &grpcPort.RenewAdRequest{AdId: ad.Id, UserId: user.Id})
+	assert.NoError(t, err, "client.RenewAd")
+	assert.False(t, renewed.ExpiresAt.AsTime().Before(ad.ExpiresAt.AsTime()))
+}
+
+func TestGRRPCScheduleAd(t *testing.T) {
+	client, ctx := getGRPCTestClient(t, app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New()))
+
+	user, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@mail.ru"})
+	assert.NoError(t, err, "client.CreateUser")
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: user.Id, Title: "hello", Text: "world"})
+	assert.NoError(t, err, "client.CreateAd")
+
+	publishAt := timestamppb.New(time.Now().Add(time.Hour))
+	_, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{UserId: user.Id, Published: true, AdId: ad.Id, PublishAt: timestamppb.New(time.Now().Add(-time.Hour))})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	ad, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{UserId: user.Id, Published: true, AdId: ad.Id, PublishAt: publishAt})
+	assert.NoError(t, err, "client.ChangeAdStatus")
+	assert.False(t, ad.Published)
+	assert.True(t, publishAt.AsTime().Equal(ad.PublishAt.AsTime()))
+
+	ad, err = client.CancelScheduledAd(ctx, &grpcPort.CancelScheduledAdRequest{AdId: ad.Id, UserId: user.Id})
+	assert.NoError(t, err, "client.CancelScheduledAd")
+	assert.Nil(t, ad.PublishAt)
+	_, err = client.CancelScheduledAd(ctx, &grpcPort.CancelScheduledAdRequest{AdId: ad.Id, UserId: user.Id})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestGRRPCBatch(t *testing.T) {
+	client, ctx := getGRPCTestClient(t, app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New()))
+
+	user, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@mail.ru"})
+	assert.NoError(t, err, "client.CreateUser")
+
+	_, err = client.CreateAds(ctx, &grpcPort.CreateAdsRequest{UserId: user.Id})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	created, err := client.CreateAds(ctx, &grpcPort.CreateAdsRequest{UserId: user.Id, Atomic: true, Ads: []*grpcPort.BatchAdItem{
+		{Title: "hello", Text: "world"},
+		{Title: "", Text: "world"},
+	}})
+	assert.NoError(t, err, "client.CreateAds")
+	assert.True(t, created.Aborted)
+	assert.Equal(t, codes.Aborted.String(), created.Results[0].Code)
+	assert.Equal(t, codes.InvalidArgument.String(), created.Results[1].Code)
+
+	created, err = client.CreateAds(ctx, &grpcPort.CreateAdsRequest{UserId: user.Id, Ads: []*grpcPort.BatchAdItem{
+		{Title: "hello", Text: "world"},
+		{Title: "hello2", Text: "world2"},
+	}})
+	assert.NoError(t, err, "client.CreateAds")
+	assert.False(t, created.Aborted)
+	ids := []int64{created.Results[0].Ad.Id, created.Results[1].Ad.Id}
+
+	changed, err := client.ChangeAdsStatus(ctx, &grpcPort.ChangeAdsStatusRequest{UserId: user.Id, AdIds: ids, Published: true})
+	assert.NoError(t, err, "client.ChangeAdsStatus")
+	for _, res := range changed.Results {
+		assert.Equal(t, codes.OK.String(), res.Code)
+		assert.True(t, res.Ad.Published)
+	}
+
+	deleted, err := client.DeleteAds(ctx, &grpcPort.DeleteAdsRequest{UserId: user.Id + 1, AdIds: ids})
+	assert.NoError(t, err, "client.DeleteAds")
+	assert.Equal(t, codes.PermissionDenied.String(), deleted.Results[0].Code)
+	deleted, err = client.DeleteAds(ctx, &grpcPort.DeleteAdsRequest{UserId: user.Id, AdIds: ids})
+	assert.NoError(t, err, "client.DeleteAds")
+	assert.Equal(t, codes.OK.String(), deleted.Results[1].Code)
+	assert.Nil(t, deleted.Results[1].Ad)
+}
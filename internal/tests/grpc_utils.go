@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+	"homework9/internal/app"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/grpcv2"
+	"homework9/middleware"
+)
+
+// serveGRPC serves the app over an in-memory listener and returns a dialer
+// for it. The server takes the actor from x-user-id like the real one.
+func serveGRPC(t *testing.T, a app.App, opts ...grpc.ServerOption) func(context.Context, string) (net.Conn, error) {
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	// The server may be stopped before Serve starts when a test makes no
+	// calls, so the result of Serve is checked once it has returned.
+	served := make(chan error, 1)
+	t.Cleanup(func() {
+		if err := <-served; !errors.Is(err, grpc.ErrServerStopped) {
+			assert.NoError(t, err, "srv.Serve")
+		}
+	})
+
+	srv := grpc.NewServer(append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(middleware.ActorUnaryServerInterceptor),
+		grpc.ChainStreamInterceptor(middleware.ActorStreamServerInterceptor),
+	}, opts...)...)
+	t.Cleanup(func() {
+		srv.Stop()
+	})
+
+	grpcPort.RegisterAdServiceServer(srv, grpcPort.NewService(a))
+	grpcv2.RegisterAdServiceServer(srv, grpcv2.NewService(a))
+
+	go func() {
+		served <- srv.Serve(lis)
+	}()
+
+	return func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+}
+
+// getGRPCTestClient serves the app over an in-memory listener and returns
+// a connected client together with a context bound to the test lifetime.
+func getGRPCTestClient(t *testing.T, a app.App, opts ...grpc.ServerOption) (grpcPort.AdServiceClient, context.Context) {
+	dialer := serveGRPC(t, a, opts...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(func() {
+		cancel()
+	})
+
+	conn, err := grpc.DialContext(ctx, "", grpc.WithContextDialer(dialer), grpc.WithInsecure()) //nolint:all
+	assert.NoError(t, err, "grpc.DialContext")
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return grpcPort.NewAdServiceClient(conn), ctx
+}
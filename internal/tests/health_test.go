@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/app"
+	"homework9/internal/health"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+)
+
+type failingPinger struct {
+	err error
+}
+
+func (p *failingPinger) Ping(context.Context) error {
+	return p.err
+}
+
+func getStatus(t *testing.T, url string) int {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestHTTPHealth(t *testing.T) {
+	storage := &failingPinger{}
+	checker := health.New(health.PingCheck("storage", storage, adrepo.New()))
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New())
+	server := httpgin.NewHTTPServer(":18080", a, httpgin.WithHealth(checker))
+	testServer := httptest.NewServer(server.Handler())
+	t.Cleanup(testServer.Close)
+
+	assert.Equal(t, http.StatusOK, getStatus(t, testServer.URL+"/healthz"))
+	assert.Equal(t, http.StatusOK, getStatus(t, testServer.URL+"/readyz"))
+
+	storage.err = errors.New("connection refused")
+	assert.Equal(t, http.StatusOK, getStatus(t, testServer.URL+"/healthz"))
+	assert.Equal(t, http.StatusServiceUnavailable, getStatus(t, testServer.URL+"/readyz"))
+
+	storage.err = nil
+	checker.Shutdown()
+	assert.Equal(t, http.StatusOK, getStatus(t, testServer.URL+"/healthz"))
+	assert.Equal(t, http.StatusServiceUnavailable, getStatus(t, testServer.URL+"/readyz"))
+}
+
+func TestHTTPHealthWithoutChecker(t *testing.T) {
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New())
+	server := httpgin.NewHTTPServer(":18080", a)
+	testServer := httptest.NewServer(server.Handler())
+	t.Cleanup(testServer.Close)
+
+	assert.Equal(t, http.StatusOK, getStatus(t, testServer.URL+"/readyz"))
+}
+
+func getHealthClient(t *testing.T, checker *health.Checker) (healthpb.HealthClient, context.Context) {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewGRPCServer(checker, grpcPort.AdService_ServiceDesc.ServiceName))
+	go srv.Serve(lis) //nolint:errcheck
+	t.Cleanup(srv.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	conn, err := grpc.DialContext(ctx, "", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}), grpc.WithInsecure()) //nolint:all
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	return healthpb.NewHealthClient(conn), ctx
+}
+
+func TestGRPCHealth(t *testing.T) {
+	checker := health.New()
+	client, ctx := getHealthClient(t, checker)
+
+	for _, service := range []string{"", "ad.AdService"} {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+	}
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "no.Such"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	watch, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	resp, err := watch.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	checker.Shutdown()
+	resp, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	resp, err = watch.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+	_, err = watch.Recv()
+	assert.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/app"
+	"homework9/internal/idempotency"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+	"homework9/middleware"
+)
+
+func TestIdempotencyStore(t *testing.T) {
+	clock := newFakeClock()
+	s := idempotency.New(time.Hour, idempotency.WithClock(clock.Now))
+
+	_, replay, err := s.Begin("k", "a")
+	require.NoError(t, err)
+	assert.False(t, replay)
+	_, _, err = s.Begin("k", "a")
+	assert.ErrorIs(t, err, idempotency.ErrInProgress)
+	s.Finish("k", 1)
+
+	result, replay, err := s.Begin("k", "a")
+	require.NoError(t, err)
+	assert.True(t, replay)
+	assert.Equal(t, 1, result)
+	_, _, err = s.Begin("k", "b")
+	assert.ErrorIs(t, err, idempotency.ErrKeyReused)
+
+	_, _, err = s.Begin("failed", "a")
+	require.NoError(t, err)
+	s.Release("failed")
+	_, replay, err = s.Begin("failed", "b")
+	require.NoError(t, err, "a released key is free")
+	assert.False(t, replay)
+
+	clock.Advance(time.Hour)
+	_, replay, err = s.Begin("k", "b")
+	require.NoError(t, err, "an expired key is free")
+	assert.False(t, replay)
+}
+
+func postWithKey(t *testing.T, client *restClient, path string, key string, body string) (*http.Response, []byte) {
+	req, err := http.NewRequest(http.MethodPost, client.server.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	resp, err := client.server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, data
+}
+
+func TestHTTPIdempotency(t *testing.T) {
+	client := getRESTClient(t, httpgin.WithIdempotency(idempotency.New(time.Hour)))
+	client.ok(http.MethodPost, "/users", `{"nickname":"nick","email":"mail"}`)
+
+	resp, first := postWithKey(t, client, "/api/v1/ads", "key-1", `{"user_id":0,"title":"hello","text":"world"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(first))
+	assert.Empty(t, resp.Header.Get("Idempotent-Replayed"))
+
+	resp, again := postWithKey(t, client, "/api/v1/ads", "key-1", `{ "text": "world", "title": "hello", "user_id": 0 }`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get("Idempotent-Replayed"))
+	assert.JSONEq(t, string(first), string(again))
+
+	resp, body := postWithKey(t, client, "/api/v1/ads", "key-1", `{"user_id":0,"title":"hello","text":"other"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	var out restResponse
+	require.NoError(t, json.Unmarshal(body, &out))
+	assert.NotNil(t, out.Error)
+
+	resp, _ = postWithKey(t, client, "/api/v1/ads", "key-2", `{"user_id":0,"title":"","text":"world"}`)
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+	resp, _ = postWithKey(t, client, "/api/v1/ads", "key-2", `{"user_id":0,"title":"hello","text":"world"}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "a failed request does not keep its key")
+
+	client.as(0).ok(http.MethodGet, "/ads/1", "")
+	resp, _ = client.as(0).do(http.MethodGet, "/ads/2", "")
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode, "the retries created no ads")
+
+	resp, body = postWithKey(t, client, "/api/v2/ads", "key-1", `{"title":"hello","text":"world"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, "the key was used on another route")
+	assert.Contains(t, string(body), `"code":"idempotency_key_reused"`)
+}
+
+func TestGRPCIdempotency(t *testing.T) {
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New())
+	client, ctx := getGRPCTestClient(t, a, grpc.UnaryInterceptor(middleware.IdempotencyUnaryServerInterceptor(idempotency.New(time.Hour))))
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "nick", Email: "mail"})
+	require.NoError(t, err)
+
+	keyCtx := metadata.AppendToOutgoingContext(ctx, middleware.IdempotencyKeyMetadata, "key-1")
+	first, err := client.CreateAd(keyCtx, &grpcPort.CreateAdRequest{UserId: 0, Title: "hello", Text: "world"})
+	require.NoError(t, err)
+	var header metadata.MD
+	again, err := client.CreateAd(keyCtx, &grpcPort.CreateAdRequest{UserId: 0, Title: "hello", Text: "world"}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, first.Id, again.Id)
+	assert.Equal(t, []string{"true"}, header.Get("idempotent-replayed"))
+
+	_, err = client.CreateAd(keyCtx, &grpcPort.CreateAdRequest{UserId: 0, Title: "hello", Text: "other"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	list, err := client.ListAds(ctx, &grpcPort.ListAdsRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, list.List, "the ad is a draft")
+	_, err = client.GetAd(ctx, &grpcPort.GetAdRequest{AdId: first.Id + 1})
+	assert.Error(t, err, "no second ad was created")
+}
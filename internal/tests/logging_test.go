@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/app"
+	"homework9/internal/logging"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/middleware"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// lines returns the messages of the records logged with the request ID.
+func (b *syncBuffer) lines(t *testing.T, requestID string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var msgs []string
+	sc := bufio.NewScanner(bytes.NewReader(b.buf.Bytes()))
+	for sc.Scan() {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &record), sc.Text())
+		if record["request_id"] == requestID {
+			msgs = append(msgs, record["msg"].(string))
+		}
+	}
+	return msgs
+}
+
+// captureLogs sends the default logger to a buffer for the test.
+func captureLogs(t *testing.T) *syncBuffer {
+	buf := &syncBuffer{}
+	prev := slog.Default()
+	slog.SetDefault(logging.New(buf, slog.LevelDebug))
+	t.Cleanup(func() {
+		slog.SetDefault(prev)
+	})
+	return buf
+}
+
+func TestHTTPRequestIDIsLoggedAndReturned(t *testing.T) {
+	logs := captureLogs(t)
+	client := getTestClient()
+
+	req, err := http.NewRequest(http.MethodPost, client.baseURL+"/api/v1/users", strings.NewReader(`{"nickname":"nick","email":"mail"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(logging.RequestIDHeader, "req-42")
+	resp, err := client.client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "req-42", resp.Header.Get(logging.RequestIDHeader))
+	assert.Equal(t, []string{"userrepo: user stored", "user created", "http request"}, logs.lines(t, "req-42"))
+}
+
+func TestHTTPRequestIDIsGenerated(t *testing.T) {
+	logs := captureLogs(t)
+	client := getTestClient()
+
+	for _, sent := range []string{"", "bad id with spaces"} {
+		req, err := http.NewRequest(http.MethodGet, client.baseURL+"/api/v1/ads", nil)
+		require.NoError(t, err)
+		if sent != "" {
+			req.Header.Set(logging.RequestIDHeader, sent)
+		}
+		resp, err := client.client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		id := resp.Header.Get(logging.RequestIDHeader)
+		assert.Len(t, id, 32)
+		assert.Equal(t, []string{"http request"}, logs.lines(t, id))
+	}
+}
+
+func TestGRPCRequestIDIsLoggedAndReturned(t *testing.T) {
+	logs := captureLogs(t)
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New())
+	client, ctx := getGRPCTestClient(t, a, grpc.ChainUnaryInterceptor(middleware.LoggerUnaryServerInterceptor))
+
+	var header metadata.MD
+	withID := metadata.AppendToOutgoingContext(ctx, logging.RequestIDMetadata, "grpc-7")
+	_, err := client.CreateUser(withID, &grpcPort.CreateUserRequest{Nickname: "nick", Email: "mail"}, grpc.Header(&header))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"grpc-7"}, header.Get(logging.RequestIDMetadata))
+	assert.Equal(t, []string{"userrepo: user stored", "user created", "grpc request"}, logs.lines(t, "grpc-7"))
+
+	_, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: 100}, grpc.Header(&header))
+	assert.Error(t, err)
+	if assert.Len(t, header.Get(logging.RequestIDMetadata), 1) {
+		assert.Equal(t, []string{"grpc request"}, logs.lines(t, header.Get(logging.RequestIDMetadata)[0]))
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"homework9/internal/users"
+)
+
+func TestMessageThread(t *testing.T) {
+	client := getTestClient()
+	seller, err := client.createUser("seller", "seller@mail.ru")
+	assert.NoError(t, err)
+	buyer, err := client.createUser("buyer", "buyer@mail.ru")
+	assert.NoError(t, err)
+	ad, err := client.createAd(seller.Data.ID, "bike", "almost new")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(seller.Data.ID, ad.Data.ID, true)
+	assert.NoError(t, err)
+
+	msg, err := client.sendMessageToAd(buyer.Data.ID, 0, "is it still available?")
+	assert.NoError(t, err)
+	assert.Equal(t, buyer.Data.ID, msg.Data.SenderID)
+	assert.False(t, msg.Data.Read)
+
+	second, err := client.sendMessageToAd(buyer.Data.ID, 0, "hello?")
+	assert.NoError(t, err)
+	assert.Equal(t, msg.Data.ConversationID, second.Data.ConversationID)
+
+	_, err = client.sendMessage(seller.Data.ID, msg.Data.ConversationID, "yes")
+	assert.NoError(t, err)
+
+	thread, err := client.getMessages(seller.Data.ID, msg.Data.ConversationID)
+	assert.NoError(t, err)
+	assert.Len(t, thread.Data, 3)
+	assert.False(t, thread.Data[0].Read)
+
+	thread, err = client.getMessages(buyer.Data.ID, msg.Data.ConversationID)
+	assert.NoError(t, err)
+	assert.True(t, thread.Data[0].Read)
+	assert.True(t, thread.Data[1].Read)
+	assert.False(t, thread.Data[2].Read)
+
+	inbox, err := client.getInbox(seller.Data.ID, seller.Data.ID)
+	assert.NoError(t, err)
+	assert.Len(t, inbox.Data, 1)
+	assert.Equal(t, buyer.Data.ID, inbox.Data[0].BuyerID)
+	assert.Equal(t, ad.Data.AuthorID, inbox.Data[0].SellerID)
+}
+
+func TestMessageAccess(t *testing.T) {
+	client := getTestClient()
+	seller, err := client.createUser("seller", "seller@mail.ru")
+	assert.NoError(t, err)
+	buyer, err := client.createUser("buyer", "buyer@mail.ru")
+	assert.NoError(t, err)
+	stranger, err := client.createUser("stranger", "stranger@mail.ru")
+	assert.NoError(t, err)
+	_, err = client.createAd(seller.Data.ID, "bike", "almost new")
+	assert.NoError(t, err)
+	_, err = client.sendMessageToAd(buyer.Data.ID, 0, "hi")
+	assert.ErrorIs(t, err, ErrNotFound, "drafts take no messages")
+	_, err = client.changeAdStatus(seller.Data.ID, 0, true)
+	assert.NoError(t, err)
+
+	_, err = client.sendMessageToAd(seller.Data.ID, 0, "talking to myself")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = client.sendMessageToAd(buyer.Data.ID, 0, "")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	msg, err := client.sendMessageToAd(buyer.Data.ID, 0, "hi")
+	assert.NoError(t, err)
+
+	_, err = client.getMessages(stranger.Data.ID, msg.Data.ConversationID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.sendMessage(stranger.Data.ID, msg.Data.ConversationID, "hi")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = client.getAdConversations(buyer.Data.ID, 0)
+	assert.ErrorIs(t, err, ErrForbidden)
+	convs, err := client.getAdConversations(seller.Data.ID, 0)
+	assert.NoError(t, err)
+	assert.Len(t, convs.Data, 1)
+	_, err = client.userRepo.SetUserRole(context.Background(), buyer.Data.ID, users.RoleModerator)
+	assert.NoError(t, err)
+	convs, err = client.getAdConversations(buyer.Data.ID, 0)
+	assert.NoError(t, err, "moderators read the conversations of any ad")
+	assert.Len(t, convs.Data, 1)
+
+	_, err = client.getInbox(stranger.Data.ID, seller.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.userRepo.SetUserRole(context.Background(), stranger.Data.ID, users.RoleAdmin)
+	assert.NoError(t, err)
+	inbox, err := client.getInbox(stranger.Data.ID, seller.Data.ID)
+	assert.NoError(t, err, "admins read any inbox")
+	assert.Len(t, inbox.Data, 1)
+	_, err = client.getMessages(buyer.Data.ID, 99)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
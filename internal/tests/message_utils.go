@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"homework9/middleware"
+	"net/http"
+	"strconv"
+)
+
+type messageData struct {
+	ID             int64  `json:"message_id"`
+	ConversationID int64  `json:"conversation_id"`
+	SenderID       int64  `json:"sender_id"`
+	Text           string `json:"text"`
+	Read           bool   `json:"read"`
+}
+
+type messageResponse struct {
+	Data messageData `json:"data"`
+}
+
+type messagesResponse struct {
+	Data []messageData `json:"data"`
+}
+
+type conversationData struct {
+	ID       int64 `json:"conversation_id"`
+	AdID     int64 `json:"ad_id"`
+	BuyerID  int64 `json:"buyer_id"`
+	SellerID int64 `json:"seller_id"`
+}
+
+type conversationsResponse struct {
+	Data []conversationData `json:"data"`
+}
+
+func (tc *testClient) sendMessageToAd(userID int64, adID int64, text string) (messageResponse, error) {
+	body := map[string]any{
+		"user_id": userID,
+		"text":    text,
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return messageResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/messages", adID), bytes.NewReader(data))
+	if err != nil {
+		return messageResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	var response messageResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return messageResponse{}, err
+	}
+	return response, nil
+}
+
+func (tc *testClient) sendMessage(userID int64, conversationID int64, text string) (messageResponse, error) {
+	body := map[string]any{
+		"user_id": userID,
+		"text":    text,
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return messageResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/conversations/%d/messages", conversationID), bytes.NewReader(data))
+	if err != nil {
+		return messageResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	var response messageResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return messageResponse{}, err
+	}
+	return response, nil
+}
+
+func (tc *testClient) getMessages(userID int64, conversationID int64) (messagesResponse, error) {
+	body := map[string]any{
+		"user_id": userID,
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return messagesResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/conversations/%d/messages", conversationID), bytes.NewReader(data))
+	if err != nil {
+		return messagesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	var response messagesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return messagesResponse{}, err
+	}
+	return response, nil
+}
+
+func (tc *testClient) getInbox(actorID int64, userID int64) (conversationsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/conversations", userID), nil)
+	if err != nil {
+		return conversationsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Set(middleware.UserIDHeader, strconv.FormatInt(actorID, 10))
+	var response conversationsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return conversationsResponse{}, err
+	}
+	return response, nil
+}
+
+func (tc *testClient) getAdConversations(userID int64, adID int64) (conversationsResponse, error) {
+	body := map[string]any{
+		"user_id": userID,
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return conversationsResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/conversations", adID), bytes.NewReader(data))
+	if err != nil {
+		return conversationsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	var response conversationsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return conversationsResponse{}, err
+	}
+	return response, nil
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/app"
+	"homework9/internal/metrics"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+	"homework9/middleware"
+)
+
+func scrape(t *testing.T, h http.Handler) string {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	return rec.Body.String()
+}
+
+func TestHTTPMetrics(t *testing.T) {
+	adRepo, userRepo := adrepo.New(), userrepo.New()
+	m := metrics.New()
+	m.MustRegister(metrics.NewDomainCollector(adRepo, userRepo))
+	server := httpgin.NewHTTPServer(":18080", app.NewApp(adRepo, userRepo, messagerepo.New(), reportrepo.New()), httpgin.WithMetrics(m))
+	testServer := httptest.NewServer(server.Handler())
+	t.Cleanup(testServer.Close)
+	client := &testClient{client: testServer.Client(), baseURL: testServer.URL, userRepo: userRepo}
+
+	_, err := client.createUser("nick", "mail")
+	require.NoError(t, err)
+	ad, err := client.createAd(0, "hello", "world")
+	require.NoError(t, err)
+	_, err = client.changeAdStatus(0, ad.Data.ID, true)
+	require.NoError(t, err)
+	_, err = client.createAd(0, "hello", "world")
+	require.NoError(t, err)
+	_, err = client.changeAdStatus(1, ad.Data.ID, false)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	resp, err := client.client.Get(testServer.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	for _, line := range []string{
+		`http_requests_total{code="200",method="POST",route="/api/v1/ads"} 2`,
+		`http_requests_total{code="200",method="PUT",route="/api/v1/ads/:ad_id/status"} 1`,
+		`http_requests_total{code="403",method="PUT",route="/api/v1/ads/:ad_id/status"} 1`,
+		`http_request_duration_seconds_count{method="POST",route="/api/v1/users"} 1`,
+		`http_requests_in_flight{method="POST",route="/api/v1/ads"} 0`,
+		`ads_by_status{status="draft"} 1`,
+		`ads_by_status{status="published"} 1`,
+		`ads_by_status{status="archived"} 0`,
+		`users_registered 1`,
+	} {
+		assert.Contains(t, string(body), line)
+	}
+}
+
+func TestGRPCMetrics(t *testing.T) {
+	m := metrics.New()
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New())
+	client, ctx := getGRPCTestClient(t, a, grpc.ChainUnaryInterceptor(middleware.MetricsUnaryServerInterceptor(m)))
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "nick", Email: "mail"})
+	require.NoError(t, err)
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: 0, Title: "", Text: "world"})
+	require.Error(t, err)
+
+	body := scrape(t, m.Handler())
+	for _, line := range []string{
+		`grpc_server_handled_total{code="OK",method="/ad.AdService/CreateUser"} 1`,
+		`grpc_server_handled_total{code="InvalidArgument",method="/ad.AdService/CreateAd"} 1`,
+		`grpc_server_handling_seconds_count{method="/ad.AdService/CreateUser"} 1`,
+		`grpc_server_in_flight{method="/ad.AdService/CreateUser"} 0`,
+	} {
+		assert.Contains(t, body, line)
+	}
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"homework9/internal/app"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModerationApprove(t *testing.T) {
+	client := getTestClient(app.WithPremoderation())
+	author, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+	admin, err := client.createAdmin("admin", "admin@mail.ru")
+	assert.NoError(t, err)
+	ad, err := client.createAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+	assert.Equal(t, "draft", ad.Data.Status)
+
+	ad, err = client.changeAdStatus(author.Data.ID, 0, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "pending_review", ad.Data.Status)
+	assert.False(t, ad.Data.Published)
+
+	ads, err := client.getAds()
+	assert.NoError(t, err)
+	assert.Len(t, ads.Data, 0)
+
+	_, err = client.getModerationQueue(author.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	queue, err := client.getModerationQueue(admin.Data.ID)
+	assert.NoError(t, err)
+	assert.Len(t, queue.Data, 1)
+
+	_, err = client.approveAd(author.Data.ID, 0)
+	assert.ErrorIs(t, err, ErrForbidden)
+	ad, err = client.approveAd(admin.Data.ID, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "published", ad.Data.Status)
+	assert.True(t, ad.Data.Published)
+
+	_, err = client.approveAd(admin.Data.ID, 0)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	ad, err = client.changeAdStatus(author.Data.ID, 0, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "archived", ad.Data.Status)
+}
+
+func TestModerationReject(t *testing.T) {
+	client := getTestClient(app.WithPremoderation())
+	author, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+	admin, err := client.createAdmin("admin", "admin@mail.ru")
+	assert.NoError(t, err)
+	_, err = client.createAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.rejectAd(admin.Data.ID, 0, "not submitted yet")
+	assert.ErrorIs(t, err, ErrConflict)
+
+	_, err = client.changeAdStatus(author.Data.ID, 0, true)
+	assert.NoError(t, err)
+
+	_, err = client.rejectAd(admin.Data.ID, 0, "")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	ad, err := client.rejectAd(admin.Data.ID, 0, "spam")
+	assert.NoError(t, err)
+	assert.Equal(t, "rejected", ad.Data.Status)
+	assert.Equal(t, "spam", ad.Data.RejectReason)
+
+	_, err = client.updateAd(author.Data.ID, 0, "hello", "fixed text")
+	assert.NoError(t, err)
+	ad, err = client.changeAdStatus(author.Data.ID, 0, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "pending_review", ad.Data.Status)
+	assert.Empty(t, ad.Data.RejectReason)
+}
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/app"
+	"homework9/internal/ports/httpgin"
+)
+
+type openAPIOperation struct {
+	Parameters []struct {
+		Name string `json:"name"`
+		In   string `json:"in"`
+	} `json:"parameters"`
+	Responses map[string]json.RawMessage `json:"responses"`
+}
+
+type openAPISpec struct {
+	OpenAPI string `json:"openapi"`
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components map[string]map[string]json.RawMessage  `json:"components"`
+}
+
+var ginParam = regexp.MustCompile(`[:*]([a-z_]+)`)
+
+func getOpenAPIServer(t *testing.T) (*httptest.Server, *gin.Engine) {
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New())
+	server := httpgin.NewHTTPServer(":18080", a)
+	testServer := httptest.NewServer(server.Handler())
+	t.Cleanup(testServer.Close)
+	return testServer, server.Handler().(*gin.Engine)
+}
+
+func fetchOpenAPI(t *testing.T, server *httptest.Server) (openAPISpec, []byte) {
+	resp, err := server.Client().Get(server.URL + "/api/v1/openapi.json")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var spec openAPISpec
+	require.NoError(t, json.Unmarshal(raw, &spec))
+	return spec, raw
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	server, engine := getOpenAPIServer(t)
+	spec, _ := fetchOpenAPI(t, server)
+	require.Len(t, spec.Servers, 1)
+	prefix := spec.Servers[0].URL
+
+	described := make(map[string]bool)
+	for _, route := range engine.Routes() {
+		if !strings.HasPrefix(route.Path, prefix+"/") {
+			continue
+		}
+		path := ginParam.ReplaceAllString(strings.TrimPrefix(route.Path, prefix), "{$1}")
+		op, ok := spec.Paths[path][strings.ToLower(route.Method)]
+		if !assert.True(t, ok, "%s %s is not in the spec", route.Method, route.Path) {
+			continue
+		}
+		described[route.Method+" "+path] = true
+
+		for _, m := range ginParam.FindAllStringSubmatch(route.Path, -1) {
+			found := false
+			for _, p := range op.Parameters {
+				found = found || (p.In == "path" && p.Name == m[1])
+			}
+			assert.True(t, found, "%s %s: path parameter %s is not described", route.Method, route.Path, m[1])
+		}
+		assert.Contains(t, op.Responses, "200", "%s %s", route.Method, route.Path)
+	}
+
+	for path, ops := range spec.Paths {
+		for method := range ops {
+			assert.True(t, described[strings.ToUpper(method)+" "+path], "%s %s is in the spec but not routed", method, path)
+		}
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	server, _ := getOpenAPIServer(t)
+	spec, raw := fetchOpenAPI(t, server)
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+
+	refs := regexp.MustCompile(`"\$ref": "#/components/([a-z]+)/([A-Za-z]+)"`).FindAllStringSubmatch(string(raw), -1)
+	require.NotEmpty(t, refs)
+	for _, ref := range refs {
+		assert.Contains(t, spec.Components[ref[1]], ref[2], "unresolved %s", ref[0])
+	}
+
+	resp, err := server.Client().Get(server.URL + "/api/v1/docs")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	page, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(page), `url: "openapi.json"`)
+}
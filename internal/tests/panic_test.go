@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/logging"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+	"homework9/internal/users"
+	"homework9/middleware"
+)
+
+// panickingApp panics in CreateUser, GetAd and ExportUsers.
+type panickingApp struct {
+	app.App
+}
+
+func (panickingApp) CreateUser(context.Context, string, string) (users.User, error) {
+	panic("boom")
+}
+
+func (panickingApp) GetAd(context.Context, int64, int64) (ads.Ad, error) {
+	panic("boom")
+}
+
+func (panickingApp) ExportUsers(context.Context, int64) ([]users.User, error) {
+	panic("boom")
+}
+
+func newPanickingApp() app.App {
+	return panickingApp{app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New())}
+}
+
+// panicRecord returns the log record of the recovered panic.
+func panicRecord(t *testing.T, logs *syncBuffer) map[string]any {
+	logs.mu.Lock()
+	defer logs.mu.Unlock()
+	for _, line := range strings.Split(logs.buf.String(), "\n") {
+		var record map[string]any
+		if json.Unmarshal([]byte(line), &record) == nil && record["msg"] == "panic recovered" {
+			return record
+		}
+	}
+	t.Fatal("no panic in the log")
+	return nil
+}
+
+func TestHTTPPanicRecovery(t *testing.T) {
+	logs := captureLogs(t)
+	server := httpgin.NewHTTPServer(":18080", newPanickingApp())
+	testServer := httptest.NewServer(server.Handler())
+	t.Cleanup(testServer.Close)
+
+	req, err := http.NewRequest(http.MethodPost, testServer.URL+"/api/v1/users", strings.NewReader(`{"nickname":"nick","email":"mail"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(logging.RequestIDHeader, "panic-1")
+	resp, err := testServer.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.JSONEq(t, `{"data":null,"error":"internal server error"}`, string(body))
+
+	record := panicRecord(t, logs)
+	assert.Equal(t, "panic-1", record["request_id"])
+	assert.Equal(t, "boom", record["panic"])
+	assert.Contains(t, record["stack"], "panickingApp.CreateUser")
+}
+
+func TestHTTPPanicBehindETag(t *testing.T) {
+	captureLogs(t)
+	server := httpgin.NewHTTPServer(":18080", newPanickingApp())
+	testServer := httptest.NewServer(server.Handler())
+	t.Cleanup(testServer.Close)
+
+	resp, err := testServer.Client().Get(testServer.URL + "/api/v1/ads/0")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.JSONEq(t, `{"data":null,"error":"internal server error"}`, string(body))
+	assert.Empty(t, resp.Header.Get("ETag"))
+}
+
+func TestGRPCPanicRecovery(t *testing.T) {
+	logs := captureLogs(t)
+	client, ctx := getGRPCTestClient(t, newPanickingApp(),
+		grpc.ChainUnaryInterceptor(middleware.PanicUnaryInterceptor, middleware.LoggerUnaryServerInterceptor),
+		grpc.ChainStreamInterceptor(middleware.PanicStreamInterceptor, middleware.LoggerStreamServerInterceptor),
+	)
+
+	withID := metadata.AppendToOutgoingContext(ctx, logging.RequestIDMetadata, "panic-2")
+	_, err := client.CreateUser(withID, &grpcPort.CreateUserRequest{Nickname: "nick", Email: "mail"})
+	st := status.Convert(err)
+	assert.Equal(t, codes.Internal, st.Code())
+	assert.Equal(t, "internal server error", st.Message())
+	assert.Equal(t, "panic-2", panicRecord(t, logs)["request_id"])
+
+	stream, err := client.ExportData(ctx, &grpcPort.ExportRequest{AdminId: 0, Format: "jsonl"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestGRPCPanicInInterceptor(t *testing.T) {
+	logs := captureLogs(t)
+	boom := func(context.Context, any, *grpc.UnaryServerInfo, grpc.UnaryHandler) (any, error) {
+		panic("boom")
+	}
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New())
+	client, ctx := getGRPCTestClient(t, a, grpc.ChainUnaryInterceptor(middleware.PanicUnaryInterceptor, middleware.LoggerUnaryServerInterceptor, boom))
+
+	withID := metadata.AppendToOutgoingContext(ctx, logging.RequestIDMetadata, "panic-3")
+	_, err := client.GetUser(withID, &grpcPort.GetUserRequest{Id: 0})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "panic-3", panicRecord(t, logs)["request_id"])
+}
@@ -0,0 +1,244 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/app"
+	"homework9/internal/identity"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+	"homework9/internal/ratelimit"
+	"homework9/middleware"
+)
+
+func TestLimiterBuckets(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := ratelimit.New(ratelimit.Limit{RPS: 1, Burst: 2}, map[string]ratelimit.Limit{"POST /ads": {RPS: 0.5, Burst: 1}}, ratelimit.WithClock(clock.Now))
+
+	for i := 0; i < 2; i++ {
+		ok, _ := l.Allow("GET /ads", "ip:1")
+		assert.True(t, ok)
+	}
+	ok, wait := l.Allow("GET /users", "ip:1")
+	assert.False(t, ok, "routes without a limit share the default bucket")
+	assert.Equal(t, time.Second, wait)
+
+	ok, _ = l.Allow("GET /ads", "ip:2")
+	assert.True(t, ok, "clients have their own buckets")
+	ok, _ = l.Allow("POST /ads", "ip:1")
+	assert.True(t, ok, "a route with a limit has its own bucket")
+	ok, wait = l.Allow("POST /ads", "ip:1")
+	assert.False(t, ok)
+	assert.Equal(t, 2*time.Second, wait)
+
+	clock.Advance(500 * time.Millisecond)
+	ok, wait = l.Allow("GET /ads", "ip:1")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+	clock.Advance(500 * time.Millisecond)
+	ok, _ = l.Allow("GET /ads", "ip:1")
+	assert.True(t, ok)
+}
+
+func TestHTTPRateLimit(t *testing.T) {
+	l := ratelimit.New(ratelimit.Limit{RPS: 1, Burst: 1}, map[string]ratelimit.Limit{"POST /api/v1/ads": {RPS: 0.001, Burst: 2}})
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New())
+	server := httpgin.NewHTTPServer(":18080", a, httpgin.WithRateLimit(l))
+	testServer := httptest.NewServer(server.Handler())
+	t.Cleanup(testServer.Close)
+	client := &testClient{client: testServer.Client(), baseURL: testServer.URL}
+
+	_, err := client.createUser("nick", "mail")
+	require.NoError(t, err)
+	_, err = client.getUser(0)
+	assert.ErrorIs(t, err, ErrTooMany)
+
+	for i := 0; i < 2; i++ {
+		_, err = client.createAd(0, "hello", "world")
+		require.NoError(t, err)
+	}
+	_, err = client.createAd(0, "hello", "world")
+	assert.ErrorIs(t, err, ErrTooMany)
+
+	resp, err := testServer.Client().Post(testServer.URL+"/api/v1/ads", "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	require.NoError(t, err)
+	assert.Greater(t, retryAfter, 900)
+}
+
+func TestGRPCRateLimit(t *testing.T) {
+	l := ratelimit.New(ratelimit.Limit{RPS: 0.1, Burst: 1}, nil)
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New())
+	client, ctx := getGRPCTestClient(t, a, grpc.ChainUnaryInterceptor(middleware.RateLimitUnaryServerInterceptor(l)))
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "nick", Email: "mail"})
+	require.NoError(t, err)
+
+	var header metadata.MD
+	_, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: 0}, grpc.Header(&header))
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	assert.Equal(t, []string{"10"}, header.Get("retry-after"))
+	require.Len(t, st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.InDelta(t, 10*time.Second, info.RetryDelay.AsDuration(), float64(time.Second))
+}
+
+func TestHTTPRateLimitPerUser(t *testing.T) {
+	l := ratelimit.New(ratelimit.Limit{RPS: 0.001, Burst: 1}, nil)
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New())
+	server := httpgin.NewHTTPServer(":18080", a, httpgin.WithRateLimit(l))
+	testServer := httptest.NewServer(server.Handler())
+	t.Cleanup(testServer.Close)
+
+	getUser := func(userID string) int {
+		req, err := http.NewRequest(http.MethodGet, testServer.URL+"/api/v1/users/0", nil)
+		require.NoError(t, err)
+		if userID != "" {
+			req.Header.Set(middleware.UserIDHeader, userID)
+		}
+		resp, err := testServer.Client().Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.NotEqual(t, http.StatusTooManyRequests, getUser("1"))
+	assert.Equal(t, http.StatusTooManyRequests, getUser("1"))
+	assert.Equal(t, http.StatusTooManyRequests, getUser("2"), "another user ID does not escape the bucket of the address")
+	assert.Equal(t, http.StatusTooManyRequests, getUser(""))
+}
+
+func TestGRPCRateLimitPerUser(t *testing.T) {
+	l := ratelimit.New(ratelimit.Limit{RPS: 0.001, Burst: 1}, nil)
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New())
+	// the client name stands in for the one of a verified certificate
+	certified := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if names := md.Get("test-client"); len(names) > 0 {
+			ctx = identity.WithClient(ctx, names[0])
+		}
+		return handler(ctx, req)
+	}
+	client, ctx := getGRPCTestClient(t, a, grpc.ChainUnaryInterceptor(certified, middleware.ActorUnaryServerInterceptor, middleware.RateLimitUnaryServerInterceptor(l)))
+
+	getUser := func(clientName, userID string) codes.Code {
+		md := metadata.Pairs(middleware.UserIDMetadata, userID)
+		if clientName != "" {
+			md.Append("test-client", clientName)
+		}
+		_, err := client.GetUser(metadata.NewOutgoingContext(ctx, md), &grpcPort.GetUserRequest{Id: 0})
+		return status.Code(err)
+	}
+	assert.NotEqual(t, codes.ResourceExhausted, getUser("gateway", "1"))
+	assert.Equal(t, codes.ResourceExhausted, getUser("gateway", "1"))
+	assert.NotEqual(t, codes.ResourceExhausted, getUser("gateway", "2"), "a certified client has a bucket per user")
+	assert.NotEqual(t, codes.ResourceExhausted, getUser("", "3"))
+	assert.Equal(t, codes.ResourceExhausted, getUser("", "4"), "without a certificate the user ID is not trusted")
+}
+
+func TestDailyAdQuota(t *testing.T) {
+	client := getTestClient(app.WithDailyAdQuota(3))
+	_, err := client.createUser("nick", "mail")
+	require.NoError(t, err)
+
+	_, err = client.createAd(0, "hello", "world")
+	require.NoError(t, err)
+
+	code, resp, err := client.createAds(0, false, "one", "two", "three")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	require.Len(t, resp.Data, 3)
+	assert.Equal(t, http.StatusOK, resp.Data[0].Status)
+	assert.Equal(t, http.StatusOK, resp.Data[1].Status)
+	assert.Equal(t, http.StatusTooManyRequests, resp.Data[2].Status)
+
+	_, err = client.createAd(0, "hello", "world")
+	assert.ErrorIs(t, err, ErrTooMany)
+
+	code, _, err = client.createAds(0, false, "four")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, code)
+
+	_, err = client.createUser("other", "other mail")
+	require.NoError(t, err)
+	_, err = client.createAd(1, "hello", "world")
+	assert.NoError(t, err, "the quota is per user")
+}
+
+func TestDailyAdQuotaCountsCreations(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New(), app.WithDailyAdQuota(2), app.WithClock(clock.Now))
+	user, err := a.CreateUser(ctx, "nick", "mail")
+	require.NoError(t, err)
+
+	ad, err := a.CreateAd(ctx, "hello", "world", user.ID)
+	require.NoError(t, err)
+	require.NoError(t, a.DeleteAd(ctx, ad.ID, user.ID))
+	_, err = a.CreateAd(ctx, "hello", "world", user.ID)
+	require.NoError(t, err)
+	_, err = a.CreateAd(ctx, "hello", "world", user.ID)
+	assert.ErrorIs(t, err, app.ErrQuotaExceeded, "a deleted ad still counts")
+
+	clock.Advance(24 * time.Hour)
+	_, err = a.CreateAd(ctx, "hello", "world", user.ID)
+	assert.NoError(t, err, "the quota is renewed at midnight")
+}
+
+func TestDailyAdQuotaConcurrentCreates(t *testing.T) {
+	ctx := context.Background()
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New(), app.WithDailyAdQuota(5))
+	user, err := a.CreateUser(ctx, "nick", "mail")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var created atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := a.CreateAd(ctx, "hello", "world", user.ID); err == nil {
+				created.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(5), created.Load())
+}
+
+func TestGRPCDailyAdQuota(t *testing.T) {
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New(), app.WithDailyAdQuota(1))
+	client, ctx := getGRPCTestClient(t, a)
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "nick", Email: "mail"})
+	require.NoError(t, err)
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: 0, Title: "hello", Text: "world"})
+	require.NoError(t, err)
+
+	var header metadata.MD
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: 0, Title: "hello", Text: "world"}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Len(t, header.Get("retry-after"), 1)
+}
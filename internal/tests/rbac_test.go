@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminCanDeleteAnyAd(t *testing.T) {
+	client := getTestClient()
+	author, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+	other, err := client.createUser("other", "other@mail.ru")
+	assert.NoError(t, err)
+	admin, err := client.createAdmin("admin", "admin@mail.ru")
+	assert.NoError(t, err)
+	ad, err := client.createAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.deleteAd(other.Data.ID, ad.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = client.deleteAd(admin.Data.ID, ad.Data.ID)
+	assert.NoError(t, err)
+}
+
+func TestModeratorCanOnlyUnpublish(t *testing.T) {
+	client := getTestClient()
+	author, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+	moderator, err := client.createUser("moderator", "moderator@mail.ru")
+	assert.NoError(t, err)
+	admin, err := client.createAdmin("admin", "admin@mail.ru")
+	assert.NoError(t, err)
+
+	resp, err := client.setUserRole(admin.Data.ID, moderator.Data.ID, "moderator")
+	assert.NoError(t, err)
+	assert.Equal(t, "moderator", resp.Data.Role)
+
+	ad, err := client.createAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(author.Data.ID, ad.Data.ID, true)
+	assert.NoError(t, err)
+
+	_, err = client.changeAdStatus(moderator.Data.ID, ad.Data.ID, true)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.updateAd(moderator.Data.ID, ad.Data.ID, "title", "text")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.deleteAd(moderator.Data.ID, ad.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	unpublished, err := client.changeAdStatus(moderator.Data.ID, ad.Data.ID, false)
+	assert.NoError(t, err)
+	assert.False(t, unpublished.Data.Published)
+}
+
+func TestUnpublishedAdsAreHidden(t *testing.T) {
+	client := getTestClient()
+	author, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+	other, err := client.createUser("other", "other@mail.ru")
+	assert.NoError(t, err)
+	moderator, err := client.createUser("moderator", "moderator@mail.ru")
+	assert.NoError(t, err)
+	admin, err := client.createAdmin("admin", "admin@mail.ru")
+	assert.NoError(t, err)
+	_, err = client.setUserRole(admin.Data.ID, moderator.Data.ID, "moderator")
+	assert.NoError(t, err)
+	ad, err := client.createAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.getAd(ad.Data.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = client.getAdAs(other.Data.ID, ad.Data.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	for _, id := range []int64{author.Data.ID, moderator.Data.ID, admin.Data.ID} {
+		draft, err := client.getAdAs(id, ad.Data.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "draft", draft.Data.Status)
+	}
+
+	_, err = client.changeAdStatus(author.Data.ID, ad.Data.ID, true)
+	assert.NoError(t, err)
+	_, err = client.getAd(ad.Data.ID)
+	assert.NoError(t, err, "published ads are seen by everybody")
+}
+
+func TestSetUserRole(t *testing.T) {
+	client := getTestClient()
+	user, err := client.createUser("user", "user@mail.ru")
+	assert.NoError(t, err)
+	admin, err := client.createAdmin("admin", "admin@mail.ru")
+	assert.NoError(t, err)
+
+	_, err = client.setUserRole(user.Data.ID, user.Data.ID, "admin")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = client.setUserRole(admin.Data.ID, user.Data.ID, "superuser")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	resp, err := client.setUserRole(admin.Data.ID, user.Data.ID, "admin")
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", resp.Data.Role)
+}
+
+func TestBannedUser(t *testing.T) {
+	client := getTestClient()
+	user, err := client.createUser("user", "user@mail.ru")
+	assert.NoError(t, err)
+	admin, err := client.createAdmin("admin", "admin@mail.ru")
+	assert.NoError(t, err)
+	ad, err := client.createAd(user.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.banUser(user.Data.ID, admin.Data.ID, true)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	resp, err := client.banUser(admin.Data.ID, user.Data.ID, true)
+	assert.NoError(t, err)
+	assert.True(t, resp.Data.Banned)
+
+	_, err = client.createAd(user.Data.ID, "hello", "world")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.updateAd(user.Data.ID, ad.Data.ID, "title", "text")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = client.banUser(admin.Data.ID, user.Data.ID, false)
+	assert.NoError(t, err)
+	_, err = client.createAd(user.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+}
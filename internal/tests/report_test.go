@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"fmt"
+	"homework9/internal/app"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportAutoHide(t *testing.T) {
+	client := getTestClient(app.WithReportThreshold(2))
+	author, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+	admin, err := client.createAdmin("admin", "admin@mail.ru")
+	assert.NoError(t, err)
+	reporters := make([]int64, 2)
+	for i := range reporters {
+		user, err := client.createUser("reporter", fmt.Sprintf("reporter%d@mail.ru", i))
+		assert.NoError(t, err)
+		reporters[i] = user.Data.ID
+	}
+	_, err = client.createAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(author.Data.ID, 0, true)
+	assert.NoError(t, err)
+
+	_, err = client.reportAd(author.Data.ID, 0, "spam", "")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.reportAd(reporters[0], 0, "because", "")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	report, err := client.reportAd(reporters[0], 0, "spam", "buy my course")
+	assert.NoError(t, err)
+	assert.Equal(t, "spam", report.Data.Reason)
+	_, err = client.reportAd(reporters[0], 0, "fraud", "")
+	assert.NoError(t, err)
+
+	ad, err := client.getAd(0)
+	assert.NoError(t, err)
+	assert.True(t, ad.Data.Published, "one reporter is not enough")
+
+	_, err = client.reportAd(reporters[1], 0, "spam", "")
+	assert.NoError(t, err)
+	_, err = client.getAd(0)
+	assert.ErrorIs(t, err, ErrNotFound, "the hidden ad is gone for the others")
+	ad, err = client.getAdAs(author.Data.ID, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "pending_review", ad.Data.Status)
+
+	_, err = client.getReportedAds(author.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	summaries, err := client.getReportedAds(admin.Data.ID)
+	assert.NoError(t, err)
+	assert.Len(t, summaries.Data, 1)
+	assert.Equal(t, 3, summaries.Data[0].Reports)
+	assert.Equal(t, 2, summaries.Data[0].Reporters)
+	assert.Equal(t, 2, summaries.Data[0].Reasons["spam"])
+
+	ad, err = client.resolveReports(admin.Data.ID, 0, "dismissed", "")
+	assert.NoError(t, err)
+	assert.True(t, ad.Data.Published)
+	summaries, err = client.getReportedAds(admin.Data.ID)
+	assert.NoError(t, err)
+	assert.Len(t, summaries.Data, 0)
+}
+
+func TestReportedAdWaitsForModerator(t *testing.T) {
+	client := getTestClient(app.WithReportThreshold(2))
+	author, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+	admin, err := client.createAdmin("admin", "admin@mail.ru")
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err := client.createUser("reporter", fmt.Sprintf("reporter%d@mail.ru", i))
+		assert.NoError(t, err)
+	}
+	_, err = client.createAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(author.Data.ID, 0, true)
+	assert.NoError(t, err)
+	for _, reporter := range []int64{2, 3} {
+		_, err = client.reportAd(reporter, 0, "spam", "")
+		assert.NoError(t, err)
+	}
+
+	_, err = client.changeAdStatus(author.Data.ID, 0, true)
+	assert.ErrorIs(t, err, ErrConflict, "the author cannot republish a hidden ad")
+	_, err = client.changeAdStatus(author.Data.ID, 0, false)
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(author.Data.ID, 0, true)
+	assert.ErrorIs(t, err, ErrConflict, "nor through a draft")
+
+	_, err = client.resolveReports(admin.Data.ID, 0, "dismissed", "")
+	assert.NoError(t, err)
+	ad, err := client.changeAdStatus(author.Data.ID, 0, true)
+	assert.NoError(t, err)
+	assert.True(t, ad.Data.Published)
+}
+
+func TestApproveDismissesReports(t *testing.T) {
+	client := getTestClient(app.WithReportThreshold(2))
+	author, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+	admin, err := client.createAdmin("admin", "admin@mail.ru")
+	assert.NoError(t, err)
+	reporters := make([]int64, 3)
+	for i := range reporters {
+		user, err := client.createUser("reporter", fmt.Sprintf("reporter%d@mail.ru", i))
+		assert.NoError(t, err)
+		reporters[i] = user.Data.ID
+	}
+	_, err = client.createAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(author.Data.ID, 0, true)
+	assert.NoError(t, err)
+	for _, reporter := range reporters[:2] {
+		_, err = client.reportAd(reporter, 0, "spam", "")
+		assert.NoError(t, err)
+	}
+
+	ad, err := client.approveAd(admin.Data.ID, 0)
+	assert.NoError(t, err)
+	assert.True(t, ad.Data.Published)
+	summaries, err := client.getReportedAds(admin.Data.ID)
+	assert.NoError(t, err)
+	assert.Len(t, summaries.Data, 0)
+
+	_, err = client.reportAd(reporters[2], 0, "spam", "")
+	assert.NoError(t, err)
+	ad, err = client.getAd(0)
+	assert.NoError(t, err)
+	assert.True(t, ad.Data.Published, "the approved reports do not count again")
+}
+
+func TestReportReject(t *testing.T) {
+	client := getTestClient()
+	author, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+	reporter, err := client.createUser("reporter", "reporter@mail.ru")
+	assert.NoError(t, err)
+	admin, err := client.createAdmin("admin", "admin@mail.ru")
+	assert.NoError(t, err)
+	_, err = client.createAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(author.Data.ID, 0, true)
+	assert.NoError(t, err)
+	_, err = client.reportAd(reporter.Data.ID, 0, "prohibited", "")
+	assert.NoError(t, err)
+
+	_, err = client.resolveReports(admin.Data.ID, 0, "rejected", "")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	ad, err := client.resolveReports(admin.Data.ID, 0, "rejected", "prohibited goods")
+	assert.NoError(t, err)
+	assert.Equal(t, "rejected", ad.Data.Status)
+	assert.Equal(t, "prohibited goods", ad.Data.RejectReason)
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type reportData struct {
+	ID         int64  `json:"report_id"`
+	AdID       int64  `json:"ad_id"`
+	ReporterID int64  `json:"reporter_id"`
+	Reason     string `json:"reason"`
+	Comment    string `json:"comment"`
+}
+
+type reportResponse struct {
+	Data reportData `json:"data"`
+}
+
+type reportSummaryData struct {
+	AdID      int64          `json:"ad_id"`
+	Reports   int            `json:"reports"`
+	Reporters int            `json:"reporters"`
+	Reasons   map[string]int `json:"reasons"`
+}
+
+type reportSummariesResponse struct {
+	Data []reportSummaryData `json:"data"`
+}
+
+func (tc *testClient) reportAd(userID int64, adID int64, reason string, comment string) (reportResponse, error) {
+	body := map[string]any{
+		"user_id": userID,
+		"reason":  reason,
+		"comment": comment,
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return reportResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/reports", adID), bytes.NewReader(data))
+	if err != nil {
+		return reportResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	var response reportResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return reportResponse{}, err
+	}
+	return response, nil
+}
+
+func (tc *testClient) getReportedAds(moderatorID int64) (reportSummariesResponse, error) {
+	body := map[string]any{
+		"moderator_id": moderatorID,
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return reportSummariesResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/moderation/reports", bytes.NewReader(data))
+	if err != nil {
+		return reportSummariesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	var response reportSummariesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return reportSummariesResponse{}, err
+	}
+	return response, nil
+}
+
+func (tc *testClient) resolveReports(moderatorID int64, adID int64, resolution string, comment string) (adResponse, error) {
+	body := map[string]any{
+		"moderator_id": moderatorID,
+		"resolution":   resolution,
+		"comment":      comment,
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/moderation/reports/%d/resolve", adID), bytes.NewReader(data))
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+	return response, nil
+}
@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/app"
+	"homework9/internal/ports/httpgin"
+	"homework9/middleware"
+)
+
+type restResponse struct {
+	Data  json.RawMessage `json:"data"`
+	Error *string         `json:"error"`
+}
+
+// restClient calls /api/v1 as the user of X-User-ID, or as an old client
+// when user is negative.
+type restClient struct {
+	t      *testing.T
+	server *httptest.Server
+	user   int64
+}
+
+func getRESTClient(t *testing.T, opts ...httpgin.Option) *restClient {
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New())
+	httpServer := httpgin.NewHTTPServer(":18080", a, opts...)
+	server := httptest.NewServer(httpServer.Handler())
+	t.Cleanup(server.Close)
+	return &restClient{t: t, server: server, user: -1}
+}
+
+func (rc *restClient) as(user int64) *restClient {
+	return &restClient{t: rc.t, server: rc.server, user: user}
+}
+
+func (rc *restClient) do(method string, path string, body string) (*http.Response, restResponse) {
+	req, err := http.NewRequest(method, rc.server.URL+"/api/v1"+path, strings.NewReader(body))
+	require.NoError(rc.t, err)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if rc.user >= 0 {
+		req.Header.Set(middleware.UserIDHeader, strconv.FormatInt(rc.user, 10))
+	}
+	resp, err := rc.server.Client().Do(req)
+	require.NoError(rc.t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(rc.t, err)
+	var out restResponse
+	require.NoError(rc.t, json.Unmarshal(data, &out), string(data))
+	return resp, out
+}
+
+func (rc *restClient) ok(method string, path string, body string) json.RawMessage {
+	resp, out := rc.do(method, path, body)
+	require.Equal(rc.t, http.StatusOK, resp.StatusCode, "%s %s: %v", method, path, out.Error)
+	assert.Empty(rc.t, resp.Header.Get("Deprecation"), "%s %s", method, path)
+	return out.Data
+}
+
+func TestRESTShapes(t *testing.T) {
+	client := getRESTClient(t)
+	client.ok(http.MethodPost, "/users", `{"nickname":"author","email":"author@mail"}`)
+	client.ok(http.MethodPost, "/users", `{"nickname":"buyer","email":"buyer@mail"}`)
+	author, buyer := client.as(0), client.as(1)
+
+	author.ok(http.MethodPost, "/ads", `{"title":"first","text":"text"}`)
+	author.ok(http.MethodPost, "/ads", `{"title":"second","text":"text"}`)
+	author.ok(http.MethodPut, "/ads/0/status", `{"published":true}`)
+
+	var ad adData
+	require.NoError(t, json.Unmarshal(client.ok(http.MethodGet, "/ads/0", ""), &ad))
+	assert.Equal(t, "first", ad.Title)
+	client.ok(http.MethodGet, "/ads/title/second", "")
+	client.ok(http.MethodGet, "/users/1", "")
+
+	var list []adData
+	require.NoError(t, json.Unmarshal(client.ok(http.MethodGet, "/ads?author_id=0&published=true", ""), &list))
+	require.Len(t, list, 1)
+	assert.Equal(t, "first", list[0].Title)
+	require.NoError(t, json.Unmarshal(client.ok(http.MethodGet, "/ads?author_id=1", ""), &list))
+	assert.Empty(t, list)
+	resp, _ := client.do(http.MethodGet, "/ads?published=maybe", "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	buyer.ok(http.MethodPost, "/ads/0/messages", `{"text":"hello"}`)
+	author.ok(http.MethodGet, "/ads/0/conversations", "")
+	author.ok(http.MethodGet, "/conversations/0/messages", "")
+
+	resp, _ = buyer.do(http.MethodDelete, "/ads/1", "")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "the actor comes from the header")
+	author.ok(http.MethodDelete, "/ads/1", "")
+
+	author.ok(http.MethodPost, "/ads", `{"title":"third","text":"text"}`)
+	author.ok(http.MethodPost, "/ads", `{"title":"fourth","text":"text"}`)
+	var results []struct {
+		Status int `json:"status"`
+	}
+	require.NoError(t, json.Unmarshal(author.ok(http.MethodDelete, "/ads/batch?id=2&id=3&atomic=true", ""), &results))
+	assert.Len(t, results, 2)
+
+	resp, _ = author.do(http.MethodDelete, "/users/1", "")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "users delete only themselves")
+	buyer.ok(http.MethodDelete, "/users/1", "")
+}
+
+func TestRESTMissingActor(t *testing.T) {
+	client := getRESTClient(t)
+	client.ok(http.MethodPost, "/users", `{"nickname":"nick","email":"mail"}`)
+
+	for _, path := range []string{"/moderation/ads", "/moderation/reports", "/admin/export"} {
+		resp, out := client.do(http.MethodGet, path, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, path)
+		require.NotNil(t, out.Error)
+		assert.Contains(t, *out.Error, middleware.UserIDHeader)
+	}
+	resp, _ := client.do(http.MethodDelete, "/ads/0", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	for _, call := range []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodPost, "/ads", `{"title":"hello","text":"world"}`},
+		{http.MethodPut, "/admin/users/0/role", `{"role":"admin"}`},
+		{http.MethodPost, "/ads/batch", `{"ads":[{"title":"hello","text":"world"}]}`},
+	} {
+		resp, _ := client.do(call.method, call.path, call.body)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "a body without the user is not user 0: %s %s", call.method, call.path)
+	}
+	client.ok(http.MethodPost, "/users", `{"nickname":"other","email":"other@mail"}`)
+	resp, out := client.as(1).do(http.MethodPost, "/ads", `{"user_id":0,"title":"hello","text":"world"}`)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "the body may not name another user than the header")
+	require.NotNil(t, out.Error)
+	assert.Contains(t, *out.Error, middleware.UserIDHeader)
+
+	req, err := http.NewRequest(http.MethodGet, client.server.URL+"/api/v1/moderation/ads", nil)
+	require.NoError(t, err)
+	req.Header.Set(middleware.UserIDHeader, "admin")
+	resp, err = client.server.Client().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestRESTLegacyForms(t *testing.T) {
+	client := getRESTClient(t)
+	client.ok(http.MethodPost, "/users", `{"nickname":"nick","email":"mail"}`)
+
+	for _, call := range []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodPost, "/ads", `{"user_id":0,"title":"hello","text":"world"}`},
+		{http.MethodPut, "/ads/0/status", `{"user_id":0,"published":true}`},
+		{http.MethodGet, "/ads/0", `{"id":0}`},
+		{http.MethodGet, "/users/0", `{"user_id":0}`},
+		{http.MethodGet, "/ads/params", `{"params":{"author_id":0}}`},
+		{http.MethodGet, "/ads/params", ""},
+		{http.MethodGet, "/moderation/ads", `{"moderator_id":0}`},
+		{http.MethodGet, "/admin/export?admin_id=0", ""},
+		{http.MethodDelete, "/ads/0", `{"user_id":0}`},
+	} {
+		resp, out := client.do(call.method, call.path, call.body)
+		assert.Equal(t, "true", resp.Header.Get("Deprecation"), "%s %s", call.method, call.path)
+		if call.path == "/moderation/ads" || strings.HasPrefix(call.path, "/admin") {
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode, "%s %s", call.method, call.path)
+			continue
+		}
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "%s %s: %v", call.method, call.path, out.Error)
+	}
+}
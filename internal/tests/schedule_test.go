@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"context"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishScheduledAds(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	a := newExpiringApp(clock)
+	user, err := a.CreateUser(ctx, "author", "author@mail.ru")
+	assert.NoError(t, err)
+	ad, err := a.CreateAd(ctx, "hello", "world", user.ID)
+	assert.NoError(t, err)
+
+	_, err = a.ScheduleAd(ctx, ad.ID, user.ID, clock.Now().Add(-time.Hour))
+	assert.ErrorIs(t, err, app.ErrValidationFail)
+	ad, err = a.ScheduleAd(ctx, ad.ID, user.ID, clock.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, ads.StatusDraft, ad.Status)
+	assert.True(t, ad.Scheduled())
+
+	assert.NoError(t, a.PublishScheduledAds(ctx))
+	ad, err = a.GetAd(ctx, ad.ID, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ads.StatusDraft, ad.Status)
+
+	clock.Advance(time.Hour)
+	assert.NoError(t, a.PublishScheduledAds(ctx))
+	ad, err = a.GetAd(ctx, ad.ID, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ads.StatusPublished, ad.Status)
+	assert.False(t, ad.Scheduled())
+
+	_, err = a.ScheduleAd(ctx, ad.ID, user.ID, clock.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, app.ErrInvalidTransition)
+}
+
+func TestPublishScheduledAdsSkipsFailedAds(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	repo := &failingAdRepo{AdRepository: adrepo.New(), failID: 0}
+	a := app.NewApp(repo, userrepo.New(), messagerepo.New(), reportrepo.New(), app.WithClock(clock.Now))
+	user, err := a.CreateUser(ctx, "author", "author@mail.ru")
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		ad, err := a.CreateAd(ctx, "hello", "world", user.ID)
+		assert.NoError(t, err)
+		_, err = a.ScheduleAd(ctx, ad.ID, user.ID, clock.Now().Add(time.Hour))
+		assert.NoError(t, err)
+	}
+
+	clock.Advance(time.Hour)
+	assert.ErrorIs(t, a.PublishScheduledAds(ctx), context.DeadlineExceeded)
+	ad, err := a.GetAd(ctx, 1, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ads.StatusPublished, ad.Status, "the second ad is published anyway")
+
+	repo.failID = -1
+	assert.NoError(t, a.PublishScheduledAds(ctx))
+	ad, err = a.GetAd(ctx, 0, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ads.StatusPublished, ad.Status, "the failed ad is retried")
+}
+
+func TestPublishScheduledAdsPremoderation(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	a := newExpiringApp(clock, app.WithPremoderation())
+	user, err := a.CreateUser(ctx, "author", "author@mail.ru")
+	assert.NoError(t, err)
+	ad, err := a.CreateAd(ctx, "hello", "world", user.ID)
+	assert.NoError(t, err)
+	_, err = a.ScheduleAd(ctx, ad.ID, user.ID, clock.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	clock.Advance(time.Minute)
+	assert.NoError(t, a.PublishScheduledAds(ctx))
+	ad, err = a.GetAd(ctx, ad.ID, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ads.StatusPendingReview, ad.Status)
+}
+
+func TestManualPublishClearsSchedule(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	a := newExpiringApp(clock)
+	user, err := a.CreateUser(ctx, "author", "author@mail.ru")
+	assert.NoError(t, err)
+	ad, err := a.CreateAd(ctx, "hello", "world", user.ID)
+	assert.NoError(t, err)
+	_, err = a.ScheduleAd(ctx, ad.ID, user.ID, clock.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	ad, err = a.ChangeAdStatus(ctx, ad.ID, user.ID, true)
+	assert.NoError(t, err)
+	assert.True(t, ad.Published())
+	assert.False(t, ad.Scheduled())
+}
+
+func TestScheduleAdHTTP(t *testing.T) {
+	client := getTestClient()
+	user, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+	other, err := client.createUser("other", "other@mail.ru")
+	assert.NoError(t, err)
+	_, err = client.createAd(user.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	publishAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	_, err = client.scheduleAd(other.Data.ID, 0, publishAt)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.scheduleAd(user.Data.ID, 0, time.Now().Add(-time.Hour))
+	assert.ErrorIs(t, err, ErrBadRequest)
+	ad, err := client.scheduleAd(user.Data.ID, 0, publishAt)
+	assert.NoError(t, err)
+	assert.Equal(t, "draft", ad.Data.Status)
+	assert.NotNil(t, ad.Data.PublishAt)
+	assert.True(t, publishAt.Equal(*ad.Data.PublishAt))
+
+	_, err = client.cancelScheduledAd(other.Data.ID, 0)
+	assert.ErrorIs(t, err, ErrForbidden)
+	ad, err = client.cancelScheduledAd(user.Data.ID, 0)
+	assert.NoError(t, err)
+	assert.Nil(t, ad.Data.PublishAt)
+	_, err = client.cancelScheduledAd(user.Data.ID, 0)
+	assert.ErrorIs(t, err, ErrConflict)
+}
@@ -0,0 +1,228 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/adctl"
+	"homework9/internal/app"
+	"homework9/internal/identity"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/tlsconfig"
+	"homework9/internal/users"
+	"homework9/middleware"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+var certSerial int64
+
+// newTestCA writes a self-signed CA to dir.
+func newTestCA(t *testing.T, dir string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	certSerial++
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(certSerial),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	file := filepath.Join(dir, "ca.pem")
+	writePEM(t, file, "CERTIFICATE", der)
+	return &testCA{cert: cert, key: key, file: file}
+}
+
+// issue writes a certificate for localhost named cn and its key to dir.
+func (ca *testCA) issue(t *testing.T, dir string, cn string) (certFile string, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	certSerial++
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(certSerial),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile, keyFile = filepath.Join(dir, cn+".pem"), filepath.Join(dir, cn+"-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, file string, kind string, der []byte) {
+	require.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600))
+}
+
+// serveMutualTLS serves a with certificates of ca and records the client
+// identity of every call.
+func serveMutualTLS(t *testing.T, a app.App, ca *testCA, dir string) (dialer func(context.Context, string) (net.Conn, error), clients *[]string) {
+	certFile, keyFile := ca.issue(t, dir, "server")
+	reloader, err := tlsconfig.NewReloader(certFile, keyFile)
+	require.NoError(t, err)
+	cfg, err := tlsconfig.Server(reloader, ca.file)
+	require.NoError(t, err)
+
+	clients = &[]string{}
+	record := func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		name, _ := identity.Client(ctx)
+		*clients = append(*clients, name)
+		return handler(ctx, req)
+	}
+	dialer = serveGRPC(t, a,
+		grpc.Creds(credentials.NewTLS(cfg)),
+		grpc.ChainUnaryInterceptor(middleware.IdentityUnaryServerInterceptor, record),
+	)
+	return dialer, clients
+}
+
+func dialTLS(t *testing.T, cfg adctl.Config, dialer func(context.Context, string) (net.Conn, error)) grpcPort.AdServiceClient {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	cfg.Server = "localhost"
+	conn, err := adctl.Dial(ctx, cfg, grpc.WithContextDialer(dialer))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	return grpcPort.NewAdServiceClient(conn)
+}
+
+func TestGRPCMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	dialer, clients := serveMutualTLS(t, app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New()), ca, dir)
+
+	certFile, keyFile := ca.issue(t, dir, "billing")
+	client := dialTLS(t, adctl.Config{CAFile: ca.file, CertFile: certFile, KeyFile: keyFile}, dialer)
+	_, err := client.CreateUser(context.Background(), &grpcPort.CreateUserRequest{Nickname: "nick", Email: "mail"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"billing"}, *clients)
+}
+
+func TestGRPCMutualTLSRejectsClients(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	dialer, clients := serveMutualTLS(t, app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New()), ca, dir)
+
+	client := dialTLS(t, adctl.Config{CAFile: ca.file}, dialer)
+	_, err := client.CreateUser(context.Background(), &grpcPort.CreateUserRequest{Nickname: "nick", Email: "mail"})
+	assert.Error(t, err, "no client certificate")
+
+	other := newTestCA(t, t.TempDir())
+	certFile, keyFile := other.issue(t, dir, "intruder")
+	client = dialTLS(t, adctl.Config{CAFile: ca.file, CertFile: certFile, KeyFile: keyFile}, dialer)
+	_, err = client.CreateUser(context.Background(), &grpcPort.CreateUserRequest{Nickname: "nick", Email: "mail"})
+	assert.Error(t, err, "certificate of an unknown CA")
+
+	assert.Empty(t, *clients)
+}
+
+func TestGRPCMutualTLSClientRoles(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	userRepo := userrepo.New()
+	a := app.NewApp(adrepo.New(), userRepo, messagerepo.New(), reportrepo.New(),
+		app.WithClients(map[string]users.Role{"backoffice": users.RoleAdmin, "storefront": users.RoleUser}))
+	dialer, _ := serveMutualTLS(t, a, ca, dir)
+	dial := func(name string) grpcPort.AdServiceClient {
+		certFile, keyFile := ca.issue(t, dir, name)
+		return dialTLS(t, adctl.Config{CAFile: ca.file, CertFile: certFile, KeyFile: keyFile}, dialer)
+	}
+	backoffice, storefront, intruder := dial("backoffice"), dial("storefront"), dial("intruder")
+
+	ctx := context.Background()
+	for _, nick := range []string{"admin", "user"} {
+		_, err := backoffice.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: nick, Email: nick + "@mail.ru"})
+		require.NoError(t, err)
+	}
+	_, err := userRepo.SetUserRole(ctx, 0, users.RoleAdmin)
+	require.NoError(t, err)
+
+	_, err = backoffice.SetUserRole(ctx, &grpcPort.SetUserRoleRequest{AdminId: 0, UserId: 1, Role: "moderator"})
+	assert.NoError(t, err)
+	_, err = storefront.SetUserRole(ctx, &grpcPort.SetUserRoleRequest{AdminId: 0, UserId: 1, Role: "user"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "the client caps the role of the admin")
+	_, err = storefront.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: 1})
+	assert.NoError(t, err)
+	_, err = intruder.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: 1})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "a certificate of the right CA with an unknown name")
+}
+
+func TestTLSCertificateReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	certFile, keyFile := ca.issue(t, dir, "server")
+	reloader, err := tlsconfig.NewReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	commonName := func() string {
+		cert, err := reloader.GetCertificate(&tls.ClientHelloInfo{})
+		require.NoError(t, err)
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		return leaf.Subject.CommonName
+	}
+	touch := func(after time.Duration) {
+		at := time.Now().Add(after)
+		require.NoError(t, os.Chtimes(certFile, at, at))
+		require.NoError(t, os.Chtimes(keyFile, at, at))
+	}
+	ctx := context.Background()
+
+	require.NoError(t, reloader.Reload(ctx))
+	assert.Equal(t, "server", commonName())
+
+	renewedCert, renewedKey := ca.issue(t, dir, "renewed")
+	require.NoError(t, os.Rename(renewedCert, certFile))
+	require.NoError(t, os.Rename(renewedKey, keyFile))
+	touch(time.Minute)
+	require.NoError(t, reloader.Reload(ctx))
+	assert.Equal(t, "renewed", commonName())
+
+	require.NoError(t, os.WriteFile(keyFile, []byte("garbage"), 0o600))
+	touch(2 * time.Minute)
+	assert.Error(t, reloader.Reload(ctx))
+	assert.Equal(t, "renewed", commonName(), "a broken pair keeps the previous certificate")
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/app"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+	"homework9/internal/tracing"
+	"homework9/middleware"
+)
+
+const (
+	parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID  = "00f067aa0ba902b7"
+	traceparent   = "00-" + parentTraceID + "-" + parentSpanID + "-01"
+)
+
+// recordSpans installs a tracer provider that keeps the ended spans.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	tracing.Setup(nil)
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+	})
+	return recorder
+}
+
+func newTracedApp() app.App {
+	return tracing.App(app.NewApp(
+		tracing.AdRepository(adrepo.New()),
+		tracing.UserRepository(userrepo.New()),
+		tracing.MessageRepository(messagerepo.New()),
+		tracing.ReportRepository(reportrepo.New()),
+	))
+}
+
+// spanTree maps the name of every span to the name of its parent, spans
+// without a parent in the recording map to their parent span ID.
+func spanTree(t *testing.T, spans []sdktrace.ReadOnlySpan) map[string]string {
+	names := make(map[string]string, len(spans))
+	for _, s := range spans {
+		assert.Equal(t, parentTraceID, s.SpanContext().TraceID().String(), s.Name())
+		names[s.SpanContext().SpanID().String()] = s.Name()
+	}
+	tree := make(map[string]string, len(spans))
+	for _, s := range spans {
+		parent := s.Parent().SpanID().String()
+		if name, ok := names[parent]; ok {
+			parent = name
+		}
+		tree[s.Name()] = parent
+	}
+	return tree
+}
+
+func TestHTTPTracing(t *testing.T) {
+	recorder := recordSpans(t)
+	server := httpgin.NewHTTPServer(":18080", newTracedApp())
+	testServer := httptest.NewServer(server.Handler())
+	t.Cleanup(testServer.Close)
+
+	req, err := http.NewRequest(http.MethodPost, testServer.URL+"/api/v1/users", strings.NewReader(`{"nickname":"nick","email":"mail"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", traceparent)
+	resp, err := testServer.Client().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, map[string]string{
+		"POST /api/v1/users":  parentSpanID,
+		"app.CreateUser":      "POST /api/v1/users",
+		"userrepo.CreateUser": "app.CreateUser",
+	}, spanTree(t, recorder.Ended()))
+}
+
+func TestGRPCTracing(t *testing.T) {
+	recorder := recordSpans(t)
+	client, ctx := getGRPCTestClient(t, newTracedApp(), grpc.ChainUnaryInterceptor(middleware.TracingUnaryServerInterceptor))
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "traceparent", traceparent)
+	_, err := client.GetUser(ctx, &grpcPort.GetUserRequest{Id: 100})
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	assert.Equal(t, map[string]string{
+		"/ad.AdService/GetUser": parentSpanID,
+		"app.GetUser":           "/ad.AdService/GetUser",
+		"userrepo.GetUser":      "app.GetUser",
+	}, spanTree(t, spans))
+	for _, s := range spans {
+		assert.Equal(t, codes.Error, s.Status().Code, s.Name())
+	}
+}
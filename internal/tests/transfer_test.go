@@ -0,0 +1,231 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+	"homework9/internal/users"
+)
+
+func fillForExport(t *testing.T, client *testClient) (userResponse, userResponse) {
+	admin, err := client.createAdmin("admin", "admin@mail.ru")
+	assert.NoError(t, err)
+	author, err := client.createUser("author", "author@mail.ru")
+	assert.NoError(t, err)
+	_, err = client.createAd(author.Data.ID, "hello", "world, with a comma")
+	assert.NoError(t, err)
+	_, err = client.createAd(author.Data.ID, "second", "ad")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(author.Data.ID, 1, true)
+	assert.NoError(t, err)
+	return admin, author
+}
+
+func TestExportJSONLines(t *testing.T) {
+	client := getTestClient()
+	admin, author := fillForExport(t, client)
+
+	_, _, err := client.exportData(author.Data.ID, "jsonl")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	data, header, err := client.exportData(admin.Data.ID, "jsonl")
+	assert.NoError(t, err)
+	assert.Equal(t, "application/x-ndjson", header.Get("Content-Type"))
+	lines, err := decodeLines(data)
+	assert.NoError(t, err)
+	assert.Len(t, lines, 4)
+	assert.Equal(t, "user", lines[0]["type"])
+	assert.Equal(t, "admin", lines[0]["role"])
+	assert.Equal(t, "ad", lines[2]["type"])
+	assert.Equal(t, "draft", lines[2]["status"])
+	assert.Equal(t, "published", lines[3]["status"])
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	for _, format := range []string{"jsonl", "csv"} {
+		t.Run(format, func(t *testing.T) {
+			client := getTestClient()
+			admin, _ := fillForExport(t, client)
+			data, _, err := client.exportData(admin.Data.ID, format)
+			assert.NoError(t, err)
+
+			target := getTestClient()
+			_, err = target.createUser("someone", "someone@mail.ru")
+			assert.NoError(t, err)
+			targetAdmin, err := target.createAdmin("target admin", "target@mail.ru")
+			assert.NoError(t, err)
+
+			res, err := target.importData(targetAdmin.Data.ID, format, false, data)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, res.Data.Users)
+			assert.Equal(t, 2, res.Data.Ads)
+			assert.Len(t, res.Data.Errors, 0)
+
+			ad, err := target.getAdByTitle("hello")
+			assert.NoError(t, err)
+			assert.Equal(t, "world, with a comma", ad.Data.Text)
+			assert.Equal(t, int64(3), ad.Data.AuthorID)
+			ad, err = target.getAdByTitle("second")
+			assert.NoError(t, err)
+			assert.True(t, ad.Data.Published)
+		})
+	}
+}
+
+func TestImportPreserveIDs(t *testing.T) {
+	client := getTestClient()
+	admin, err := client.createAdmin("admin", "admin@mail.ru")
+	assert.NoError(t, err)
+	data := `{"type":"user","id":10,"nickname":"old","email":"old@mail.ru"}
+{"type":"ad","id":20,"title":"kept","text":"ids","author_id":10,"status":"published"}
+{"type":"ad","id":20,"title":"twice","text":"ids","author_id":10}
+`
+	res, err := client.importData(admin.Data.ID, "jsonl", true, data)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Data.Users)
+	assert.Equal(t, 1, res.Data.Ads)
+	assert.Len(t, res.Data.Errors, 1)
+	assert.Equal(t, 3, res.Data.Errors[0].Line)
+
+	user, err := client.getUser(10)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", user.Data.Nickname)
+	ad, err := client.getAdByTitle("kept")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), ad.Data.AuthorID)
+
+	next, err := client.createUser("next", "next@mail.ru")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), next.Data.ID)
+}
+
+func TestImportLineErrors(t *testing.T) {
+	client := getTestClient()
+	admin, err := client.createAdmin("admin", "admin@mail.ru")
+	assert.NoError(t, err)
+	data := `type,id,nickname,email,title,text,author_id,status
+user,1,ivan,ivan@mail.ru,,,,
+ad,1,,,,empty title,1,
+ad,2,,,title,text,1,unknown
+ad,x,,,title,text,1,
+ad,3,,,title,text,42,
+ad,4,,,good,ad,1,draft
+`
+	res, err := client.importData(admin.Data.ID, "csv", false, data)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Data.Users)
+	assert.Equal(t, 1, res.Data.Ads)
+	lines := make([]int, len(res.Data.Errors))
+	for i, e := range res.Data.Errors {
+		lines[i] = e.Line
+	}
+	assert.Equal(t, []int{3, 4, 5, 6}, lines)
+	assert.Contains(t, res.Data.Errors[3].Error, "unknown author")
+
+	_, err = client.importData(admin.Data.ID, "xml", false, data)
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestImportRejectsAdsOfUnknownAuthors(t *testing.T) {
+	client := getTestClient()
+	admin, err := client.createAdmin("admin", "admin@mail.ru")
+	assert.NoError(t, err)
+	data := `{"type":"user","id":0,"nickname":"","email":"broken"}
+{"type":"ad","id":0,"title":"orphan","text":"ad","author_id":0}
+`
+	res, err := client.importData(admin.Data.ID, "jsonl", false, data)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, res.Data.Users)
+	assert.Equal(t, 0, res.Data.Ads)
+	assert.Len(t, res.Data.Errors, 2)
+	assert.Contains(t, res.Data.Errors[1].Error, "unknown author")
+
+	_, err = client.getAdByTitle("orphan")
+	assert.Error(t, err, "the ad is not attributed to the admin")
+}
+
+// slowExportApp takes longer to list the ads than the server may write.
+type slowExportApp struct {
+	app.App
+}
+
+func (a slowExportApp) ExportAds(ctx context.Context, adminID int64) ([]ads.Ad, error) {
+	time.Sleep(200 * time.Millisecond)
+	return a.App.ExportAds(ctx, adminID)
+}
+
+func TestExportOutlivesWriteTimeout(t *testing.T) {
+	userRepo := userrepo.New()
+	a := slowExportApp{app.NewApp(adrepo.New(), userRepo, messagerepo.New(), reportrepo.New())}
+	server := httpgin.NewHTTPServer(":18080", a)
+	testServer := httptest.NewUnstartedServer(server.Handler())
+	testServer.Config.WriteTimeout = 50 * time.Millisecond
+	testServer.Start()
+	t.Cleanup(testServer.Close)
+	client := &testClient{client: testServer.Client(), baseURL: testServer.URL, userRepo: userRepo}
+	admin, _ := fillForExport(t, client)
+
+	data, _, err := client.exportData(admin.Data.ID, "jsonl")
+	assert.NoError(t, err)
+	lines, err := decodeLines(data)
+	assert.NoError(t, err)
+	assert.Len(t, lines, 4)
+}
+
+func TestGRRPCExportImport(t *testing.T) {
+	userRepo := userrepo.New()
+	client, ctx := getGRPCTestClient(t, app.NewApp(adrepo.New(), userRepo, messagerepo.New(), reportrepo.New()))
+
+	admin, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Admin", Email: "admin@mail.ru"})
+	assert.NoError(t, err, "client.CreateUser")
+	_, err = userRepo.SetUserRole(ctx, admin.Id, users.RoleAdmin)
+	assert.NoError(t, err, "userRepo.SetUserRole")
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: admin.Id, Title: "hello", Text: "world"})
+	assert.NoError(t, err, "client.CreateAd")
+
+	stream, err := client.ExportData(ctx, &grpcPort.ExportRequest{AdminId: admin.Id + 1})
+	assert.NoError(t, err, "client.ExportData")
+	_, err = stream.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	stream, err = client.ExportData(ctx, &grpcPort.ExportRequest{AdminId: admin.Id, Format: "csv"})
+	assert.NoError(t, err, "client.ExportData")
+	var chunks []string
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err, "stream.Recv")
+		chunks = append(chunks, string(chunk.Data))
+	}
+	assert.Len(t, chunks, 2)
+	assert.True(t, strings.HasPrefix(chunks[0], "type,id,"))
+
+	res, err := client.ImportData(ctx, &grpcPort.ImportRequest{
+		AdminId: admin.Id,
+		Format:  "csv",
+		Data:    []byte(strings.Join(chunks, "")),
+	})
+	assert.NoError(t, err, "client.ImportData")
+	assert.Equal(t, int32(0), res.Users)
+	assert.Equal(t, int32(0), res.Ads)
+	assert.Len(t, res.Errors, 2)
+	assert.Equal(t, int32(2), res.Errors[0].Line)
+	assert.Equal(t, int32(3), res.Errors[1].Line, "the author was not imported")
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type importData struct {
+	Users  int `json:"users"`
+	Ads    int `json:"ads"`
+	Errors []struct {
+		Line  int    `json:"line"`
+		Error string `json:"error"`
+	} `json:"errors"`
+}
+
+type importResponse struct {
+	Data importData `json:"data"`
+}
+
+func (tc *testClient) exportData(adminID int64, format string) (string, http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/admin/export?admin_id=%d&format=%s", adminID, format), nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to create request: %w", err)
+	}
+	resp, err := tc.client.Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("unexpected error: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusForbidden {
+		return "", nil, ErrForbidden
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("unexpected status code: %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to read response: %w", err)
+	}
+	return string(data), resp.Header, nil
+}
+
+func (tc *testClient) importData(adminID int64, format string, preserveIDs bool, data string) (importResponse, error) {
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/admin/import?admin_id=%d&format=%s&preserve_ids=%t", adminID, format, preserveIDs), bytes.NewReader([]byte(data)))
+	if err != nil {
+		return importResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	var response importResponse
+	if err := tc.getResponse(req, &response); err != nil {
+		return importResponse{}, err
+	}
+	return response, nil
+}
+
+func decodeLines(data string) ([]map[string]any, error) {
+	var lines []map[string]any
+	dec := json.NewDecoder(bytes.NewReader([]byte(data)))
+	for dec.More() {
+		var line map[string]any
+		if err := dec.Decode(&line); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
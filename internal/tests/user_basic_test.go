@@ -1,9 +1,13 @@
 package tests
 
 import (
+	"context"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"homework9/internal/adapters/userrepo"
 )
 
 func TestCreateUser(t *testing.T) {
@@ -35,3 +39,25 @@ func TestDeleteUser(t *testing.T) {
 	_, err = client.getUser(response.Data.ID)
 	assert.Error(t, err)
 }
+
+func TestUserRepoConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	repo := userrepo.New()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			user, err := repo.CreateUser(ctx, "nick", fmt.Sprintf("user%d@mail.ru", i))
+			assert.NoError(t, err)
+			_, err = repo.GetUser(ctx, user.ID)
+			assert.NoError(t, err)
+			assert.Contains(t, repo.GetUsers(ctx), user.ID)
+			_, err = repo.CreateUser(ctx, "nick", fmt.Sprintf("user%d@mail.ru", i))
+			assert.Error(t, err, "the email is taken")
+			assert.NoError(t, repo.DeleteUser(ctx, user.ID))
+		}(i)
+	}
+	wg.Wait()
+	assert.Empty(t, repo.GetUsers(ctx))
+}
@@ -2,15 +2,19 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"homework9/internal/users"
 	"net/http"
 )
 
 type userData struct {
-	ID       int64  `json:"id"`
+	ID       int64  `json:"user_id"`
 	Nickname string `json:"nickname"`
 	Email    string `json:"email"`
+	Role     string `json:"role"`
+	Banned   bool   `json:"banned"`
 }
 
 type userResponse struct {
@@ -85,3 +89,61 @@ func (tc *testClient) deleteUser(id int64) (userResponse, error) {
 
 	return response, nil
 }
+
+// createAdmin creates a user and promotes it directly in the repository,
+// the same way the server binary seeds its first administrator.
+func (tc *testClient) createAdmin(nickname string, email string) (userResponse, error) {
+	response, err := tc.createUser(nickname, email)
+	if err != nil {
+		return userResponse{}, err
+	}
+	_, err = tc.userRepo.SetUserRole(context.Background(), response.Data.ID, users.RoleAdmin)
+	if err != nil {
+		return userResponse{}, err
+	}
+	return response, nil
+}
+
+func (tc *testClient) setUserRole(adminID int64, userID int64, role string) (userResponse, error) {
+	body := map[string]any{
+		"admin_id": adminID,
+		"role":     role,
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/admin/users/%d/role", userID), bytes.NewReader(data))
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+	return response, nil
+}
+
+func (tc *testClient) banUser(adminID int64, userID int64, banned bool) (userResponse, error) {
+	body := map[string]any{
+		"admin_id": adminID,
+		"banned":   banned,
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/admin/users/%d/ban", userID), bytes.NewReader(data))
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+	return response, nil
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
 	"homework9/internal/adapters/userrepo"
 	"homework9/internal/app"
 	"homework9/internal/ports/httpgin"
@@ -16,19 +18,34 @@ import (
 var (
 	ErrBadRequest = fmt.Errorf("bad request")
 	ErrForbidden  = fmt.Errorf("forbidden")
+	ErrNotFound   = fmt.Errorf("not found")
+	ErrConflict   = fmt.Errorf("conflict")
+	ErrTooMany    = fmt.Errorf("too many requests")
 )
 
+// errorResponse is the error envelope, reasons are only set by the content filters.
+type errorResponse struct {
+	Error   string `json:"error"`
+	Reasons []struct {
+		Code  string `json:"code"`
+		Field string `json:"field"`
+	} `json:"reasons"`
+}
+
 type testClient struct {
-	client  *http.Client
-	baseURL string
+	client   *http.Client
+	baseURL  string
+	userRepo app.UserRepository
 }
 
-func getTestClient() *testClient {
-	server := httpgin.NewHTTPServer(":18080", app.NewApp(adrepo.New(), userrepo.New()))
+func getTestClient(opts ...app.Option) *testClient {
+	userRepo := userrepo.New()
+	server := httpgin.NewHTTPServer(":18080", app.NewApp(adrepo.New(), userRepo, messagerepo.New(), reportrepo.New(), opts...))
 	testServer := httptest.NewServer(server.Handler())
 	client := &testClient{
-		client:  testServer.Client(),
-		baseURL: testServer.URL,
+		client:   testServer.Client(),
+		baseURL:  testServer.URL,
+		userRepo: userRepo,
 	}
 	//client.createUser("Admin", "adminEmail")   //nolint:errcheck
 	//client.createUser("Admin2", "adminEmail2") //nolint:errcheck
@@ -48,6 +65,15 @@ func (tc *testClient) getResponse(req *http.Request, out any) error {
 		if resp.StatusCode == http.StatusForbidden {
 			return ErrForbidden
 		}
+		if resp.StatusCode == http.StatusNotFound {
+			return ErrNotFound
+		}
+		if resp.StatusCode == http.StatusConflict {
+			return ErrConflict
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return ErrTooMany
+		}
 		return fmt.Errorf("unexpected status code: %s", resp.Status)
 	}
 
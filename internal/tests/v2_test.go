@@ -0,0 +1,297 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/messagerepo"
+	"homework9/internal/adapters/reportrepo"
+	"homework9/internal/adapters/userrepo"
+	"homework9/internal/app"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/grpcv2"
+	"homework9/internal/ports/httpgin"
+	"homework9/middleware"
+)
+
+type v2Ad struct {
+	ID        int64             `json:"id"`
+	Title     string            `json:"title"`
+	AuthorID  int64             `json:"author_id"`
+	Status    string            `json:"status"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Links     map[string]string `json:"links"`
+}
+
+type v2Response struct {
+	Data  json.RawMessage   `json:"data"`
+	Meta  map[string]int    `json:"meta"`
+	Links map[string]string `json:"links"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func getVersionedServer(t *testing.T, opts ...app.Option) *httptest.Server {
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New(), opts...)
+	server := httpgin.NewHTTPServer(":18080", a)
+	testServer := httptest.NewServer(server.Handler())
+	t.Cleanup(testServer.Close)
+	return testServer
+}
+
+// callV2 sends the request as user, a negative user sends no X-User-ID.
+func callV2(t *testing.T, server *httptest.Server, user int64, method string, path string, body string) (*http.Response, v2Response) {
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if user >= 0 {
+		req.Header.Set(middleware.UserIDHeader, strconv.FormatInt(user, 10))
+	}
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var out v2Response
+	if len(data) > 0 {
+		require.NoError(t, json.Unmarshal(data, &out), string(data))
+	}
+	return resp, out
+}
+
+func TestV2Ads(t *testing.T) {
+	server := getVersionedServer(t)
+	resp, out := callV2(t, server, -1, http.MethodPost, "/api/v2/users", `{"nickname":"nick","email":"mail"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/api/v2/users/0", resp.Header.Get("Location"))
+	assert.Contains(t, string(out.Data), `"ads":"/api/v2/ads?author_id=0"`)
+
+	resp, out = callV2(t, server, 0, http.MethodPost, "/api/v2/ads", `{"title":"hello","text":"world"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var ad v2Ad
+	require.NoError(t, json.Unmarshal(out.Data, &ad))
+	assert.Equal(t, "draft", ad.Status)
+	assert.False(t, ad.CreatedAt.IsZero())
+	assert.Equal(t, "/api/v2/ads/0", ad.Links["self"])
+	assert.Equal(t, "/api/v2/users/0", ad.Links["author"])
+	assert.Equal(t, "/api/v2/ads/0/publish", ad.Links["publish"])
+
+	resp, out = callV2(t, server, 0, http.MethodPost, "/api/v2/ads/0/publish", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	ad = v2Ad{}
+	require.NoError(t, json.Unmarshal(out.Data, &ad))
+	assert.Equal(t, "published", ad.Status)
+	assert.Equal(t, "/api/v2/ads/0/unpublish", ad.Links["unpublish"])
+	assert.NotContains(t, ad.Links, "publish")
+
+	resp, out = callV2(t, server, 0, http.MethodPatch, "/api/v2/ads/0", `{"text":"new text"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.Unmarshal(out.Data, &ad))
+	assert.Equal(t, "hello", ad.Title, "fields that are not sent stay")
+	assert.False(t, ad.UpdatedAt.Before(ad.CreatedAt))
+
+	resp, _ = callV2(t, server, 1, http.MethodDelete, "/api/v2/ads/0", "")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp, out = callV2(t, server, 0, http.MethodDelete, "/api/v2/ads/0", "")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, out = callV2(t, server, -1, http.MethodGet, "/api/v2/ads/0", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	require.NotNil(t, out.Error)
+	assert.Equal(t, "not_found", out.Error.Code)
+}
+
+func TestV2Pagination(t *testing.T) {
+	server := getVersionedServer(t)
+	callV2(t, server, -1, http.MethodPost, "/api/v2/users", `{"nickname":"nick","email":"mail"}`)
+	for i := 0; i < 5; i++ {
+		resp, _ := callV2(t, server, 0, http.MethodPost, "/api/v2/ads", `{"title":"ad `+strconv.Itoa(i)+`","text":"text"}`)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp, _ = callV2(t, server, 0, http.MethodPost, "/api/v2/ads/"+strconv.Itoa(i)+"/publish", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	resp, out := callV2(t, server, -1, http.MethodGet, "/api/v2/ads?per_page=2&page=2&author_id=0", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var page []v2Ad
+	require.NoError(t, json.Unmarshal(out.Data, &page))
+	require.Len(t, page, 2)
+	assert.Equal(t, "ad 2", page[0].Title)
+	assert.Equal(t, map[string]int{"page": 2, "per_page": 2, "total": 5, "total_pages": 3}, out.Meta)
+	assert.Equal(t, "/api/v2/ads?author_id=0&page=3&per_page=2", out.Links["next"])
+	assert.Equal(t, "/api/v2/ads?author_id=0&page=1&per_page=2", out.Links["prev"])
+	assert.Equal(t, "/api/v2/ads?author_id=0&page=3&per_page=2", out.Links["last"])
+
+	resp, out = callV2(t, server, -1, http.MethodGet, "/api/v2/ads?per_page=2&page=3", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.Unmarshal(out.Data, &page))
+	assert.Len(t, page, 1)
+	assert.NotContains(t, out.Links, "next")
+
+	resp, out = callV2(t, server, -1, http.MethodGet, "/api/v2/ads?per_page=1000", "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "bad_request", out.Error.Code)
+}
+
+func TestV2Errors(t *testing.T) {
+	server := getVersionedServer(t, app.WithDailyAdQuota(1))
+	callV2(t, server, -1, http.MethodPost, "/api/v2/users", `{"nickname":"nick","email":"mail"}`)
+
+	for _, tc := range []struct {
+		user   int64
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{-1, http.MethodPost, "/api/v2/ads", `{"title":"hello","text":"world"}`, http.StatusUnauthorized, "unauthenticated"},
+		{0, http.MethodPost, "/api/v2/ads", `{"title":"","text":"world"}`, http.StatusBadRequest, "validation_failed"},
+		{0, http.MethodPost, "/api/v2/ads", `{"title":`, http.StatusBadRequest, "bad_request"},
+		{0, http.MethodPost, "/api/v2/ads", `{"title":"hello","text":"world"}`, http.StatusCreated, ""},
+		{0, http.MethodPost, "/api/v2/ads", `{"title":"hello","text":"world"}`, http.StatusTooManyRequests, "quota_exceeded"},
+		{0, http.MethodPost, "/api/v2/ads/0/publish", "", http.StatusOK, ""},
+		{0, http.MethodPost, "/api/v2/ads/0/publish", `{"publish_at":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`, http.StatusConflict, "conflict"},
+		{0, http.MethodPost, "/api/v2/ads/5/publish", "", http.StatusNotFound, "not_found"},
+		{0, http.MethodGet, "/api/v2/users/7", "", http.StatusNotFound, "not_found"},
+		{0, http.MethodGet, "/api/v2/users/x", "", http.StatusBadRequest, "bad_request"},
+		{-1, http.MethodDelete, "/api/v2/users/0", "", http.StatusUnauthorized, "unauthenticated"},
+		{5, http.MethodDelete, "/api/v2/users/0", "", http.StatusForbidden, "forbidden"},
+	} {
+		resp, out := callV2(t, server, tc.user, tc.method, tc.path, tc.body)
+		assert.Equal(t, tc.status, resp.StatusCode, "%s %s %s", tc.method, tc.path, tc.body)
+		if tc.code == "" {
+			continue
+		}
+		if assert.NotNil(t, out.Error, "%s %s %s", tc.method, tc.path, tc.body) {
+			assert.Equal(t, tc.code, out.Error.Code, "%s %s %s", tc.method, tc.path, tc.body)
+			assert.NotEmpty(t, out.Error.Message)
+		}
+		assert.Nil(t, out.Data)
+	}
+
+	// the middleware answers in the envelope of the version
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/api/v2/ads", nil)
+	require.NoError(t, err)
+	req.Header.Set(middleware.UserIDHeader, "nobody")
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.JSONEq(t, `{"error":{"code":"unauthenticated","message":"invalid X-User-ID"}}`, string(body))
+
+	req.URL.Path = "/api/v1/ads"
+	resp, err = server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":null,"error":"invalid X-User-ID"}`, string(body))
+}
+
+// TestVersionsSideBySide changes the same app through both versions.
+func TestVersionsSideBySide(t *testing.T) {
+	server := getVersionedServer(t)
+	client := &restClient{t: t, server: server, user: -1}
+	client.ok(http.MethodPost, "/users", `{"nickname":"nick","email":"mail"}`)
+	client.as(0).ok(http.MethodPost, "/ads", `{"title":"from v1","text":"text"}`)
+
+	resp, out := callV2(t, server, 0, http.MethodPost, "/api/v2/ads/0/publish", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = callV2(t, server, 0, http.MethodPost, "/api/v2/ads", `{"title":"from v2","text":"text"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var v1 adData
+	require.NoError(t, json.Unmarshal(client.ok(http.MethodGet, "/ads/0", ""), &v1))
+	assert.True(t, v1.Published)
+	require.NoError(t, json.Unmarshal(client.as(0).ok(http.MethodGet, "/ads/1", ""), &v1))
+	assert.Equal(t, "from v2", v1.Title)
+
+	resp, out = callV2(t, server, -1, http.MethodGet, "/api/v2/ads", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list []v2Ad
+	require.NoError(t, json.Unmarshal(out.Data, &list))
+	require.Len(t, list, 1)
+	assert.Equal(t, "from v1", list[0].Title)
+
+	resp, _ = client.do(http.MethodGet, "/ads/9", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestGRPCVersionsSideBySide(t *testing.T) {
+	a := app.NewApp(adrepo.New(), userrepo.New(), messagerepo.New(), reportrepo.New())
+	dialer := serveGRPC(t, a, grpc.ChainUnaryInterceptor(middleware.ActorUnaryServerInterceptor))
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+	conn, err := grpc.DialContext(ctx, "", grpc.WithContextDialer(dialer), grpc.WithInsecure()) //nolint:all
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	v1 := grpcPort.NewAdServiceClient(conn)
+	v2 := grpcv2.NewAdServiceClient(conn)
+
+	_, err = v2.CreateUser(ctx, &grpcv2.CreateUserRequest{Nickname: "nick", Email: "mail"})
+	require.NoError(t, err)
+	_, err = v2.CreateAd(ctx, &grpcv2.CreateAdRequest{Title: "hello", Text: "world"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "no x-user-id")
+
+	asUser := metadata.AppendToOutgoingContext(ctx, middleware.UserIDMetadata, "0")
+	for i := 0; i < 3; i++ {
+		ad, err := v2.CreateAd(asUser, &grpcv2.CreateAdRequest{Title: "ad " + strconv.Itoa(i), Text: "text"})
+		require.NoError(t, err)
+		assert.Equal(t, grpcv2.AdStatus_AD_STATUS_DRAFT, ad.Status)
+		assert.NotNil(t, ad.CreateTime)
+		_, err = v1.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, UserId: 0, Published: true})
+		require.NoError(t, err)
+	}
+
+	ad, err := v2.UpdateAd(asUser, &grpcv2.UpdateAdRequest{Id: 0, Text: proto.String("new text")})
+	require.NoError(t, err)
+	assert.Equal(t, "ad 0", ad.Title)
+	assert.Equal(t, grpcv2.AdStatus_AD_STATUS_PUBLISHED, ad.Status)
+	v1Ad, err := v1.GetAd(ctx, &grpcPort.GetAdRequest{AdId: 0})
+	require.NoError(t, err)
+	assert.Equal(t, "new text", v1Ad.Text)
+
+	page, err := v2.ListAds(ctx, &grpcv2.ListAdsRequest{PageSize: 2})
+	require.NoError(t, err)
+	assert.Len(t, page.Ads, 2)
+	assert.EqualValues(t, 3, page.TotalSize)
+	require.NotEmpty(t, page.NextPageToken)
+	page, err = v2.ListAds(ctx, &grpcv2.ListAdsRequest{PageSize: 2, PageToken: page.NextPageToken})
+	require.NoError(t, err)
+	assert.Len(t, page.Ads, 1)
+	assert.Empty(t, page.NextPageToken)
+	page, err = v2.ListAds(ctx, &grpcv2.ListAdsRequest{PageSize: 2, PageToken: strconv.Itoa(math.MaxInt)})
+	require.NoError(t, err, "a token past the end")
+	assert.Empty(t, page.Ads)
+	assert.Empty(t, page.NextPageToken)
+
+	_, err = v2.DeleteAd(asUser, &grpcv2.DeleteAdRequest{Id: 2})
+	require.NoError(t, err)
+	_, err = v2.GetAd(ctx, &grpcv2.GetAdRequest{Id: 2})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = v1.GetAd(ctx, &grpcPort.GetAdRequest{AdId: 2})
+	assert.Equal(t, codes.NotFound, status.Code(err), "v1 answers NotFound too")
+}
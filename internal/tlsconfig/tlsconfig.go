@@ -0,0 +1,147 @@
+// Package tlsconfig builds the TLS configs of the servers and reloads the
+// certificate when its files change, so renewing it needs no restart.
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reloader serves the current key pair to TLS handshakes.
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// NewReloader loads the key pair, it fails when the files are unusable so
+// that a bad config is found at start.
+func NewReloader(certFile string, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the files again when one of them changed since the last
+// load. A broken pair is reported and the previous one is kept, a renewal
+// written in two steps is picked up once both files are in place.
+func (r *Reloader) Reload(ctx context.Context) error {
+	modTime, err := latestModTime(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.mu.RLock()
+	unchanged := r.cert != nil && modTime.Equal(r.modTime)
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load key pair: %w", err)
+	}
+	r.mu.Lock()
+	r.cert, r.modTime = &cert, modTime
+	r.mu.Unlock()
+	slog.InfoContext(ctx, "tls certificate loaded", "cert_file", r.certFile)
+	return nil
+}
+
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Server returns the config of a server using the reloaded certificate.
+// With clientCAFile set clients must present a certificate signed by it.
+func Server(r *Reloader, clientCAFile string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.GetCertificate,
+	}
+	if clientCAFile != "" {
+		pool, err := loadPool(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// Client returns the config of a client trusting caFile, or the system
+// roots when it is empty, and presenting certFile when it is set.
+func Client(caFile string, certFile string, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pool, err := loadPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load key pair: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+func loadPool(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s: no certificates found", file)
+	}
+	return pool, nil
+}
+
+// Identity names the client of a verified certificate: the common name,
+// or the first DNS name when the certificate has no common name.
+func Identity(state tls.ConnectionState) (string, error) {
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return "", errors.New("no verified client certificate")
+	}
+	leaf := state.VerifiedChains[0][0]
+	if leaf.Subject.CommonName != "" {
+		return leaf.Subject.CommonName, nil
+	}
+	if len(leaf.DNSNames) > 0 {
+		return leaf.DNSNames[0], nil
+	}
+	return "", errors.New("client certificate names nobody")
+}
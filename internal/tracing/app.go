@@ -0,0 +1,271 @@
+package tracing
+
+import (
+	"context"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/messages"
+	"homework9/internal/reports"
+	"homework9/internal/users"
+	"time"
+)
+
+// App records a span for every call of a.
+func App(a app.App) app.App {
+	return tracedApp{next: a}
+}
+
+type tracedApp struct {
+	next app.App
+}
+
+func (t tracedApp) CreateAd(ctx context.Context, Title string, Text string, UserID int64) (ads.Ad, error) {
+	ctx, span := Start(ctx, "app.CreateAd")
+	res, err := t.next.CreateAd(ctx, Title, Text, UserID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) ChangeAdStatus(ctx context.Context, adID int64, UserID int64, Published bool) (ads.Ad, error) {
+	ctx, span := Start(ctx, "app.ChangeAdStatus")
+	res, err := t.next.ChangeAdStatus(ctx, adID, UserID, Published)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) UpdateAd(ctx context.Context, adID int64, UserID int64, Title string, Text string) (ads.Ad, error) {
+	ctx, span := Start(ctx, "app.UpdateAd")
+	res, err := t.next.UpdateAd(ctx, adID, UserID, Title, Text)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) CreateUser(ctx context.Context, Nickname string, Email string) (users.User, error) {
+	ctx, span := Start(ctx, "app.CreateUser")
+	res, err := t.next.CreateUser(ctx, Nickname, Email)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) DeleteUser(ctx context.Context, actorID int64, ID int64) error {
+	ctx, span := Start(ctx, "app.DeleteUser")
+	err := t.next.DeleteUser(ctx, actorID, ID)
+	end(span, err)
+	return err
+}
+
+func (t tracedApp) GetUser(ctx context.Context, ID int64) (users.User, error) {
+	ctx, span := Start(ctx, "app.GetUser")
+	res, err := t.next.GetUser(ctx, ID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) GetAd(ctx context.Context, index int64, UserID int64) (ads.Ad, error) {
+	ctx, span := Start(ctx, "app.GetAd")
+	res, err := t.next.GetAd(ctx, index, UserID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) GetAdByTitle(ctx context.Context, Title string) (ads.Ad, error) {
+	ctx, span := Start(ctx, "app.GetAdByTitle")
+	res, err := t.next.GetAdByTitle(ctx, Title)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) GetUsers(ctx context.Context) map[int64]users.User {
+	ctx, span := Start(ctx, "app.GetUsers")
+	defer span.End()
+	return t.next.GetUsers(ctx)
+}
+
+func (t tracedApp) GetAds(ctx context.Context) ([]ads.Ad, error) {
+	ctx, span := Start(ctx, "app.GetAds")
+	res, err := t.next.GetAds(ctx)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) GetAdsPrams(ctx context.Context, param map[string]interface{}) ([]ads.Ad, error) {
+	ctx, span := Start(ctx, "app.GetAdsPrams")
+	res, err := t.next.GetAdsPrams(ctx, param)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) DeleteAd(ctx context.Context, adID int64, userID int64) error {
+	ctx, span := Start(ctx, "app.DeleteAd")
+	err := t.next.DeleteAd(ctx, adID, userID)
+	end(span, err)
+	return err
+}
+
+func (t tracedApp) SendMessageToAd(ctx context.Context, adID int64, UserID int64, Text string) (messages.Message, error) {
+	ctx, span := Start(ctx, "app.SendMessageToAd")
+	res, err := t.next.SendMessageToAd(ctx, adID, UserID, Text)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) SendMessage(ctx context.Context, conversationID int64, UserID int64, Text string) (messages.Message, error) {
+	ctx, span := Start(ctx, "app.SendMessage")
+	res, err := t.next.SendMessage(ctx, conversationID, UserID, Text)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) GetMessages(ctx context.Context, conversationID int64, UserID int64) ([]messages.Message, error) {
+	ctx, span := Start(ctx, "app.GetMessages")
+	res, err := t.next.GetMessages(ctx, conversationID, UserID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) GetInbox(ctx context.Context, actorID int64, UserID int64) ([]messages.Conversation, error) {
+	ctx, span := Start(ctx, "app.GetInbox")
+	res, err := t.next.GetInbox(ctx, actorID, UserID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) GetAdConversations(ctx context.Context, adID int64, UserID int64) ([]messages.Conversation, error) {
+	ctx, span := Start(ctx, "app.GetAdConversations")
+	res, err := t.next.GetAdConversations(ctx, adID, UserID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) SetUserRole(ctx context.Context, adminID int64, UserID int64, Role users.Role) (users.User, error) {
+	ctx, span := Start(ctx, "app.SetUserRole")
+	res, err := t.next.SetUserRole(ctx, adminID, UserID, Role)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) BanUser(ctx context.Context, adminID int64, UserID int64, Banned bool) (users.User, error) {
+	ctx, span := Start(ctx, "app.BanUser")
+	res, err := t.next.BanUser(ctx, adminID, UserID, Banned)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) GetModerationQueue(ctx context.Context, moderatorID int64) ([]ads.Ad, error) {
+	ctx, span := Start(ctx, "app.GetModerationQueue")
+	res, err := t.next.GetModerationQueue(ctx, moderatorID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) ApproveAd(ctx context.Context, adID int64, moderatorID int64) (ads.Ad, error) {
+	ctx, span := Start(ctx, "app.ApproveAd")
+	res, err := t.next.ApproveAd(ctx, adID, moderatorID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) RejectAd(ctx context.Context, adID int64, moderatorID int64, Reason string) (ads.Ad, error) {
+	ctx, span := Start(ctx, "app.RejectAd")
+	res, err := t.next.RejectAd(ctx, adID, moderatorID, Reason)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) ReportAd(ctx context.Context, adID int64, UserID int64, Reason reports.Reason, Comment string) (reports.Report, error) {
+	ctx, span := Start(ctx, "app.ReportAd")
+	res, err := t.next.ReportAd(ctx, adID, UserID, Reason, Comment)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) GetReportedAds(ctx context.Context, moderatorID int64) ([]reports.Summary, error) {
+	ctx, span := Start(ctx, "app.GetReportedAds")
+	res, err := t.next.GetReportedAds(ctx, moderatorID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) ResolveReports(ctx context.Context, adID int64, moderatorID int64, Resolution reports.Resolution, Comment string) (ads.Ad, error) {
+	ctx, span := Start(ctx, "app.ResolveReports")
+	res, err := t.next.ResolveReports(ctx, adID, moderatorID, Resolution, Comment)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) RenewAd(ctx context.Context, adID int64, UserID int64) (ads.Ad, error) {
+	ctx, span := Start(ctx, "app.RenewAd")
+	res, err := t.next.RenewAd(ctx, adID, UserID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) ExpireAds(ctx context.Context) error {
+	ctx, span := Start(ctx, "app.ExpireAds")
+	err := t.next.ExpireAds(ctx)
+	end(span, err)
+	return err
+}
+
+func (t tracedApp) ScheduleAd(ctx context.Context, adID int64, UserID int64, PublishAt time.Time) (ads.Ad, error) {
+	ctx, span := Start(ctx, "app.ScheduleAd")
+	res, err := t.next.ScheduleAd(ctx, adID, UserID, PublishAt)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) CancelScheduledAd(ctx context.Context, adID int64, UserID int64) (ads.Ad, error) {
+	ctx, span := Start(ctx, "app.CancelScheduledAd")
+	res, err := t.next.CancelScheduledAd(ctx, adID, UserID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) PublishScheduledAds(ctx context.Context) error {
+	ctx, span := Start(ctx, "app.PublishScheduledAds")
+	err := t.next.PublishScheduledAds(ctx)
+	end(span, err)
+	return err
+}
+
+func (t tracedApp) CreateAds(ctx context.Context, UserID int64, items []app.NewAd, atomic bool) ([]app.BatchResult, error) {
+	ctx, span := Start(ctx, "app.CreateAds")
+	res, err := t.next.CreateAds(ctx, UserID, items, atomic)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) ChangeAdsStatus(ctx context.Context, adIDs []int64, UserID int64, Published bool, atomic bool) ([]app.BatchResult, error) {
+	ctx, span := Start(ctx, "app.ChangeAdsStatus")
+	res, err := t.next.ChangeAdsStatus(ctx, adIDs, UserID, Published, atomic)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) DeleteAds(ctx context.Context, adIDs []int64, UserID int64, atomic bool) ([]app.BatchResult, error) {
+	ctx, span := Start(ctx, "app.DeleteAds")
+	res, err := t.next.DeleteAds(ctx, adIDs, UserID, atomic)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) ExportUsers(ctx context.Context, adminID int64) ([]users.User, error) {
+	ctx, span := Start(ctx, "app.ExportUsers")
+	res, err := t.next.ExportUsers(ctx, adminID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) ExportAds(ctx context.Context, adminID int64) ([]ads.Ad, error) {
+	ctx, span := Start(ctx, "app.ExportAds")
+	res, err := t.next.ExportAds(ctx, adminID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedApp) Import(ctx context.Context, adminID int64, Users []users.User, Ads []ads.Ad, PreserveIDs bool) (app.ImportResult, error) {
+	ctx, span := Start(ctx, "app.Import")
+	res, err := t.next.Import(ctx, adminID, Users, Ads, PreserveIDs)
+	end(span, err)
+	return res, err
+}
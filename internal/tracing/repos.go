@@ -0,0 +1,269 @@
+package tracing
+
+import (
+	"context"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/messages"
+	"homework9/internal/reports"
+	"homework9/internal/users"
+	"time"
+)
+
+// AdRepository, UserRepository, MessageRepository and ReportRepository
+// record a span for every call of the repository they wrap.
+
+func AdRepository(r app.AdRepository) app.AdRepository {
+	return tracedAdRepo{next: r}
+}
+
+type tracedAdRepo struct {
+	next app.AdRepository
+}
+
+func UserRepository(r app.UserRepository) app.UserRepository {
+	return tracedUserRepo{next: r}
+}
+
+type tracedUserRepo struct {
+	next app.UserRepository
+}
+
+func MessageRepository(r app.MessageRepository) app.MessageRepository {
+	return tracedMessageRepo{next: r}
+}
+
+type tracedMessageRepo struct {
+	next app.MessageRepository
+}
+
+func ReportRepository(r app.ReportRepository) app.ReportRepository {
+	return tracedReportRepo{next: r}
+}
+
+type tracedReportRepo struct {
+	next app.ReportRepository
+}
+
+func (t tracedAdRepo) CreateAd(ctx context.Context, Title string, Text string, UserID int64) (ads.Ad, error) {
+	ctx, span := Start(ctx, "adrepo.CreateAd")
+	res, err := t.next.CreateAd(ctx, Title, Text, UserID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedAdRepo) ChangeAdStatus(ctx context.Context, adID int64, Status ads.Status, Reason string) (ads.Ad, error) {
+	ctx, span := Start(ctx, "adrepo.ChangeAdStatus")
+	res, err := t.next.ChangeAdStatus(ctx, adID, Status, Reason)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedAdRepo) UpdateAd(ctx context.Context, adID int64, Title string, Text string) (ads.Ad, error) {
+	ctx, span := Start(ctx, "adrepo.UpdateAd")
+	res, err := t.next.UpdateAd(ctx, adID, Title, Text)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedAdRepo) GetAd(ctx context.Context, index int64) (ads.Ad, error) {
+	ctx, span := Start(ctx, "adrepo.GetAd")
+	res, err := t.next.GetAd(ctx, index)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedAdRepo) GetAdByTitle(ctx context.Context, Title string) (ads.Ad, error) {
+	ctx, span := Start(ctx, "adrepo.GetAdByTitle")
+	res, err := t.next.GetAdByTitle(ctx, Title)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedAdRepo) GetAds(ctx context.Context) ([]ads.Ad, error) {
+	ctx, span := Start(ctx, "adrepo.GetAds")
+	res, err := t.next.GetAds(ctx)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedAdRepo) GetAdsByStatus(ctx context.Context, Status ads.Status) ([]ads.Ad, error) {
+	ctx, span := Start(ctx, "adrepo.GetAdsByStatus")
+	res, err := t.next.GetAdsByStatus(ctx, Status)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedAdRepo) GetAdsByAuthor(ctx context.Context, authorID int64) ([]ads.Ad, error) {
+	ctx, span := Start(ctx, "adrepo.GetAdsByAuthor")
+	res, err := t.next.GetAdsByAuthor(ctx, authorID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedAdRepo) SetAdExpiration(ctx context.Context, adID int64, ExpiresAt time.Time, ExpiryNotified bool) (ads.Ad, error) {
+	ctx, span := Start(ctx, "adrepo.SetAdExpiration")
+	res, err := t.next.SetAdExpiration(ctx, adID, ExpiresAt, ExpiryNotified)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedAdRepo) SetAdPublishAt(ctx context.Context, adID int64, PublishAt time.Time) (ads.Ad, error) {
+	ctx, span := Start(ctx, "adrepo.SetAdPublishAt")
+	res, err := t.next.SetAdPublishAt(ctx, adID, PublishAt)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedAdRepo) GetScheduledAds(ctx context.Context, before time.Time) ([]ads.Ad, error) {
+	ctx, span := Start(ctx, "adrepo.GetScheduledAds")
+	res, err := t.next.GetScheduledAds(ctx, before)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedAdRepo) GetAllAds(ctx context.Context) ([]ads.Ad, error) {
+	ctx, span := Start(ctx, "adrepo.GetAllAds")
+	res, err := t.next.GetAllAds(ctx)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedAdRepo) ImportAd(ctx context.Context, ad ads.Ad, PreserveID bool) (ads.Ad, error) {
+	ctx, span := Start(ctx, "adrepo.ImportAd")
+	res, err := t.next.ImportAd(ctx, ad, PreserveID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedAdRepo) DeleteAd(ctx context.Context, adID int64) error {
+	ctx, span := Start(ctx, "adrepo.DeleteAd")
+	err := t.next.DeleteAd(ctx, adID)
+	end(span, err)
+	return err
+}
+
+func (t tracedUserRepo) CreateUser(ctx context.Context, Nickname string, Email string) (users.User, error) {
+	ctx, span := Start(ctx, "userrepo.CreateUser")
+	res, err := t.next.CreateUser(ctx, Nickname, Email)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedUserRepo) DeleteUser(ctx context.Context, ID int64) error {
+	ctx, span := Start(ctx, "userrepo.DeleteUser")
+	err := t.next.DeleteUser(ctx, ID)
+	end(span, err)
+	return err
+}
+
+func (t tracedUserRepo) GetUser(ctx context.Context, ID int64) (users.User, error) {
+	ctx, span := Start(ctx, "userrepo.GetUser")
+	res, err := t.next.GetUser(ctx, ID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedUserRepo) GetUsers(ctx context.Context) map[int64]users.User {
+	ctx, span := Start(ctx, "userrepo.GetUsers")
+	defer span.End()
+	return t.next.GetUsers(ctx)
+}
+
+func (t tracedUserRepo) SetUserRole(ctx context.Context, ID int64, Role users.Role) (users.User, error) {
+	ctx, span := Start(ctx, "userrepo.SetUserRole")
+	res, err := t.next.SetUserRole(ctx, ID, Role)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedUserRepo) SetUserBanned(ctx context.Context, ID int64, Banned bool) (users.User, error) {
+	ctx, span := Start(ctx, "userrepo.SetUserBanned")
+	res, err := t.next.SetUserBanned(ctx, ID, Banned)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedUserRepo) ImportUser(ctx context.Context, user users.User, PreserveID bool) (users.User, error) {
+	ctx, span := Start(ctx, "userrepo.ImportUser")
+	res, err := t.next.ImportUser(ctx, user, PreserveID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedMessageRepo) GetOrCreateConversation(ctx context.Context, adID int64, buyerID int64, sellerID int64) (messages.Conversation, error) {
+	ctx, span := Start(ctx, "messagerepo.GetOrCreateConversation")
+	res, err := t.next.GetOrCreateConversation(ctx, adID, buyerID, sellerID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedMessageRepo) GetConversation(ctx context.Context, ID int64) (messages.Conversation, error) {
+	ctx, span := Start(ctx, "messagerepo.GetConversation")
+	res, err := t.next.GetConversation(ctx, ID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedMessageRepo) GetConversationsByUser(ctx context.Context, userID int64) ([]messages.Conversation, error) {
+	ctx, span := Start(ctx, "messagerepo.GetConversationsByUser")
+	res, err := t.next.GetConversationsByUser(ctx, userID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedMessageRepo) GetConversationsByAd(ctx context.Context, adID int64) ([]messages.Conversation, error) {
+	ctx, span := Start(ctx, "messagerepo.GetConversationsByAd")
+	res, err := t.next.GetConversationsByAd(ctx, adID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedMessageRepo) CreateMessage(ctx context.Context, conversationID int64, senderID int64, Text string) (messages.Message, error) {
+	ctx, span := Start(ctx, "messagerepo.CreateMessage")
+	res, err := t.next.CreateMessage(ctx, conversationID, senderID, Text)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedMessageRepo) GetMessages(ctx context.Context, conversationID int64) ([]messages.Message, error) {
+	ctx, span := Start(ctx, "messagerepo.GetMessages")
+	res, err := t.next.GetMessages(ctx, conversationID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedMessageRepo) MarkRead(ctx context.Context, conversationID int64, readerID int64) error {
+	ctx, span := Start(ctx, "messagerepo.MarkRead")
+	err := t.next.MarkRead(ctx, conversationID, readerID)
+	end(span, err)
+	return err
+}
+
+func (t tracedReportRepo) CreateReport(ctx context.Context, adID int64, reporterID int64, Reason reports.Reason, Comment string) (reports.Report, error) {
+	ctx, span := Start(ctx, "reportrepo.CreateReport")
+	res, err := t.next.CreateReport(ctx, adID, reporterID, Reason, Comment)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedReportRepo) GetOpenReports(ctx context.Context) ([]reports.Report, error) {
+	ctx, span := Start(ctx, "reportrepo.GetOpenReports")
+	res, err := t.next.GetOpenReports(ctx)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedReportRepo) GetOpenReportsByAd(ctx context.Context, adID int64) ([]reports.Report, error) {
+	ctx, span := Start(ctx, "reportrepo.GetOpenReportsByAd")
+	res, err := t.next.GetOpenReportsByAd(ctx, adID)
+	end(span, err)
+	return res, err
+}
+
+func (t tracedReportRepo) ResolveReports(ctx context.Context, adID int64, Resolution reports.Resolution) error {
+	ctx, span := Start(ctx, "reportrepo.ResolveReports")
+	err := t.next.ResolveReports(ctx, adID, Resolution)
+	end(span, err)
+	return err
+}
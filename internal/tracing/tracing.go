@@ -0,0 +1,96 @@
+// Package tracing records OpenTelemetry spans for the ports, the app and
+// the repositories. The W3C traceparent header links the spans of one
+// request, also across services.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "homework9"
+	serviceName         = "ads"
+)
+
+// Setup installs the global tracer provider and the traceparent propagator.
+// The returned function flushes the spans and closes the exporter.
+func Setup(exporter sdktrace.SpanExporter) func(context.Context) error {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	if exporter == nil {
+		return func(context.Context) error { return nil }
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown
+}
+
+// NewExporter makes the exporter of the config, nil for "none". The file
+// exporter appends to path, so traces of several runs can be collected.
+func NewExporter(kind string, path string) (sdktrace.SpanExporter, error) {
+	var w io.Writer
+	switch kind {
+	case "none":
+		return nil, nil
+	case "stdout":
+		w = os.Stdout
+	case "file":
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		return fileExporter{stdoutExporter(f), f}, nil
+	default:
+		return nil, fmt.Errorf("unknown span exporter %q", kind)
+	}
+	return stdoutExporter(w), nil
+}
+
+func stdoutExporter(w io.Writer) sdktrace.SpanExporter {
+	// the options only fail on invalid values, there are none here
+	exp, _ := stdouttrace.New(stdouttrace.WithWriter(w))
+	return exp
+}
+
+// fileExporter closes the file when the exporter shuts down.
+type fileExporter struct {
+	sdktrace.SpanExporter
+	f *os.File
+}
+
+func (e fileExporter) Shutdown(ctx context.Context) error {
+	err := e.SpanExporter.Shutdown(ctx)
+	if cerr := e.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Start begins a span of the service, the global provider is looked up on
+// every call so that Setup may run after the wrappers are built.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// end marks the span failed when err is set and ends it.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,439 @@
+// Package transfer encodes users and ads as JSON Lines or CSV for moving
+// data between environments.
+package transfer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework9/internal/ads"
+	"homework9/internal/users"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatJSONL Format = "jsonl"
+	FormatCSV   Format = "csv"
+)
+
+// ParseFormat accepts the format names used in query strings, JSON Lines
+// is the default.
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case "", FormatJSONL:
+		return FormatJSONL, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	}
+	return "", fmt.Errorf("unknown format %q", s)
+}
+
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv"
+	}
+	return "application/x-ndjson"
+}
+
+type Kind string
+
+const (
+	KindUser Kind = "user"
+	KindAd   Kind = "ad"
+)
+
+// Record is one line of an export, either a user or an ad.
+type Record struct {
+	Kind Kind
+	User users.User
+	Ad   ads.Ad
+}
+
+// LineError is a record that could not be decoded. Decoding can go on
+// with the next record after it.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// row is the flat shape shared by both formats.
+type row struct {
+	Type         Kind       `json:"type"`
+	ID           int64      `json:"id"`
+	Nickname     string     `json:"nickname,omitempty"`
+	Email        string     `json:"email,omitempty"`
+	Role         string     `json:"role,omitempty"`
+	Banned       bool       `json:"banned,omitempty"`
+	Title        string     `json:"title,omitempty"`
+	Text         string     `json:"text,omitempty"`
+	AuthorID     int64      `json:"author_id,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	RejectReason string     `json:"reject_reason,omitempty"`
+	DateCreate   *time.Time `json:"date_create,omitempty"`
+	DateUpdate   *time.Time `json:"date_update,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	PublishAt    *time.Time `json:"publish_at,omitempty"`
+}
+
+var columns = []string{
+	"type", "id", "nickname", "email", "role", "banned", "title", "text", "author_id",
+	"status", "reject_reason", "date_create", "date_update", "expires_at", "publish_at",
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func timeValue(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+func newRow(rec Record) row {
+	if rec.Kind == KindUser {
+		u := rec.User
+		return row{Type: KindUser, ID: u.ID, Nickname: u.Nickname, Email: u.Email, Role: string(u.Role), Banned: u.Banned}
+	}
+	ad := rec.Ad
+	return row{
+		Type:         KindAd,
+		ID:           ad.ID,
+		Title:        ad.Title,
+		Text:         ad.Text,
+		AuthorID:     ad.AuthorID,
+		Status:       string(ad.Status),
+		RejectReason: ad.RejectReason,
+		DateCreate:   timePtr(ad.DateCreate),
+		DateUpdate:   timePtr(ad.DateUpdate),
+		ExpiresAt:    timePtr(ad.ExpiresAt),
+		PublishAt:    timePtr(ad.PublishAt),
+	}
+}
+
+func (r row) record() (Record, error) {
+	switch r.Type {
+	case KindUser:
+		return Record{Kind: KindUser, User: users.User{
+			ID:       r.ID,
+			Nickname: r.Nickname,
+			Email:    r.Email,
+			Role:     users.Role(r.Role),
+			Banned:   r.Banned,
+		}}, nil
+	case KindAd:
+		return Record{Kind: KindAd, Ad: ads.Ad{
+			ID:           r.ID,
+			Title:        r.Title,
+			Text:         r.Text,
+			AuthorID:     r.AuthorID,
+			Status:       ads.Status(r.Status),
+			RejectReason: r.RejectReason,
+			DateCreate:   timeValue(r.DateCreate),
+			DateUpdate:   timeValue(r.DateUpdate),
+			ExpiresAt:    timeValue(r.ExpiresAt),
+			PublishAt:    timeValue(r.PublishAt),
+		}}, nil
+	}
+	return Record{}, fmt.Errorf("unknown record type %q", r.Type)
+}
+
+type Encoder interface {
+	// Encode writes one record with a single Write call to the underlying
+	// writer, so every record can be flushed to the client on its own.
+	Encode(rec Record) error
+}
+
+func NewEncoder(w io.Writer, f Format) Encoder {
+	if f == FormatCSV {
+		return &csvEncoder{w: csv.NewWriter(w)}
+	}
+	return jsonEncoder{enc: json.NewEncoder(w)}
+}
+
+type jsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e jsonEncoder) Encode(rec Record) error {
+	return e.enc.Encode(newRow(rec))
+}
+
+type csvEncoder struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (e *csvEncoder) Encode(rec Record) error {
+	if !e.headerWritten {
+		if err := e.w.Write(columns); err != nil {
+			return err
+		}
+		e.headerWritten = true
+	}
+	r := newRow(rec)
+	values := map[string]string{
+		"type":          string(r.Type),
+		"id":            strconv.FormatInt(r.ID, 10),
+		"nickname":      r.Nickname,
+		"email":         r.Email,
+		"role":          r.Role,
+		"title":         r.Title,
+		"text":          r.Text,
+		"status":        r.Status,
+		"reject_reason": r.RejectReason,
+		"date_create":   formatTime(r.DateCreate),
+		"date_update":   formatTime(r.DateUpdate),
+		"expires_at":    formatTime(r.ExpiresAt),
+		"publish_at":    formatTime(r.PublishAt),
+	}
+	if r.Type == KindUser {
+		values["banned"] = strconv.FormatBool(r.Banned)
+	} else {
+		values["author_id"] = strconv.FormatInt(r.AuthorID, 10)
+	}
+	line := make([]string, len(columns))
+	for i, col := range columns {
+		line[i] = values[col]
+	}
+	if err := e.w.Write(line); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+type Decoder interface {
+	// Decode returns the next record, io.EOF at the end of the input and a
+	// *LineError for a record that is malformed. Other errors are fatal.
+	Decode() (Record, error)
+	// Line is the line of the record returned by the last Decode.
+	Line() int
+}
+
+func NewDecoder(r io.Reader, f Format) Decoder {
+	if f == FormatCSV {
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		return &csvDecoder{r: reader}
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	return &jsonDecoder{s: scanner}
+}
+
+type jsonDecoder struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func (d *jsonDecoder) Line() int {
+	return d.line
+}
+
+func (d *jsonDecoder) Decode() (Record, error) {
+	for d.s.Scan() {
+		d.line++
+		if len(d.s.Bytes()) == 0 {
+			continue
+		}
+		var r row
+		if err := json.Unmarshal(d.s.Bytes(), &r); err != nil {
+			return Record{}, &LineError{Line: d.line, Err: err}
+		}
+		rec, err := r.record()
+		if err != nil {
+			return Record{}, &LineError{Line: d.line, Err: err}
+		}
+		return rec, nil
+	}
+	if err := d.s.Err(); err != nil {
+		return Record{}, err
+	}
+	return Record{}, io.EOF
+}
+
+type csvDecoder struct {
+	r      *csv.Reader
+	header map[string]int
+	line   int
+}
+
+func (d *csvDecoder) Line() int {
+	return d.line
+}
+
+func (d *csvDecoder) Decode() (Record, error) {
+	if d.header == nil {
+		names, err := d.r.Read()
+		if err != nil {
+			return Record{}, err
+		}
+		d.header = make(map[string]int, len(names))
+		for i, name := range names {
+			d.header[name] = i
+		}
+		if _, ok := d.header["type"]; !ok {
+			return Record{}, errors.New("csv header has no type column")
+		}
+	}
+	fields, err := d.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			d.line = parseErr.Line
+			return Record{}, &LineError{Line: parseErr.Line, Err: parseErr.Err}
+		}
+		return Record{}, err
+	}
+	d.line, _ = d.r.FieldPos(0)
+	rec, err := d.parse(fields)
+	if err != nil {
+		return Record{}, &LineError{Line: d.line, Err: err}
+	}
+	return rec, nil
+}
+
+func (d *csvDecoder) parse(fields []string) (Record, error) {
+	get := func(name string) string {
+		i, ok := d.header[name]
+		if !ok || i >= len(fields) {
+			return ""
+		}
+		return fields[i]
+	}
+	r := row{
+		Type:         Kind(get("type")),
+		Nickname:     get("nickname"),
+		Email:        get("email"),
+		Role:         get("role"),
+		Title:        get("title"),
+		Text:         get("text"),
+		Status:       get("status"),
+		RejectReason: get("reject_reason"),
+	}
+	var err error
+	if r.ID, err = parseInt(get("id")); err != nil {
+		return Record{}, fmt.Errorf("id: %w", err)
+	}
+	if r.AuthorID, err = parseInt(get("author_id")); err != nil {
+		return Record{}, fmt.Errorf("author_id: %w", err)
+	}
+	if banned := get("banned"); banned != "" {
+		if r.Banned, err = strconv.ParseBool(banned); err != nil {
+			return Record{}, fmt.Errorf("banned: %w", err)
+		}
+	}
+	for name, dst := range map[string]**time.Time{
+		"date_create": &r.DateCreate,
+		"date_update": &r.DateUpdate,
+		"expires_at":  &r.ExpiresAt,
+		"publish_at":  &r.PublishAt,
+	} {
+		if *dst, err = parseTime(get(name)); err != nil {
+			return Record{}, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return r.record()
+}
+
+func parseInt(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func parseTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Batch is a decoded import split by kind. UserLines and AdLines hold the
+// line of every user and ad, Errors the records that could not be decoded.
+type Batch struct {
+	Users     []users.User
+	UserLines []int
+	Ads       []ads.Ad
+	AdLines   []int
+	Errors    []*LineError
+}
+
+// ReadAll decodes the whole input, it fails only on errors that stop the
+// decoding.
+func ReadAll(d Decoder) (Batch, error) {
+	var b Batch
+	for {
+		rec, err := d.Decode()
+		if errors.Is(err, io.EOF) {
+			return b, nil
+		}
+		var lineErr *LineError
+		if errors.As(err, &lineErr) {
+			b.Errors = append(b.Errors, lineErr)
+			continue
+		}
+		if err != nil {
+			return Batch{}, err
+		}
+		if rec.Kind == KindUser {
+			b.Users = append(b.Users, rec.User)
+			b.UserLines = append(b.UserLines, d.Line())
+		} else {
+			b.Ads = append(b.Ads, rec.Ad)
+			b.AdLines = append(b.AdLines, d.Line())
+		}
+	}
+}
+
+// LineErrors joins the decoding errors with the errors of storing the
+// users and ads of the batch, ordered by line.
+func (b Batch) LineErrors(userErrs []error, adErrs []error) []*LineError {
+	all := append([]*LineError(nil), b.Errors...)
+	for i, err := range userErrs {
+		if err != nil {
+			all = append(all, &LineError{Line: b.UserLines[i], Err: err})
+		}
+	}
+	for i, err := range adErrs {
+		if err != nil {
+			all = append(all, &LineError{Line: b.AdLines[i], Err: err})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Line < all[j].Line
+	})
+	return all
+}
@@ -1,7 +1,25 @@
 package users
 
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
 type User struct {
 	ID       int64
 	Nickname string
 	Email    string
+	Role     Role
+	Banned   bool
 }
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"homework9/internal/identity"
+	"net/http"
+	"strconv"
+)
+
+// UserIDHeader carries the user a request acts for. The service has no
+// logins of its own, the authenticating proxy in front of it sets the
+// header and strips it from the requests of clients.
+const UserIDHeader = "X-User-ID"
+
+// Actor puts the user of UserIDHeader into the request context, handlers
+// fall back to the IDs in bodies and queries of old clients without it.
+func Actor(c *gin.Context) {
+	header := c.GetHeader(UserIDHeader)
+	if header == "" {
+		c.Next()
+		return
+	}
+	id, err := strconv.ParseInt(header, 10, 64)
+	if err != nil {
+		abortWithError(c, http.StatusUnauthorized, "unauthenticated", "invalid "+UserIDHeader)
+		return
+	}
+	c.Request = c.Request.WithContext(identity.WithUser(c.Request.Context(), id))
+	c.Next()
+}
+
+// UserIDMetadata is UserIDHeader of gRPC calls.
+const UserIDMetadata = "x-user-id"
+
+// ActorUnaryServerInterceptor puts the user of UserIDMetadata into the call
+// context, the services of ad.v2 take the actor from there.
+func ActorUnaryServerInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := withActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func ActorStreamServerInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := withActor(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ss, ctx})
+}
+
+func withActor(ctx context.Context) (context.Context, error) {
+	values := metadata.ValueFromIncomingContext(ctx, UserIDMetadata)
+	if len(values) == 0 {
+		return ctx, nil
+	}
+	id, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid "+UserIDMetadata)
+	}
+	return identity.WithUser(ctx, id), nil
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+const errorFormatKey = "middleware.errorFormat"
+
+// ErrorFormat builds the body of the errors the middleware answers with,
+// code names the error for the envelopes that carry one.
+type ErrorFormat func(code string, message string) any
+
+// WithErrorFormat makes the middleware after it answer errors in the
+// envelope of f instead of the one of /api/v1.
+func WithErrorFormat(f ErrorFormat) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(errorFormatKey, f)
+		c.Next()
+	}
+}
+
+func abortWithError(c *gin.Context, status int, code string, message string) {
+	if f, ok := c.Value(errorFormatKey).(ErrorFormat); ok {
+		c.AbortWithStatusJSON(status, f(code, message))
+		return
+	}
+	c.AbortWithStatusJSON(status, gin.H{"data": nil, "error": message})
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+// etagCacheControl lets clients keep a response but ask whether it is
+// still current before using it, with ETag the answer is a bodyless 304.
+const etagCacheControl = "private, no-cache"
+
+// ETag tags the 200 responses of a GET with a hash of the body and answers
+// 304 Not Modified when If-None-Match already names it.
+func ETag(c *gin.Context) {
+	if c.Request.Method != http.MethodGet {
+		c.Next()
+		return
+	}
+	w := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+	c.Writer = w
+	// on a panic of the handler the buffered body is dropped, Recover has
+	// to write its error to the client and not to the buffer
+	defer func() {
+		c.Writer = w.ResponseWriter
+	}()
+	c.Next()
+
+	if w.status != http.StatusOK {
+		w.flush(w.status)
+		return
+	}
+	sum := sha256.Sum256(w.body.Bytes())
+	tag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", tag)
+	c.Header("Cache-Control", etagCacheControl)
+	if etagMatch(c.GetHeader("If-None-Match"), tag) {
+		w.body.Reset()
+		w.Header().Del("Content-Type")
+		w.flush(http.StatusNotModified)
+		return
+	}
+	w.flush(http.StatusOK)
+}
+
+// etagMatch is the weak comparison If-None-Match calls for.
+func etagMatch(header string, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedWriter holds back the response until ETag knows its status.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return false
+}
+
+func (w *bufferedWriter) flush(status int) {
+	w.ResponseWriter.WriteHeader(status)
+	w.ResponseWriter.WriteHeaderNow()
+	_, _ = w.ResponseWriter.Write(w.body.Bytes())
+}
@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"homework9/internal/idempotency"
+	"homework9/internal/identity"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// IdempotencyKeyHeader lets a client retry a create request without
+// creating the resource twice.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyKeyMetadata is IdempotencyKeyHeader of gRPC calls.
+const IdempotencyKeyMetadata = "idempotency-key"
+
+// replayedHeader marks a response that was stored for an earlier request.
+const replayedHeader = "Idempotent-Replayed"
+
+type storedResponse struct {
+	status      int
+	contentType string
+	location    string
+	body        []byte
+}
+
+// Idempotency answers a POST with an Idempotency-Key the client sent
+// before with the stored response. A key reused with another body or path
+// is answered with 422, a key whose request still runs with 409.
+func Idempotency(s *idempotency.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			if body, err = io.ReadAll(c.Request.Body); err != nil {
+				abortWithError(c, http.StatusBadRequest, "bad_request", err.Error())
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		scoped := idempotencyScope(c.Request.Context()) + " " + key
+		fingerprint := idempotency.Fingerprint([]byte(c.Request.Method), []byte(c.Request.URL.RequestURI()), canonicalJSON(body))
+		result, replay, err := s.Begin(scoped, fingerprint)
+		switch {
+		case errors.Is(err, idempotency.ErrKeyReused):
+			abortWithError(c, http.StatusUnprocessableEntity, "idempotency_key_reused", err.Error())
+			return
+		case errors.Is(err, idempotency.ErrInProgress):
+			abortWithError(c, http.StatusConflict, "idempotency_key_in_use", err.Error())
+			return
+		}
+		if replay {
+			resp := result.(storedResponse)
+			if resp.location != "" {
+				c.Header("Location", resp.location)
+			}
+			c.Header(replayedHeader, "true")
+			c.Data(resp.status, resp.contentType, resp.body)
+			c.Abort()
+			return
+		}
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		if w.Status() < 200 || w.Status() >= 300 {
+			s.Release(scoped)
+			return
+		}
+		s.Finish(scoped, storedResponse{
+			status:      w.Status(),
+			contentType: w.Header().Get("Content-Type"),
+			location:    w.Header().Get("Location"),
+			body:        w.body.Bytes(),
+		})
+	}
+}
+
+// IdempotencyUnaryServerInterceptor is Idempotency for the Create methods
+// of the gRPC services, the key comes in IdempotencyKeyMetadata.
+func IdempotencyUnaryServerInterceptor(s *idempotency.Store) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		values := metadata.ValueFromIncomingContext(ctx, IdempotencyKeyMetadata)
+		msg, ok := req.(proto.Message)
+		if len(values) == 0 || values[0] == "" || !ok || !isCreateMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		payload, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		scoped := idempotencyScope(ctx) + " " + values[0]
+		result, replay, err := s.Begin(scoped, idempotency.Fingerprint([]byte(info.FullMethod), payload))
+		switch {
+		case errors.Is(err, idempotency.ErrKeyReused):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, idempotency.ErrInProgress):
+			return nil, status.Error(codes.Aborted, err.Error())
+		}
+		if replay {
+			_ = grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(replayedHeader), "true"))
+			return proto.Clone(result.(proto.Message)), nil
+		}
+
+		resp, err := handler(ctx, req)
+		if m, ok := resp.(proto.Message); ok && err == nil {
+			s.Finish(scoped, proto.Clone(m))
+		} else {
+			s.Release(scoped)
+		}
+		return resp, err
+	}
+}
+
+// idempotencyScope keeps the keys of users apart, requests of old clients
+// without X-User-ID share one scope.
+func idempotencyScope(ctx context.Context) string {
+	if id, ok := identity.User(ctx); ok {
+		return "user:" + strconv.FormatInt(id, 10)
+	}
+	if name, ok := identity.Client(ctx); ok {
+		return "client:" + name
+	}
+	return "anonymous"
+}
+
+func isCreateMethod(fullMethod string) bool {
+	name := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	return strings.HasPrefix(name, "Create")
+}
+
+// canonicalJSON makes bodies that differ only in spacing and key order
+// match, other bodies are compared as they are.
+func canonicalJSON(body []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return body
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// recordingWriter keeps a copy of the response body.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
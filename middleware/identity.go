@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"homework9/internal/identity"
+	"homework9/internal/tlsconfig"
+)
+
+// IdentityUnaryServerInterceptor names the client after its verified TLS
+// certificate, calls over plaintext or without a certificate stay anonymous.
+func IdentityUnaryServerInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withCertIdentity(ctx), req)
+}
+
+func IdentityStreamServerInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &contextStream{ss, withCertIdentity(ss.Context())})
+}
+
+func withCertIdentity(ctx context.Context) context.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ctx
+	}
+	name, err := tlsconfig.Identity(info.State)
+	if err != nil {
+		return ctx
+	}
+	return identity.WithClient(ctx, name)
+}
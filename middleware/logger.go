@@ -4,25 +4,96 @@ import (
 	"context"
 	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc"
-	"log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"homework9/internal/logging"
+	"log/slog"
+	"net/http"
 	"time"
 )
 
+// Logger assigns the request ID, returns it in X-Request-ID and logs the
+// request once it is handled. Handlers get the ID through the context of
+// the request, so the router has to use ContextWithFallback.
 func Logger(c *gin.Context) {
 	t := time.Now()
+	id := logging.RequestIDFrom(c.GetHeader(logging.RequestIDHeader))
+	c.Header(logging.RequestIDHeader, id)
+	ctx := logging.WithRequestID(c.Request.Context(), id)
+	c.Request = c.Request.WithContext(ctx)
+
 	c.Next()
-	latency := time.Since(t)
+
 	status := c.Writer.Status()
-	log.Println("latency:", latency, "method:", c.Request.Method, "path:", c.Request.URL.Path, "status:", status)
+	level := slog.LevelInfo
+	switch {
+	case status >= http.StatusInternalServerError:
+		level = slog.LevelError
+	case status >= http.StatusBadRequest:
+		level = slog.LevelWarn
+	}
+	slog.Log(ctx, level, "http request",
+		"method", c.Request.Method,
+		"route", c.FullPath(),
+		"path", c.Request.URL.Path,
+		"status", status,
+		"latency", time.Since(t),
+	)
 }
 
+// LoggerUnaryServerInterceptor does for gRPC what Logger does for HTTP, the
+// request ID travels in the x-request-id metadata.
 func LoggerUnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
 	t := time.Now()
+	id := grpcRequestID(ctx)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(logging.RequestIDMetadata, id))
+	ctx = logging.WithRequestID(ctx, id)
+
 	h, err := handler(ctx, req)
-	latency := time.Since(t)
-	log.Printf("Request - Method:%s\tDuration:%s\tError:%v\n\n", // no lint
-		info.FullMethod, //no lint
-		latency,         //no lint
-		err)             //no lint
+
+	logGRPC(ctx, info.FullMethod, t, err)
 	return h, err
 }
+
+func LoggerStreamServerInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	t := time.Now()
+	id := grpcRequestID(ss.Context())
+	_ = ss.SetHeader(metadata.Pairs(logging.RequestIDMetadata, id))
+	ctx := logging.WithRequestID(ss.Context(), id)
+
+	err := handler(srv, &contextStream{ss, ctx})
+
+	logGRPC(ctx, info.FullMethod, t, err)
+	return err
+}
+
+// grpcRequestID keeps the ID an outer interceptor already assigned, else
+// takes the one of the metadata.
+func grpcRequestID(ctx context.Context) string {
+	if id := logging.RequestID(ctx); id != "" {
+		return id
+	}
+	var sent string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(logging.RequestIDMetadata); len(ids) > 0 {
+			sent = ids[0]
+		}
+	}
+	return logging.RequestIDFrom(sent)
+}
+
+func logGRPC(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	if code == codes.Internal || code == codes.Unknown {
+		level = slog.LevelError
+	} else if err != nil {
+		level = slog.LevelWarn
+	}
+	attrs := []any{"method", method, "code", code.String(), "latency", time.Since(start)}
+	if err != nil {
+		attrs = append(attrs, "error", err.Error())
+	}
+	slog.Log(ctx, level, "grpc request", attrs...)
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"homework9/internal/metrics"
+)
+
+// Metrics records the requests of every route. The route is the pattern,
+// not the path, so ad IDs do not blow up the number of series.
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		done := m.TrackHTTP(c.Request.Method, route)
+		c.Next()
+		done(c.Writer.Status())
+	}
+}
+
+func MetricsUnaryServerInterceptor(m *metrics.Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		done := m.TrackGRPC(info.FullMethod)
+		resp, err := handler(ctx, req)
+		done(status.Code(err).String())
+		return resp, err
+	}
+}
+
+func MetricsStreamServerInterceptor(m *metrics.Metrics) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		done := m.TrackGRPC(info.FullMethod)
+		err := handler(srv, ss)
+		done(status.Code(err).String())
+		return err
+	}
+}
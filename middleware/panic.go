@@ -2,28 +2,63 @@ package middleware
 
 import (
 	"context"
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"homework9/internal/logging"
+	"log/slog"
 	"net/http"
+	"runtime/debug"
 )
 
+// internalErrorMessage is all a client learns about a panic, the details
+// stay in the log.
+const internalErrorMessage = "internal server error"
+
+// Recover turns a panic of a handler into 500 with the usual error
+// envelope. The panic value and the stack go to the log with the request ID.
+// It goes first to catch the panics of the other middleware too.
 func Recover(c *gin.Context) {
 	defer func() {
-		if err := recover(); err != nil {
-			fmt.Println("panicMiddleware", c.Request.URL.Path)
-			fmt.Println("recovered", err)
-			c.JSON(http.StatusInternalServerError, err)
+		if p := recover(); p != nil {
+			if p == http.ErrAbortHandler {
+				// the client went away, net/http handles this one quietly
+				panic(p)
+			}
+			logPanic(c.Request.Context(), p, "path", c.Request.URL.Path)
+			abortWithError(c, http.StatusInternalServerError, "internal", internalErrorMessage)
 		}
 	}()
 	c.Next()
 }
-func PanicUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (_ any, err error) {
+
+// PanicUnaryInterceptor is Recover for gRPC. It goes first in the chain to
+// catch the panics of the other interceptors too, so it assigns the request
+// ID the logger interceptor then reuses.
+func PanicUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	ctx = logging.WithRequestID(ctx, grpcRequestID(ctx))
 	defer func() {
-		if err := recover(); err != nil {
-			fmt.Println("gRPC panic Middleware", info.FullMethod, '\n', "recovered", err)
+		if p := recover(); p != nil {
+			logPanic(ctx, p, "method", info.FullMethod)
+			resp, err = nil, status.Error(codes.Internal, internalErrorMessage)
 		}
 	}()
-	resp, err := handler(ctx, req)
-	return resp, err
+	return handler(ctx, req)
+}
+
+func PanicStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	ctx := logging.WithRequestID(ss.Context(), grpcRequestID(ss.Context()))
+	defer func() {
+		if p := recover(); p != nil {
+			logPanic(ctx, p, "method", info.FullMethod)
+			err = status.Error(codes.Internal, internalErrorMessage)
+		}
+	}()
+	return handler(srv, &contextStream{ss, ctx})
+}
+
+func logPanic(ctx context.Context, p any, attrs ...any) {
+	attrs = append(attrs, "panic", p, "stack", string(debug.Stack()))
+	slog.ErrorContext(ctx, "panic recovered", attrs...)
 }
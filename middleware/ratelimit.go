@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"homework9/internal/identity"
+	"homework9/internal/ratelimit"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const rateLimitMessage = "rate limit exceeded"
+
+// RateLimit answers 429 with Retry-After once the client used up its
+// bucket for the route. Routes are named "POST /api/v1/ads" in the config.
+// It has to run after Actor, see limitKey for who owns a bucket.
+func RateLimit(l *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client, ok := limitKey(c.Request.Context())
+		if !ok {
+			client = "ip:" + c.ClientIP()
+		}
+		ok, wait := l.Allow(c.Request.Method+" "+c.FullPath(), client)
+		if !ok {
+			slog.InfoContext(c.Request.Context(), "rate limited", "client", client, "route", c.FullPath())
+			c.Header("Retry-After", strconv.Itoa(ratelimit.RetryAfterSeconds(wait)))
+			abortWithError(c, http.StatusTooManyRequests, "rate_limited", rateLimitMessage)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RateLimitUnaryServerInterceptor is RateLimit for gRPC, routes are full
+// method names and the wait goes to the retry-after header and RetryInfo.
+// It has to run after ActorUnaryServerInterceptor.
+func RateLimitUnaryServerInterceptor(l *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		client := grpcClient(ctx)
+		ok, wait := l.Allow(info.FullMethod, client)
+		if !ok {
+			slog.InfoContext(ctx, "rate limited", "client", client, "method", info.FullMethod)
+			return nil, retryAfterError(ctx, codes.ResourceExhausted, rateLimitMessage, wait)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// retryAfterError builds a status error telling the client when to retry.
+func retryAfterError(ctx context.Context, code codes.Code, msg string, wait time.Duration) error {
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(ratelimit.RetryAfterSeconds(wait))))
+	st, err := status.New(code, msg).WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)})
+	if err != nil {
+		return status.Error(code, msg)
+	}
+	return st.Err()
+}
+
+// limitKey names the bucket owner of a request: the client of its
+// certificate, or the user it acts for when that client vouches for it.
+// Anyone can send a user ID, so without a certificate the caller falls back
+// to the address.
+func limitKey(ctx context.Context) (string, bool) {
+	name, ok := identity.Client(ctx)
+	if !ok {
+		return "", false
+	}
+	if id, ok := identity.User(ctx); ok {
+		return "client:" + name + ":user:" + strconv.FormatInt(id, 10), true
+	}
+	return "client:" + name, true
+}
+
+func grpcClient(ctx context.Context) string {
+	if key, ok := limitKey(ctx); ok {
+		return key
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "ip:unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return "ip:" + p.Addr.String()
+	}
+	return "ip:" + host
+}
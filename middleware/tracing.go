@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"homework9/internal/tracing"
+	"net/http"
+)
+
+// Tracing starts the server span of a request, continuing the trace of
+// the traceparent header when the client sent one.
+func Tracing(c *gin.Context) {
+	ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	ctx, span := tracing.Start(ctx, c.Request.Method+" "+route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.method", c.Request.Method),
+			attribute.String("http.route", route),
+		),
+	)
+	defer span.End()
+	c.Request = c.Request.WithContext(ctx)
+
+	c.Next()
+
+	code := c.Writer.Status()
+	span.SetAttributes(attribute.Int("http.status_code", code))
+	if code >= http.StatusInternalServerError {
+		span.SetStatus(otelcodes.Error, http.StatusText(code))
+	}
+}
+
+func TracingUnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, span := startGRPCSpan(ctx, info.FullMethod)
+	defer span.End()
+	resp, err := handler(ctx, req)
+	endGRPCSpan(span, err)
+	return resp, err
+}
+
+func TracingStreamServerInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := startGRPCSpan(ss.Context(), info.FullMethod)
+	defer span.End()
+	err := handler(srv, &contextStream{ss, ctx})
+	endGRPCSpan(span, err)
+	return err
+}
+
+func startGRPCSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	return tracing.Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("rpc.system", "grpc"), attribute.String("rpc.method", method)),
+	)
+}
+
+func endGRPCSpan(span trace.Span, err error) {
+	code := status.Code(err)
+	span.SetAttributes(attribute.String("rpc.grpc.status_code", code.String()))
+	if err != nil {
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+}
+
+// metadataCarrier reads the traceparent from gRPC metadata, whose keys are
+// lower case like the ones the propagator asks for.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if v := metadata.MD(c).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// contextStream replaces the context of a server stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}